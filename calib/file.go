@@ -0,0 +1,196 @@
+package calib
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// FileFormatVersion is the version of the calibration files written by this package.
+const FileFormatVersion = 1
+
+var (
+	// ErrInvalidDEID signals an unknown detection element.
+	ErrInvalidDEID = errors.New("invalid detection element id")
+	// ErrInvalidPadUID signals a pad that does not exist in its detection element.
+	ErrInvalidPadUID = errors.New("invalid pad uid")
+	// ErrUnsupportedVersion signals a calibration file with an unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported calibration file version")
+	// ErrInvalidStatus signals an unknown channel status.
+	ErrInvalidStatus = errors.New("invalid channel status")
+	// ErrInvalidCSV signals a malformed CSV calibration file.
+	ErrInvalidCSV = errors.New("invalid CSV calibration file")
+)
+
+// Status is the result of the calibration of one channel.
+type Status int
+
+const (
+	// OK is a channel with a reasonable pedestal and noise.
+	OK Status = iota
+	// Dead is a channel without signal or with an unreasonable pedestal.
+	Dead
+	// Noisy is a channel with a too large noise.
+	Noisy
+)
+
+var statusNames = []string{"ok", "dead", "noisy"}
+
+func (s Status) String() string {
+	if s < 0 || int(s) >= len(statusNames) {
+		return "unknown"
+	}
+	return statusNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Status) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(statusNames) {
+		return nil, ErrInvalidStatus
+	}
+	return []byte(statusNames[s]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Status) UnmarshalText(text []byte) error {
+	for i, n := range statusNames {
+		if n == string(text) {
+			*s = Status(i)
+			return nil
+		}
+	}
+	return ErrInvalidStatus
+}
+
+// Pedestal is the calibration of one channel.
+type Pedestal struct {
+	ChannelKey
+	PadUID  mapping.PadUID
+	Entries int64
+	Mean    float64
+	RMS     float64
+	Status  Status
+}
+
+// Calibration is the set of the pedestals of several channels.
+type Calibration struct {
+	Version   int
+	Pedestals []Pedestal
+}
+
+// Find returns the pedestal of one pad of one detection element.
+func (c *Calibration) Find(deid mapping.DEID, paduid mapping.PadUID) (Pedestal, bool) {
+	for _, p := range c.Pedestals {
+		if p.DEID == deid && p.PadUID == paduid {
+			return p, true
+		}
+	}
+	return Pedestal{}, false
+}
+
+// WriteJSON writes the calibration in JSON format.
+func (c *Calibration) WriteJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", " ")
+	return enc.Encode(c)
+}
+
+// ReadJSON reads a calibration in JSON format.
+func ReadJSON(in io.Reader) (*Calibration, error) {
+	var c Calibration
+	if err := json.NewDecoder(in).Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Version != FileFormatVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	return &c, nil
+}
+
+var csvHeader = []string{"deid", "dsid", "channel", "paduid", "entries", "mean", "rms", "status"}
+
+// WriteCSV writes the calibration in CSV format. The first line
+// is a comment holding the version of the format.
+func (c *Calibration) WriteCSV(out io.Writer) error {
+	if _, err := fmt.Fprintf(out, "# version %d\n", c.Version); err != nil {
+		return err
+	}
+	w := csv.NewWriter(out)
+	w.Write(csvHeader)
+	for _, p := range c.Pedestals {
+		w.Write([]string{
+			strconv.Itoa(int(p.DEID)),
+			strconv.Itoa(int(p.DualSampaID)),
+			strconv.Itoa(int(p.Channel)),
+			strconv.Itoa(int(p.PadUID)),
+			strconv.FormatInt(p.Entries, 10),
+			strconv.FormatFloat(p.Mean, 'f', 3, 64),
+			strconv.FormatFloat(p.RMS, 'f', 3, 64),
+			p.Status.String(),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// ReadCSV reads a calibration in CSV format.
+func ReadCSV(in io.Reader) (*Calibration, error) {
+	c := Calibration{}
+	br := bufio.NewReader(in)
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, ErrInvalidCSV
+	}
+	var version int
+	if _, err := fmt.Sscanf(line, "# version %d", &version); err != nil {
+		return nil, ErrInvalidCSV
+	}
+	if version != FileFormatVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	c.Version = version
+	r := csv.NewReader(br)
+	r.FieldsPerRecord = len(csvHeader)
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrInvalidCSV
+	}
+	for _, rec := range records[1:] {
+		var ints [5]int64
+		for i := range ints {
+			ints[i], err = strconv.ParseInt(rec[i], 10, 64)
+			if err != nil {
+				return nil, ErrInvalidCSV
+			}
+		}
+		p := Pedestal{
+			ChannelKey: ChannelKey{
+				DEID:        mapping.DEID(ints[0]),
+				DualSampaID: mapping.DualSampaID(ints[1]),
+				Channel:     mapping.DualSampaChannelID(ints[2]),
+			},
+			PadUID:  mapping.PadUID(ints[3]),
+			Entries: ints[4],
+		}
+		if p.Mean, err = strconv.ParseFloat(rec[5], 64); err != nil {
+			return nil, ErrInvalidCSV
+		}
+		if p.RMS, err = strconv.ParseFloat(rec[6], 64); err != nil {
+			return nil, ErrInvalidCSV
+		}
+		if err = p.Status.UnmarshalText([]byte(rec[7])); err != nil {
+			return nil, err
+		}
+		c.Pedestals = append(c.Pedestals, p)
+	}
+	return &c, nil
+}
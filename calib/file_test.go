@@ -0,0 +1,60 @@
+package calib
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testCalibration = &Calibration{
+	Version: FileFormatVersion,
+	Pedestals: []Pedestal{
+		{ChannelKey: ChannelKey{100, 1, 2}, PadUID: 12, Entries: 100, Mean: 50.5, RMS: 1.25, Status: OK},
+		{ChannelKey: ChannelKey{100, 1, 3}, PadUID: 13, Entries: 0, Status: Dead},
+		{ChannelKey: ChannelKey{501, 1027, 63}, PadUID: 4000, Entries: 100, Mean: 60, RMS: 12.5, Status: Noisy},
+	},
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testCalibration.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"Status": "noisy"`) {
+		t.Errorf("expected status as text in %s", buf.String())
+	}
+	c, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, testCalibration) {
+		t.Errorf("expected %v and got %v", testCalibration, c)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testCalibration.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	c, err := ReadCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, testCalibration) {
+		t.Errorf("expected %v and got %v", testCalibration, c)
+	}
+}
+
+func TestUnsupportedVersion(t *testing.T) {
+	if _, err := ReadJSON(strings.NewReader(`{"Version":42}`)); err != ErrUnsupportedVersion {
+		t.Errorf("expected ErrUnsupportedVersion and got %v", err)
+	}
+	if _, err := ReadCSV(strings.NewReader("# version 42\n")); err != ErrUnsupportedVersion {
+		t.Errorf("expected ErrUnsupportedVersion and got %v", err)
+	}
+	if _, err := ReadCSV(strings.NewReader("deid,dsid\n")); err != ErrInvalidCSV {
+		t.Errorf("expected ErrInvalidCSV and got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package calib
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// ChannelKey identifies one electronic channel.
+type ChannelKey struct {
+	DEID        mapping.DEID
+	DualSampaID mapping.DualSampaID
+	Channel     mapping.DualSampaChannelID
+}
+
+func lessKey(a, b ChannelKey) bool {
+	if a.DEID != b.DEID {
+		return a.DEID < b.DEID
+	}
+	if a.DualSampaID != b.DualSampaID {
+		return a.DualSampaID < b.DualSampaID
+	}
+	return a.Channel < b.Channel
+}
+
+// runningStat computes the mean and variance of a stream of values,
+// using Welford's algorithm.
+type runningStat struct {
+	n    int64
+	mean float64
+	m2   float64
+}
+
+func (s *runningStat) add(v float64) {
+	s.n++
+	delta := v - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (v - s.mean)
+}
+
+func (s *runningStat) rms() float64 {
+	if s.n < 2 {
+		return 0
+	}
+	return math.Sqrt(s.m2 / float64(s.n-1))
+}
+
+// Accumulator accumulates the samples of pedestal runs, per channel.
+type Accumulator struct {
+	stats map[ChannelKey]*runningStat
+	segs  mapping.SegCache
+}
+
+// NewAccumulator returns an empty accumulator.
+func NewAccumulator() *Accumulator {
+	return &Accumulator{stats: make(map[ChannelKey]*runningStat)}
+}
+
+// AddSamples accumulates the samples of one channel.
+func (a *Accumulator) AddSamples(key ChannelKey, samples ...uint16) {
+	s := a.stats[key]
+	if s == nil {
+		s = &runningStat{}
+		a.stats[key] = s
+	}
+	for _, v := range samples {
+		s.add(float64(v))
+	}
+}
+
+// AddDigit accumulates one digit, considered as one measurement of
+// the average sample value of its channel (ADC/NofSamples).
+func (a *Accumulator) AddDigit(d digit.Digit) error {
+	seg := a.segs.Segmentation(d.DEID)
+	if seg == nil {
+		return ErrInvalidDEID
+	}
+	if d.PadUID < 0 || int(d.PadUID) >= seg.NofPads() {
+		return ErrInvalidPadUID
+	}
+	key := ChannelKey{d.DEID, seg.PadDualSampaID(d.PadUID), seg.PadDualSampaChannel(d.PadUID)}
+	s := a.stats[key]
+	if s == nil {
+		s = &runningStat{}
+		a.stats[key] = s
+	}
+	v := float64(d.ADC)
+	if d.NofSamples > 0 {
+		v /= float64(d.NofSamples)
+	}
+	s.add(v)
+	return nil
+}
+
+// Len returns the number of channels seen so far.
+func (a *Accumulator) Len() int {
+	return len(a.stats)
+}
+
+// Config holds the thresholds used to flag bad channels.
+type Config struct {
+	// MinEntries is the minimum number of entries for a channel to be
+	// considered alive.
+	MinEntries int64
+	// MinRMS and MaxRMS are the limits on the noise (in ADC counts) of a good
+	// channel. Below MinRMS the channel is considered dead, above MaxRMS noisy.
+	MinRMS float64
+	MaxRMS float64
+	// MinMean and MaxMean are the limits on the pedestal mean (in ADC counts)
+	// of a good channel. Outside those limits the channel is considered dead.
+	MinMean float64
+	MaxMean float64
+}
+
+// DefaultConfig are reasonable defaults for the pedestal calibration.
+var DefaultConfig = Config{
+	MinEntries: 10,
+	MinRMS:     0.1,
+	MaxRMS:     5,
+	MinMean:    1,
+	MaxMean:    500,
+}
+
+// Pedestals computes the pedestal and noise of all the channels of the
+// detection elements seen by the accumulator, and flags the dead and noisy ones.
+// Channels of those detection elements without any entry are flagged as dead.
+func (a *Accumulator) Pedestals(cfg Config) *Calibration {
+	c := &Calibration{Version: FileFormatVersion}
+	deids := make(map[mapping.DEID]bool)
+	for key, s := range a.stats {
+		deids[key.DEID] = true
+		p := Pedestal{
+			ChannelKey: key,
+			PadUID:     mapping.InvalidPadUID,
+			Entries:    s.n,
+			Mean:       s.mean,
+			RMS:        s.rms(),
+		}
+		p.Status = cfg.status(p)
+		if seg := a.segs.Segmentation(key.DEID); seg != nil {
+			if paduid, err := seg.FindPadByFEE(key.DualSampaID, key.Channel); err == nil {
+				p.PadUID = paduid
+			}
+		}
+		c.Pedestals = append(c.Pedestals, p)
+	}
+	for deid := range deids {
+		seg := a.segs.Segmentation(deid)
+		if seg == nil {
+			continue
+		}
+		seg.ForEachPad(func(paduid mapping.PadUID) {
+			key := ChannelKey{deid, seg.PadDualSampaID(paduid), seg.PadDualSampaChannel(paduid)}
+			if _, ok := a.stats[key]; !ok {
+				c.Pedestals = append(c.Pedestals, Pedestal{ChannelKey: key, PadUID: paduid, Status: Dead})
+			}
+		})
+	}
+	sort.Slice(c.Pedestals, func(i, j int) bool {
+		return lessKey(c.Pedestals[i].ChannelKey, c.Pedestals[j].ChannelKey)
+	})
+	return c
+}
+
+func (cfg Config) status(p Pedestal) Status {
+	if p.Entries < cfg.MinEntries {
+		return Dead
+	}
+	if p.Mean < cfg.MinMean || p.Mean > cfg.MaxMean || p.RMS < cfg.MinRMS {
+		return Dead
+	}
+	if p.RMS > cfg.MaxRMS {
+		return Noisy
+	}
+	return OK
+}
@@ -0,0 +1,101 @@
+package calib
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+func TestRunningStat(t *testing.T) {
+	var s runningStat
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	for _, v := range values {
+		s.add(v)
+	}
+	if s.mean != 5 {
+		t.Errorf("expected mean 5 and got %v", s.mean)
+	}
+	if rms := s.rms(); math.Abs(rms-math.Sqrt(32.0/7)) > 1e-12 {
+		t.Errorf("expected rms %v and got %v", math.Sqrt(32.0/7), rms)
+	}
+}
+
+func TestPedestals(t *testing.T) {
+	seg := mapping.NewSegmentation(501)
+	rng := rand.New(rand.NewSource(1))
+	acc := NewAccumulator()
+	noisy := ChannelKey{501, 4, 10}
+	dead := ChannelKey{501, 4, 11}
+	seg.ForEachPad(func(paduid mapping.PadUID) {
+		key := ChannelKey{501, seg.PadDualSampaID(paduid), seg.PadDualSampaChannel(paduid)}
+		if key == dead {
+			return
+		}
+		sigma := 1.0
+		if key == noisy {
+			sigma = 20
+		}
+		for i := 0; i < 100; i++ {
+			acc.AddSamples(key, uint16(math.Round(100+rng.NormFloat64()*sigma)))
+		}
+	})
+	c := acc.Pedestals(DefaultConfig)
+	if len(c.Pedestals) != seg.NofPads() {
+		t.Fatalf("expected %d channels and got %d", seg.NofPads(), len(c.Pedestals))
+	}
+	for _, p := range c.Pedestals {
+		expected := OK
+		switch p.ChannelKey {
+		case noisy:
+			expected = Noisy
+		case dead:
+			expected = Dead
+		}
+		if p.Status != expected {
+			t.Errorf("channel %v : expected status %v and got %v", p.ChannelKey, expected, p.Status)
+		}
+		paduid, _ := seg.FindPadByFEE(p.DualSampaID, p.Channel)
+		if p.PadUID != paduid {
+			t.Errorf("channel %v : expected pad %d and got %d", p.ChannelKey, paduid, p.PadUID)
+		}
+		if p.Status == OK && math.Abs(p.Mean-100) > 0.5 {
+			t.Errorf("channel %v : wrong mean %v", p.ChannelKey, p.Mean)
+		}
+	}
+}
+
+func TestAddDigit(t *testing.T) {
+	acc := NewAccumulator()
+	if err := acc.AddDigit(digit.Digit{DEID: 42}); err != ErrInvalidDEID {
+		t.Errorf("expected ErrInvalidDEID and got %v", err)
+	}
+	for _, paduid := range []mapping.PadUID{-1, 100000} {
+		if err := acc.AddDigit(digit.Digit{DEID: 100, PadUID: paduid}); err != ErrInvalidPadUID {
+			t.Errorf("pad %d : expected ErrInvalidPadUID and got %v", paduid, err)
+		}
+	}
+	if acc.Len() != 0 {
+		t.Errorf("invalid digits should not be accumulated")
+	}
+	for i := 0; i < 20; i++ {
+		acc.AddDigit(digit.Digit{DEID: 100, PadUID: 3, ADC: 500, NofSamples: 10})
+	}
+	if acc.Len() != 1 {
+		t.Fatalf("expected 1 channel and got %d", acc.Len())
+	}
+	p, ok := acc.Pedestals(DefaultConfig).Find(100, 3)
+	if !ok {
+		t.Fatal("could not find pad 3")
+	}
+	if p.Mean != 50 || p.Entries != 20 {
+		t.Errorf("expected 20 entries of mean 50 and got %v", p)
+	}
+	// no fluctuation at all is a dead channel
+	if p.Status != Dead {
+		t.Errorf("expected dead channel and got %v", p.Status)
+	}
+}
@@ -0,0 +1,197 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/mathieson"
+	"github.com/mrrtf/pigiron/precluster"
+	"github.com/mrrtf/pigiron/transform"
+)
+
+// Cluster is the reconstructed position of a particle crossing
+// a detection element.
+type Cluster struct {
+	DEID      mapping.DEID
+	X, Y      float64 // local position (cm)
+	EX, EY    float64 // uncertainties on X and Y (cm)
+	GX, GY    float64 // global position (cm)
+	GZ        float64
+	Charge    float64 // total charge (sum of both cathodes)
+	NofDigits int
+	Chi2      float64
+	Fitted    bool // false if the position is the center of gravity
+}
+
+func (c Cluster) String() string {
+	return fmt.Sprintf("CLUSTER DE %4d X %7.3f +- %5.3f Y %7.3f +- %5.3f (GX %8.3f GY %8.3f GZ %9.3f) Q %8.1f N %3d CHI2 %7.2f",
+		c.DEID, c.X, c.EX, c.Y, c.EY, c.GX, c.GY, c.GZ, c.Charge, c.NofDigits, c.Chi2)
+}
+
+var (
+	// ErrEmptyPrecluster signals an attempt to reconstruct a cluster without digits.
+	ErrEmptyPrecluster = errors.New("cannot find a cluster without digits")
+	// ErrNoCharge signals a precluster with a null total charge.
+	ErrNoCharge = errors.New("precluster has no charge")
+)
+
+// Config holds the parameters of the cluster finder.
+type Config struct {
+	MaxIterations int     // maximum number of iterations of the fit
+	Tolerance     float64 // fit convergence criteria on the position (cm)
+	// Fit is true to fit the Mathieson distribution, or false
+	// to only compute the center of gravity
+	Fit bool
+}
+
+// DefaultConfig are reasonable defaults for the cluster finder.
+var DefaultConfig = Config{MaxIterations: 100, Tolerance: 1e-5, Fit: true}
+
+// Finder reconstructs clusters from the preclusters of one detection element.
+type Finder struct {
+	seg       mapping.Segmentation
+	mathieson mathieson.Mathieson
+	tr        transform.Transformation
+	cfg       Config
+}
+
+// NewFinder returns a cluster finder for the detection element
+// described by seg.
+func NewFinder(seg mapping.Segmentation, cfg Config) (*Finder, error) {
+	m, err := mathieson.ForDetectionElement(seg.DetElemID())
+	if err != nil {
+		return nil, err
+	}
+	tr, err := transform.ForDetectionElement(seg.DetElemID())
+	if err != nil {
+		return nil, err
+	}
+	return &Finder{seg: seg, mathieson: m, tr: tr, cfg: cfg}, nil
+}
+
+// charge returns the charge of a digit.
+func charge(d digit.Digit) float64 {
+	return float64(d.ADC)
+}
+
+// cathodeCOG is the charge weighted center of gravity of one cathode
+type cathodeCOG struct {
+	x, y   float64
+	charge float64
+	// minimum pad sizes
+	dx, dy float64
+	n      int
+}
+
+func (c *cathodeCOG) add(seg mapping.Segmentation, d digit.Digit) {
+	q := charge(d)
+	c.x += q * seg.PadPositionX(d.PadUID)
+	c.y += q * seg.PadPositionY(d.PadUID)
+	c.charge += q
+	if c.n == 0 || seg.PadSizeX(d.PadUID) < c.dx {
+		c.dx = seg.PadSizeX(d.PadUID)
+	}
+	if c.n == 0 || seg.PadSizeY(d.PadUID) < c.dy {
+		c.dy = seg.PadSizeY(d.PadUID)
+	}
+	c.n++
+}
+
+func (c *cathodeCOG) finalize() {
+	if c.charge > 0 {
+		c.x /= c.charge
+		c.y /= c.charge
+	}
+}
+
+// CenterOfGravity returns the charge weighted center of gravity of the digits.
+// The x coordinate is taken from the non-bending cathode and the y
+// coordinate from the bending one, whenever those cathodes have some charge.
+// The returned uncertainties are the pad sizes divided by sqrt(12).
+func CenterOfGravity(seg mapping.Segmentation, digits []digit.Digit) (x, y, ex, ey float64, err error) {
+	b, nb, err := cogs(seg, digits)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return combine(b, nb)
+}
+
+func cogs(seg mapping.Segmentation, digits []digit.Digit) (b, nb cathodeCOG, err error) {
+	if len(digits) == 0 {
+		return b, nb, ErrEmptyPrecluster
+	}
+	for _, d := range digits {
+		if seg.IsBendingPad(d.PadUID) {
+			b.add(seg, d)
+		} else {
+			nb.add(seg, d)
+		}
+	}
+	if b.charge+nb.charge <= 0 {
+		return b, nb, ErrNoCharge
+	}
+	b.finalize()
+	nb.finalize()
+	return b, nb, nil
+}
+
+func combine(b, nb cathodeCOG) (x, y, ex, ey float64, err error) {
+	sqrt12 := math.Sqrt(12)
+	xc, yc := nb, b
+	if nb.charge <= 0 {
+		xc = b
+	}
+	if b.charge <= 0 {
+		yc = nb
+	}
+	return xc.x, yc.y, xc.dx / sqrt12, yc.dy / sqrt12, nil
+}
+
+// FindCluster reconstructs one cluster from the digits of one precluster.
+// The starting point is the center of gravity of the digits which is then,
+// if so configured, refined by a fit of the Mathieson distribution to the
+// charges of the pads of both cathodes.
+func (f *Finder) FindCluster(digits []digit.Digit) (Cluster, error) {
+	b, nb, err := cogs(f.seg, digits)
+	if err != nil {
+		return Cluster{}, err
+	}
+	x, y, ex, ey, _ := combine(b, nb)
+	c := Cluster{
+		DEID:      f.seg.DetElemID(),
+		X:         x,
+		Y:         y,
+		EX:        ex,
+		EY:        ey,
+		Charge:    b.charge + nb.charge,
+		NofDigits: len(digits),
+	}
+	if f.cfg.Fit && len(digits) > 1 {
+		fx, fy, fex, fey, chi2, err := f.fit(digits, []float64{x, y}, b.charge, nb.charge)
+		if err == nil {
+			c.X, c.Y, c.Chi2, c.Fitted = fx, fy, chi2, true
+			// the fit uncertainties can not be worse than the
+			// center of gravity ones
+			c.EX = math.Min(fex, ex)
+			c.EY = math.Min(fey, ey)
+		}
+	}
+	c.GX, c.GY, c.GZ = f.tr.LocalToGlobal(c.X, c.Y, 0)
+	return c, nil
+}
+
+// Run reconstructs one cluster per precluster.
+func (f *Finder) Run(pcs []precluster.Precluster, digits []digit.Digit) ([]Cluster, error) {
+	clusters := make([]Cluster, 0, len(pcs))
+	for _, pc := range pcs {
+		c, err := f.FindCluster(pc.Digits(digits))
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
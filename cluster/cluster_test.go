@@ -0,0 +1,134 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+	"github.com/mrrtf/pigiron/mathieson"
+	"github.com/mrrtf/pigiron/precluster"
+	"github.com/mrrtf/pigiron/transform"
+)
+
+// mathiesonDigits returns the digits of the pads seeing a fraction of
+// a charge q deposited at (x0,y0) above some threshold.
+func mathiesonDigits(t *testing.T, seg mapping.Segmentation, x0, y0, q float64) []digit.Digit {
+	m, err := mathieson.ForDetectionElement(seg.DetElemID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var digits []digit.Digit
+	seg.ForEachPad(func(paduid mapping.PadUID) {
+		adc := math.Round(q * m.IntegratePad(seg, paduid, x0, y0))
+		if adc >= 5 {
+			digits = append(digits, digit.Digit{DEID: seg.DetElemID(), PadUID: paduid, ADC: uint32(adc)})
+		}
+	})
+	return digits
+}
+
+func TestCenterOfGravity(t *testing.T) {
+	seg := mapping.NewSegmentation(501)
+	b, nb, err := seg.FindPadPairByPosition(10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digits := []digit.Digit{{PadUID: b, ADC: 100}, {PadUID: nb, ADC: 100}}
+	x, y, ex, ey, err := CenterOfGravity(seg, digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x != seg.PadPositionX(nb) || y != seg.PadPositionY(b) {
+		t.Errorf("expected x from non-bending pad and y from bending pad, got (%v,%v)", x, y)
+	}
+	if ex != seg.PadSizeX(nb)/math.Sqrt(12) || ey != seg.PadSizeY(b)/math.Sqrt(12) {
+		t.Errorf("wrong uncertainties (%v,%v)", ex, ey)
+	}
+	if _, _, _, _, err := CenterOfGravity(seg, nil); err != ErrEmptyPrecluster {
+		t.Errorf("expected ErrEmptyPrecluster and got %v", err)
+	}
+	if _, _, _, _, err := CenterOfGravity(seg, []digit.Digit{{PadUID: b}}); err != ErrNoCharge {
+		t.Errorf("expected ErrNoCharge and got %v", err)
+	}
+}
+
+func TestFitIsBetterThanCenterOfGravity(t *testing.T) {
+	for _, test := range []struct {
+		deid   mapping.DEID
+		x0, y0 float64
+	}{
+		{501, 10.3, 5.12},
+		{501, -20.1, -3.9},
+		{100, 30.2, 40.17},
+		{1025, 7.7, 2.35},
+	} {
+		seg := mapping.NewSegmentation(test.deid)
+		digits := mathiesonDigits(t, seg, test.x0, test.y0, 1000)
+		f, err := NewFinder(seg, DefaultConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := f.FindCluster(digits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !c.Fitted {
+			t.Fatalf("DE %d : fit failed", test.deid)
+		}
+		x, y, _, _, _ := CenterOfGravity(seg, digits)
+		dfit := math.Hypot(c.X-test.x0, c.Y-test.y0)
+		dcog := math.Hypot(x-test.x0, y-test.y0)
+		if dfit > 0.01 || dfit > dcog {
+			t.Errorf("DE %d : fit (%v,%v) too far from (%v,%v) (cog was (%v,%v))",
+				test.deid, c.X, c.Y, test.x0, test.y0, x, y)
+		}
+		if c.NofDigits != len(digits) {
+			t.Errorf("DE %d : expected %d digits and got %d", test.deid, len(digits), c.NofDigits)
+		}
+	}
+}
+
+func TestGlobalPosition(t *testing.T) {
+	seg := mapping.NewSegmentation(717)
+	digits := mathiesonDigits(t, seg, 3.5, 4.2, 500)
+	f, err := NewFinder(seg, DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := f.FindCluster(digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, _ := transform.ForDetectionElement(717)
+	gx, gy, gz := tr.LocalToGlobal(c.X, c.Y, 0)
+	if gx != c.GX || gy != c.GY || gz != c.GZ {
+		t.Errorf("wrong global position")
+	}
+}
+
+func TestRun(t *testing.T) {
+	seg := mapping.NewSegmentation(501)
+	digits := append(mathiesonDigits(t, seg, -30, 5, 800), mathiesonDigits(t, seg, 30, -5, 800)...)
+	pcs, digits, err := precluster.NewPreclusterer(seg).Run(digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _ := NewFinder(seg, Config{Fit: false})
+	clusters, err := f.Run(pcs, digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters and got %d", len(clusters))
+	}
+	for _, c := range clusters {
+		if c.Fitted {
+			t.Errorf("expected a center of gravity only")
+		}
+		if math.Abs(math.Abs(c.X)-30) > 1 || math.Abs(math.Abs(c.Y)-5) > 1 {
+			t.Errorf("unexpected cluster %v", c)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"errors"
+	"math"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// ErrFitFailed signals a Mathieson fit that did not converge.
+var ErrFitFailed = errors.New("mathieson fit did not converge")
+
+// pad is what the fit needs to know about a fired pad
+type pad struct {
+	xmin, ymin, xmax, ymax float64
+	q                      float64
+	sigma                  float64
+	bending                bool
+}
+
+// newPads returns the fit description of the pads of the digits.
+func newPads(seg mapping.Segmentation, digits []digit.Digit) []pad {
+	pads := make([]pad, len(digits))
+	for i, d := range digits {
+		p := &pads[i]
+		mapping.ComputePadBBox(seg, d.PadUID, &p.xmin, &p.ymin, &p.xmax, &p.ymax)
+		p.q = charge(d)
+		// poisson like uncertainty on the charge
+		p.sigma = math.Sqrt(math.Max(p.q, 1))
+		p.bending = seg.IsBendingPad(d.PadUID)
+	}
+	return pads
+}
+
+// fitter computes the chi2 of a set of pads against a Mathieson
+// distribution centered at a given position.
+type fitter struct {
+	f    *Finder
+	pads []pad
+	qb   float64 // total charge of the bending cathode
+	qnb  float64 // total charge of the non-bending cathode
+	// predicted charge fractions (buffer)
+	fractions []float64
+}
+
+// residuals fills r with the normalized residuals of the pads for a
+// Mathieson centered at (x0,y0). The predicted charge of a pad is the
+// total charge of its cathode times the fraction of the distribution
+// seen by the pad relative to the fraction seen by all the fired pads
+// of that cathode.
+func (ft *fitter) residuals(x0, y0 float64, r []float64) bool {
+	var sb, snb float64
+	m := ft.f.mathieson
+	for i, p := range ft.pads {
+		v := m.Integrate(p.xmin-x0, p.ymin-y0, p.xmax-x0, p.ymax-y0)
+		ft.fractions[i] = v
+		if p.bending {
+			sb += v
+		} else {
+			snb += v
+		}
+	}
+	if (ft.qb > 0 && sb <= 0) || (ft.qnb > 0 && snb <= 0) {
+		return false
+	}
+	for i, p := range ft.pads {
+		var predicted float64
+		if p.bending {
+			predicted = ft.qb * ft.fractions[i] / sb
+		} else {
+			predicted = ft.qnb * ft.fractions[i] / snb
+		}
+		r[i] = (p.q - predicted) / p.sigma
+	}
+	return true
+}
+
+// fit adjusts the position of a Mathieson distribution to the charges
+// of the digits using a Levenberg-Marquardt minimization of the chi2,
+// starting from the given position.
+func (f *Finder) fit(digits []digit.Digit, start []float64, qb, qnb float64) (x, y, ex, ey, chi2 float64, err error) {
+	ft := fitter{
+		f:         f,
+		pads:      newPads(f.seg, digits),
+		qb:        qb,
+		qnb:       qnb,
+		fractions: make([]float64, len(digits)),
+	}
+
+	p := []float64{start[0], start[1]}
+	tol := []float64{f.cfg.Tolerance, f.cfg.Tolerance}
+	chi2, cov, err := minimize(p, tol, len(digits), f.cfg.MaxIterations, func(p, r []float64) bool {
+		return ft.residuals(p[0], p[1], r)
+	})
+	if err != nil {
+		return 0, 0, 0, 0, 0, err
+	}
+	// uncertainties from the covariance matrix,
+	// scaled by the reduced chi2 if there are enough degrees of freedom
+	scale := 1.0
+	if ndf := len(digits) - 2; ndf > 0 {
+		scale = math.Max(chi2/float64(ndf), 1)
+	}
+	if cov[0][0] <= 0 || cov[1][1] <= 0 {
+		return 0, 0, 0, 0, 0, ErrFitFailed
+	}
+	return p[0], p[1], math.Sqrt(cov[0][0] * scale), math.Sqrt(cov[1][1] * scale), chi2, nil
+}
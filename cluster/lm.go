@@ -0,0 +1,182 @@
+package cluster
+
+import "math"
+
+// residualFunc fills r with the normalized residuals for the parameters p.
+// It returns false if the residuals can not be computed for those parameters.
+type residualFunc func(p, r []float64) bool
+
+func sumSquares(r []float64) float64 {
+	var s float64
+	for _, v := range r {
+		s += v * v
+	}
+	return s
+}
+
+// minimize adjusts the parameters p (in place) to minimize the sum of the
+// squares of n residuals, using a Levenberg-Marquardt algorithm with
+// a numerical jacobian.
+// The minimization stops when all the parameter steps are below tol
+// or when no step can decrease the chi2 anymore.
+// It returns the chi2 at the minimum and the (unscaled) covariance
+// matrix of the parameters.
+func minimize(p []float64, tol []float64, n int, maxIterations int, residuals residualFunc) (chi2 float64, cov [][]float64, err error) {
+	np := len(p)
+	r := make([]float64, n)
+	rt := make([]float64, n)
+	jac := make([][]float64, np)
+	for k := range jac {
+		jac[k] = make([]float64, n)
+	}
+	a := newMatrix(np)
+	damped := newMatrix(np)
+	g := make([]float64, np)
+	dp := make([]float64, np)
+	pt := make([]float64, np)
+
+	if !residuals(p, r) {
+		return 0, nil, ErrFitFailed
+	}
+	chi2 = sumSquares(r)
+	lambda := 1e-3
+	converged := false
+
+	for iter := 0; iter < maxIterations && !converged; iter++ {
+		for k := range p {
+			h := 1e-4 * math.Max(1, math.Abs(p[k]))
+			copy(pt, p)
+			pt[k] += h
+			if !residuals(pt, jac[k]) {
+				return 0, nil, ErrFitFailed
+			}
+			for i := range r {
+				jac[k][i] = (jac[k][i] - r[i]) / h
+			}
+		}
+		for k := range p {
+			g[k] = 0
+			for i := range r {
+				g[k] += jac[k][i] * r[i]
+			}
+			for l := 0; l <= k; l++ {
+				var s float64
+				for i := range r {
+					s += jac[k][i] * jac[l][i]
+				}
+				a[k][l], a[l][k] = s, s
+			}
+		}
+		// try steps with increasing damping until the chi2 decreases
+		improved := false
+		for ; lambda < 1e10; lambda *= 10 {
+			for k := range a {
+				copy(damped[k], a[k])
+				damped[k][k] *= 1 + lambda
+				dp[k] = -g[k]
+			}
+			if !solve(damped, dp) {
+				continue
+			}
+			for k := range p {
+				pt[k] = p[k] + dp[k]
+			}
+			if !residuals(pt, rt) {
+				continue
+			}
+			if c := sumSquares(rt); c <= chi2 {
+				copy(p, pt)
+				copy(r, rt)
+				chi2 = c
+				lambda /= 10
+				improved = true
+				converged = true
+				for k := range dp {
+					if math.Abs(dp[k]) >= tol[k] {
+						converged = false
+					}
+				}
+				break
+			}
+		}
+		if !improved {
+			// no step can decrease the chi2 : we are at the minimum
+			converged = true
+		}
+	}
+	if !converged {
+		return 0, nil, ErrFitFailed
+	}
+	cov = newMatrix(np)
+	for k := range cov {
+		copy(damped[k], a[k])
+		cov[k][k] = 1
+	}
+	if !invert(damped, cov) {
+		return 0, nil, ErrFitFailed
+	}
+	return chi2, cov, nil
+}
+
+func newMatrix(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+	}
+	return m
+}
+
+// solve solves a.x = b by gaussian elimination with partial pivoting.
+// a is destroyed and b is replaced by the solution.
+func solve(a [][]float64, b []float64) bool {
+	n := len(b)
+	for c := 0; c < n; c++ {
+		pivot := c
+		for i := c + 1; i < n; i++ {
+			if math.Abs(a[i][c]) > math.Abs(a[pivot][c]) {
+				pivot = i
+			}
+		}
+		if a[pivot][c] == 0 {
+			return false
+		}
+		a[c], a[pivot] = a[pivot], a[c]
+		b[c], b[pivot] = b[pivot], b[c]
+		for i := c + 1; i < n; i++ {
+			f := a[i][c] / a[c][c]
+			for j := c; j < n; j++ {
+				a[i][j] -= f * a[c][j]
+			}
+			b[i] -= f * b[c]
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			b[i] -= a[i][j] * b[j]
+		}
+		b[i] /= a[i][i]
+	}
+	return true
+}
+
+// invert computes the inverse of a into inv, which must be
+// the identity matrix on input. a is destroyed.
+func invert(a [][]float64, inv [][]float64) bool {
+	n := len(a)
+	col := make([]float64, n)
+	// solve one column at a time, on a copy of a
+	work := newMatrix(n)
+	for c := 0; c < n; c++ {
+		for i := range a {
+			copy(work[i], a[i])
+			col[i] = inv[i][c]
+		}
+		if !solve(work, col) {
+			return false
+		}
+		for i := range col {
+			inv[i][c] = col[i]
+		}
+	}
+	return true
+}
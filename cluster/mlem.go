@@ -0,0 +1,352 @@
+package cluster
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/precluster"
+)
+
+// MLEMConfig holds the parameters of the MLEM cluster splitting.
+type MLEMConfig struct {
+	// MaxIterations is the number of expectation-maximisation iterations
+	MaxIterations int
+	// MinPixelSize is the smallest allowed pixel size (cm). The actual pixel
+	// size is half the smallest dimension of the fired pads, but not less
+	// than MinPixelSize
+	MinPixelSize float64
+	// MaxPixels limits the size of the pixel grid. The pixel size is increased
+	// as needed to stay below that limit
+	MaxPixels int
+	// MaxHits is the maximum number of hits fitted simultaneously
+	MaxHits int
+	// MinMaximumFraction is the minimum charge of a local maximum, relative
+	// to the charge of the highest pixel, to be considered a hit candidate
+	MinMaximumFraction float64
+}
+
+// DefaultMLEMConfig are reasonable defaults for the MLEM cluster splitting.
+var DefaultMLEMConfig = MLEMConfig{
+	MaxIterations:      100,
+	MinPixelSize:       0.05,
+	MaxPixels:          10000,
+	MaxHits:            4,
+	MinMaximumFraction: 0.1,
+}
+
+// pixelGrid is a regular grid of pixels covering the fired pads.
+// Only the pixels that are covered by fired pads of all the fired
+// cathodes are kept.
+type pixelGrid struct {
+	x0, y0 float64 // lower left corner
+	size   float64
+	nx, ny int
+	index  []int // index of pixel (ix,iy) in x,y,q, or -1
+	x, y   []float64
+	q      []float64
+}
+
+func (g *pixelGrid) pixel(ix, iy int) int {
+	if ix < 0 || iy < 0 || ix >= g.nx || iy >= g.ny {
+		return -1
+	}
+	return g.index[iy*g.nx+ix]
+}
+
+func newPixelGrid(pads []pad, cfg MLEMConfig) *pixelGrid {
+	xmin, ymin := math.MaxFloat64, math.MaxFloat64
+	xmax, ymax := -math.MaxFloat64, -math.MaxFloat64
+	size := math.MaxFloat64
+	hasB, hasNB := false, false
+	for _, p := range pads {
+		xmin = math.Min(xmin, p.xmin)
+		ymin = math.Min(ymin, p.ymin)
+		xmax = math.Max(xmax, p.xmax)
+		ymax = math.Max(ymax, p.ymax)
+		size = math.Min(size, math.Min(p.xmax-p.xmin, p.ymax-p.ymin))
+		if p.bending {
+			hasB = true
+		} else {
+			hasNB = true
+		}
+	}
+	size = math.Max(size/2, cfg.MinPixelSize)
+	if cfg.MaxPixels > 0 {
+		if n := (xmax - xmin) * (ymax - ymin) / (size * size); n > float64(cfg.MaxPixels) {
+			size *= math.Sqrt(n / float64(cfg.MaxPixels))
+		}
+	}
+	g := &pixelGrid{x0: xmin, y0: ymin, size: size}
+	g.nx = int(math.Ceil((xmax - xmin) / size))
+	g.ny = int(math.Ceil((ymax - ymin) / size))
+	g.index = make([]int, g.nx*g.ny)
+	for iy := 0; iy < g.ny; iy++ {
+		y := ymin + (float64(iy)+0.5)*size
+		for ix := 0; ix < g.nx; ix++ {
+			x := xmin + (float64(ix)+0.5)*size
+			inB, inNB := false, false
+			for _, p := range pads {
+				if x >= p.xmin && x < p.xmax && y >= p.ymin && y < p.ymax {
+					if p.bending {
+						inB = true
+					} else {
+						inNB = true
+					}
+				}
+			}
+			if inB == hasB && inNB == hasNB {
+				g.index[iy*g.nx+ix] = len(g.x)
+				g.x = append(g.x, x)
+				g.y = append(g.y, y)
+			} else {
+				g.index[iy*g.nx+ix] = -1
+			}
+		}
+	}
+	return g
+}
+
+// mlem runs the expectation-maximisation deconvolution of the pad charges
+// onto the pixels of the grid, using the Mathieson distribution as the
+// response of a pad to a pixel.
+func (f *Finder) mlem(pads []pad, g *pixelGrid, maxIterations int) {
+	npix := len(g.x)
+	// c[i*npix+j] is the fraction of the charge of pixel j seen by pad i
+	c := make([]float64, len(pads)*npix)
+	// sensitivity of each pixel, i.e. the fraction of its charge
+	// seen by all the fired pads
+	sensitivity := make([]float64, npix)
+	var qtot float64
+	for i, p := range pads {
+		for j := range g.x {
+			v := f.mathieson.Integrate(p.xmin-g.x[j], p.ymin-g.y[j], p.xmax-g.x[j], p.ymax-g.y[j])
+			c[i*npix+j] = v
+			sensitivity[j] += v
+		}
+		qtot += p.q
+	}
+	g.q = make([]float64, npix)
+	for j := range g.q {
+		g.q[j] = qtot / float64(npix)
+	}
+	ratio := make([]float64, len(pads))
+	next := make([]float64, npix)
+	for iter := 0; iter < maxIterations; iter++ {
+		// ratio of measured to predicted pad charges
+		for i, p := range pads {
+			var predicted float64
+			for j, q := range g.q {
+				predicted += c[i*npix+j] * q
+			}
+			ratio[i] = 0
+			if predicted > 0 {
+				ratio[i] = p.q / predicted
+			}
+		}
+		var change, total float64
+		for j := range g.q {
+			next[j] = 0
+			if sensitivity[j] <= 0 {
+				continue
+			}
+			var s float64
+			for i := range pads {
+				s += c[i*npix+j] * ratio[i]
+			}
+			next[j] = g.q[j] * s / sensitivity[j]
+			change += math.Abs(next[j] - g.q[j])
+			total += next[j]
+		}
+		g.q, next = next, g.q
+		if total <= 0 || change/total < 1e-6 {
+			break
+		}
+	}
+}
+
+// maxima returns the indices of the pixels that are local maxima,
+// by decreasing charge.
+func (g *pixelGrid) maxima(minFraction float64) []int {
+	var qmax float64
+	for _, q := range g.q {
+		qmax = math.Max(qmax, q)
+	}
+	var maxima []int
+	for iy := 0; iy < g.ny; iy++ {
+		for ix := 0; ix < g.nx; ix++ {
+			j := g.pixel(ix, iy)
+			if j < 0 || g.q[j] <= 0 || g.q[j] < minFraction*qmax {
+				continue
+			}
+			isMax := true
+			for dy := -1; dy <= 1 && isMax; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					k := g.pixel(ix+dx, iy+dy)
+					if k < 0 || k == j {
+						continue
+					}
+					// for plateaus only the first pixel is a maximum
+					if g.q[k] > g.q[j] || (g.q[k] == g.q[j] && k < j) {
+						isMax = false
+						break
+					}
+				}
+			}
+			if isMax {
+				maxima = append(maxima, j)
+			}
+		}
+	}
+	sort.Slice(maxima, func(a, b int) bool {
+		return g.q[maxima[a]] > g.q[maxima[b]]
+	})
+	return maxima
+}
+
+// hitCharge is the charge of the pixels around the maximum j
+func (g *pixelGrid) hitCharge(j int, maxima []int) float64 {
+	// each pixel is attributed to the closest maximum
+	var q float64
+	for k := range g.q {
+		closest := j
+		d := math.Hypot(g.x[k]-g.x[j], g.y[k]-g.y[j])
+		for _, m := range maxima {
+			if dm := math.Hypot(g.x[k]-g.x[m], g.y[k]-g.y[m]); dm < d {
+				closest, d = m, dm
+			}
+		}
+		if closest == j {
+			q += g.q[k]
+		}
+	}
+	return q
+}
+
+// FindClustersMLEM reconstructs one or several clusters from the digits
+// of one precluster. The pad charges of both cathodes are first deconvolved
+// onto a grid of pixels using an MLEM (Maximum Likelihood Expectation
+// Maximisation) algorithm. The local maxima of the pixel charges are then
+// used as the starting points of a simultaneous fit of several Mathieson
+// distributions to the pad charges.
+//
+// If the fit fails, the returned clusters are positioned on the local maxima.
+func (f *Finder) FindClustersMLEM(digits []digit.Digit, cfg MLEMConfig) ([]Cluster, error) {
+	if len(digits) == 0 {
+		return nil, ErrEmptyPrecluster
+	}
+	pads := newPads(f.seg, digits)
+	var qtot float64
+	for _, p := range pads {
+		qtot += p.q
+	}
+	if qtot <= 0 {
+		return nil, ErrNoCharge
+	}
+	g := newPixelGrid(pads, cfg)
+	if len(g.x) == 0 {
+		// the two cathodes do not overlap : nothing to split
+		c, err := f.FindCluster(digits)
+		if err != nil {
+			return nil, err
+		}
+		return []Cluster{c}, nil
+	}
+	f.mlem(pads, g, cfg.MaxIterations)
+	maxima := g.maxima(cfg.MinMaximumFraction)
+	// each hit has 3 parameters : x, y and charge
+	nhits := len(maxima)
+	if cfg.MaxHits > 0 && nhits > cfg.MaxHits {
+		nhits = cfg.MaxHits
+	}
+	if nhits > len(pads)/3 {
+		nhits = len(pads) / 3
+	}
+	if nhits <= 1 {
+		c, err := f.FindCluster(digits)
+		if err != nil {
+			return nil, err
+		}
+		return []Cluster{c}, nil
+	}
+	maxima = maxima[:nhits]
+
+	p := make([]float64, 3*nhits)
+	tol := make([]float64, 3*nhits)
+	for k, j := range maxima {
+		p[3*k] = g.x[j]
+		p[3*k+1] = g.y[j]
+		p[3*k+2] = g.hitCharge(j, maxima)
+		tol[3*k] = f.cfg.Tolerance
+		tol[3*k+1] = f.cfg.Tolerance
+		tol[3*k+2] = 1e-3 * p[3*k+2]
+	}
+
+	clusters := make([]Cluster, nhits)
+	sqrt12 := math.Sqrt(12)
+	for k, j := range maxima {
+		clusters[k] = Cluster{
+			DEID:      f.seg.DetElemID(),
+			X:         g.x[j],
+			Y:         g.y[j],
+			EX:        g.size / sqrt12,
+			EY:        g.size / sqrt12,
+			Charge:    2 * p[3*k+2],
+			NofDigits: len(digits),
+		}
+	}
+
+	chi2, cov, err := minimize(p, tol, len(pads), f.cfg.MaxIterations, func(p, r []float64) bool {
+		for k := 0; k < nhits; k++ {
+			if p[3*k+2] <= 0 {
+				return false
+			}
+		}
+		for i, pad := range pads {
+			var predicted float64
+			for k := 0; k < nhits; k++ {
+				x0, y0 := p[3*k], p[3*k+1]
+				predicted += p[3*k+2] * f.mathieson.Integrate(pad.xmin-x0, pad.ymin-y0, pad.xmax-x0, pad.ymax-y0)
+			}
+			r[i] = (pad.q - predicted) / pad.sigma
+		}
+		return true
+	})
+	if err == nil {
+		scale := 1.0
+		if ndf := len(pads) - len(p); ndf > 0 {
+			scale = math.Max(chi2/float64(ndf), 1)
+		}
+		for k := range clusters {
+			c := &clusters[k]
+			c.X, c.Y, c.Chi2, c.Fitted = p[3*k], p[3*k+1], chi2, true
+			// each cathode sees the full charge of the hit
+			c.Charge = 2 * p[3*k+2]
+			if v := cov[3*k][3*k]; v > 0 {
+				c.EX = math.Min(math.Sqrt(v*scale), c.EX)
+			}
+			if v := cov[3*k+1][3*k+1]; v > 0 {
+				c.EY = math.Min(math.Sqrt(v*scale), c.EY)
+			}
+		}
+	}
+	for k := range clusters {
+		c := &clusters[k]
+		c.GX, c.GY, c.GZ = f.tr.LocalToGlobal(c.X, c.Y, 0)
+	}
+	return clusters, nil
+}
+
+// RunMLEM reconstructs the clusters of all the preclusters, possibly
+// splitting each precluster into several clusters.
+func (f *Finder) RunMLEM(pcs []precluster.Precluster, digits []digit.Digit, cfg MLEMConfig) ([]Cluster, error) {
+	var clusters []Cluster
+	for _, pc := range pcs {
+		c, err := f.FindClustersMLEM(pc.Digits(digits), cfg)
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, c...)
+	}
+	return clusters, nil
+}
@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/precluster"
+)
+
+// mergeDigits adds the charges of the digits on the same pads.
+func mergeDigits(digits ...[]digit.Digit) []digit.Digit {
+	index := make(map[mapping.PadUID]int)
+	var merged []digit.Digit
+	for _, dl := range digits {
+		for _, d := range dl {
+			if i, ok := index[d.PadUID]; ok {
+				merged[i].ADC += d.ADC
+				continue
+			}
+			index[d.PadUID] = len(merged)
+			merged = append(merged, d)
+		}
+	}
+	return merged
+}
+
+func TestMLEMSplitsTwoCloseHits(t *testing.T) {
+	seg := mapping.NewSegmentation(100)
+	hits := [][2]float64{{20.1, 30.3}, {21.3, 30.9}}
+	digits := mergeDigits(
+		mathiesonDigits(t, seg, hits[0][0], hits[0][1], 1000),
+		mathiesonDigits(t, seg, hits[1][0], hits[1][1], 1000))
+	pcs, digits, err := precluster.NewPreclusterer(seg).Run(digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcs) != 1 {
+		t.Fatalf("expected the two hits to be in the same precluster, got %d preclusters", len(pcs))
+	}
+	f, err := NewFinder(seg, DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clusters, err := f.RunMLEM(pcs, digits, DefaultMLEMConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters and got %d", len(clusters))
+	}
+	for _, h := range hits {
+		found := false
+		for _, c := range clusters {
+			if !c.Fitted {
+				t.Errorf("expected fitted clusters")
+			}
+			if math.Hypot(c.X-h[0], c.Y-h[1]) < 0.02 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no cluster found close to hit %v : %v", h, clusters)
+		}
+	}
+}
+
+func TestMLEMSingleHit(t *testing.T) {
+	seg := mapping.NewSegmentation(501)
+	digits := mathiesonDigits(t, seg, 10.3, 5.12, 1000)
+	f, err := NewFinder(seg, DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clusters, err := f.FindClustersMLEM(digits, DefaultMLEMConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster and got %d", len(clusters))
+	}
+	if math.Hypot(clusters[0].X-10.3, clusters[0].Y-5.12) > 0.01 {
+		t.Errorf("cluster too far from hit : %v", clusters[0])
+	}
+}
+
+func TestMLEMMaxHits(t *testing.T) {
+	seg := mapping.NewSegmentation(100)
+	digits := mergeDigits(
+		mathiesonDigits(t, seg, 20.1, 30.3, 1000),
+		mathiesonDigits(t, seg, 21.3, 30.9, 1000))
+	f, _ := NewFinder(seg, DefaultConfig)
+	cfg := DefaultMLEMConfig
+	cfg.MaxHits = 1
+	clusters, err := f.FindClustersMLEM(digits, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 {
+		t.Errorf("expected 1 cluster and got %d", len(clusters))
+	}
+}
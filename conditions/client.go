@@ -0,0 +1,77 @@
+package conditions
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Client fetches objects from a conditions server.
+type Client struct {
+	// BaseURL is the URL of the server, e.g. http://localhost:8081
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client of the server at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTP: http.DefaultClient}
+}
+
+func infoFromHeaders(path string, h http.Header) ObjectInfo {
+	info := ObjectInfo{Path: path}
+	info.Validity.Start, _ = strconv.ParseInt(h.Get("Valid-From"), 10, 64)
+	info.Validity.End, _ = strconv.ParseInt(h.Get("Valid-Until"), 10, 64)
+	info.Version, _ = strconv.Atoi(h.Get("Version"))
+	return info
+}
+
+func responseError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	msg, _ := ioutil.ReadAll(resp.Body)
+	return errors.New(strings.TrimSpace(string(msg)))
+}
+
+// Get returns the content of the object valid at time t for the given path.
+func (c *Client) Get(path string, t int64) ([]byte, ObjectInfo, error) {
+	if err := checkPath(path); err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	resp, err := c.HTTP.Get(fmt.Sprintf("%s%s%s?t=%d", c.BaseURL, objectsPrefix, path, t))
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, ObjectInfo{}, responseError(resp)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	return data, infoFromHeaders(path, resp.Header), err
+}
+
+// Put stores an object under a path with a given validity.
+func (c *Client) Put(path string, v Validity, data []byte) (ObjectInfo, error) {
+	if err := checkPath(path); err != nil {
+		return ObjectInfo{}, err
+	}
+	url := fmt.Sprintf("%s%s%s?start=%d&end=%d", c.BaseURL, objectsPrefix, path, v.Start, v.End)
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return ObjectInfo{}, responseError(resp)
+	}
+	return infoFromHeaders(path, resp.Header), nil
+}
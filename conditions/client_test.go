@@ -0,0 +1,35 @@
+package conditions
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	s, clean := newTestStore(t)
+	defer clean()
+	ts := httptest.NewServer(Handler(s))
+	defer ts.Close()
+	c := NewClient(ts.URL)
+	info, err := c.Put("MCH/Align", Validity{5, 10}, []byte("xyz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != 1 {
+		t.Errorf("want version 1, got %d", info.Version)
+	}
+	var src Source = c
+	data, info, err := src.Get("MCH/Align", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "xyz" || info.Validity != (Validity{5, 10}) {
+		t.Errorf("unexpected object %q %v", data, info)
+	}
+	if _, _, err := c.Get("MCH/Align", 10); err != ErrNotFound {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+	if _, err := c.Put("MCH/Align", Validity{10, 5}, nil); err == nil {
+		t.Error("want an error for an invalid validity")
+	}
+}
@@ -0,0 +1,159 @@
+package conditions
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Source is anything able to return the object valid at a given time,
+// e.g. a local Store or a Client of a remote one.
+type Source interface {
+	Get(path string, t int64) ([]byte, ObjectInfo, error)
+}
+
+const (
+	objectsPrefix = "/objects/"
+	listPrefix    = "/list/"
+)
+
+// MaxObjectSize is the maximum size (in bytes) of an object uploaded
+// through the HTTP handler.
+var MaxObjectSize int64 = 64 << 20
+
+var (
+	// ErrMissingTime signals an object request without time.
+	ErrMissingTime = errors.New("specifying a time (t=[integer]) is required")
+	// ErrMissingStart signals an object upload without validity start.
+	ErrMissingStart = errors.New("specifying a validity start (start=[integer]) is required")
+	// ErrInvalidInteger signals a malformed time or validity.
+	ErrInvalidInteger = errors.New("times and validities must be integers")
+	// ErrObjectTooLarge signals an object upload larger than MaxObjectSize.
+	ErrObjectTooLarge = errors.New("object too large")
+)
+
+func queryInt(q url.Values, name string, missing error) (int64, error) {
+	v, ok := q[name]
+	if !ok {
+		return 0, missing
+	}
+	i, err := strconv.ParseInt(v[0], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidInteger
+	}
+	return i, nil
+}
+
+func setInfoHeaders(w http.ResponseWriter, info ObjectInfo) {
+	w.Header().Set("Valid-From", strconv.FormatInt(info.Validity.Start, 10))
+	w.Header().Set("Valid-Until", strconv.FormatInt(info.Validity.End, 10))
+	w.Header().Set("Version", strconv.Itoa(info.Version))
+}
+
+func httpStatus(err error) int {
+	switch err {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrInvalidPath, ErrInvalidValidity, ErrMissingTime, ErrMissingStart, ErrInvalidInteger:
+		return http.StatusBadRequest
+	case ErrObjectTooLarge:
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
+
+func objects(s *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		path := strings.TrimPrefix(r.URL.Path, objectsPrefix)
+		q := r.URL.Query()
+		switch r.Method {
+		case http.MethodGet:
+			t, err := queryInt(q, "t", ErrMissingTime)
+			if err != nil {
+				http.Error(w, err.Error(), httpStatus(err))
+				return
+			}
+			data, info, err := s.Get(path, t)
+			if err != nil {
+				http.Error(w, err.Error(), httpStatus(err))
+				return
+			}
+			setInfoHeaders(w, info)
+			w.Header().Set("Content-type", "application/octet-stream")
+			w.Write(data)
+		case http.MethodPut, http.MethodPost:
+			start, err := queryInt(q, "start", ErrMissingStart)
+			if err != nil {
+				http.Error(w, err.Error(), httpStatus(err))
+				return
+			}
+			var end int64
+			if _, ok := q["end"]; ok {
+				if end, err = queryInt(q, "end", nil); err != nil {
+					http.Error(w, err.Error(), httpStatus(err))
+					return
+				}
+			}
+			if r.ContentLength > MaxObjectSize {
+				http.Error(w, ErrObjectTooLarge.Error(), httpStatus(ErrObjectTooLarge))
+				return
+			}
+			data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxObjectSize))
+			if err != nil && int64(len(data)) >= MaxObjectSize {
+				http.Error(w, ErrObjectTooLarge.Error(), httpStatus(ErrObjectTooLarge))
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			info, err := s.Put(path, Validity{start, end}, data)
+			if err != nil {
+				http.Error(w, err.Error(), httpStatus(err))
+				return
+			}
+			setInfoHeaders(w, info)
+			w.Header().Set("Content-type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(info)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func list(s *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		objects, err := s.List(strings.TrimPrefix(r.URL.Path, listPrefix))
+		if err != nil {
+			http.Error(w, err.Error(), httpStatus(err))
+			return
+		}
+		if objects == nil {
+			objects = []ObjectInfo{}
+		}
+		w.Header().Set("Content-type", "application/json")
+		json.NewEncoder(w).Encode(objects)
+	}
+}
+
+// Handler returns an HTTP handler serving the objects of the store :
+//
+// GET /objects/path?t=[integer] returns the object valid at time t
+//
+// PUT /objects/path?start=[integer](&end=[integer]) stores the request body,
+// which must not exceed MaxObjectSize bytes
+//
+// GET /list/path returns the description of all the objects of a path
+func Handler(s *Store) http.Handler {
+	r := http.NewServeMux()
+	r.HandleFunc(objectsPrefix, objects(s))
+	r.HandleFunc(listPrefix, list(s))
+	return r
+}
@@ -0,0 +1,40 @@
+package conditions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPutTooLarge(t *testing.T) {
+	s, clean := newTestStore(t)
+	defer clean()
+	defer func(n int64) { MaxObjectSize = n }(MaxObjectSize)
+	MaxObjectSize = 4
+	h := Handler(s)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/objects/MCH/Calib?start=1", strings.NewReader("hello")))
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("want status %d, got %d", http.StatusRequestEntityTooLarge, rr.Code)
+	}
+
+	// without content length the body is only limited while being read
+	req := httptest.NewRequest(http.MethodPut, "/objects/MCH/Calib?start=1", strings.NewReader("hello"))
+	req.ContentLength = -1
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("want status %d, got %d", http.StatusRequestEntityTooLarge, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/objects/MCH/Calib?start=1", strings.NewReader("hell")))
+	if rr.Code != http.StatusCreated {
+		t.Errorf("want status %d, got %d", http.StatusCreated, rr.Code)
+	}
+	if objects, _ := s.List("MCH/Calib"); len(objects) != 1 {
+		t.Errorf("want 1 object stored, got %d", len(objects))
+	}
+}
@@ -0,0 +1,215 @@
+package conditions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNotFound signals that no object is valid for the requested path and time.
+	ErrNotFound = errors.New("no valid object found")
+	// ErrInvalidPath signals a malformed object path.
+	ErrInvalidPath = errors.New("invalid object path (must be of the form a/b/c with [A-Za-z0-9_.-] characters)")
+	// ErrInvalidValidity signals a validity range that ends before it starts.
+	ErrInvalidValidity = errors.New("invalid validity range")
+)
+
+// Validity is a range [Start,End) of run numbers or timestamps,
+// depending on the object. An End of zero means the range is not bounded.
+type Validity struct {
+	Start int64
+	End   int64
+}
+
+// Contains returns true if t is within the validity range.
+func (v Validity) Contains(t int64) bool {
+	return t >= v.Start && (v.End == 0 || t < v.End)
+}
+
+func (v Validity) isValid() bool {
+	return v.Start >= 0 && v.End >= 0 && (v.End == 0 || v.End > v.Start)
+}
+
+// ObjectInfo describes one object of the store.
+type ObjectInfo struct {
+	Path     string
+	Validity Validity
+	// Version increases each time an object is stored under a given path.
+	// When several objects are valid at a given time, the one with the
+	// highest version is used.
+	Version int
+}
+
+// Store is a conditions database backed by a local directory.
+//
+// Each object is a file named start_end_version within the
+// directory corresponding to its path.
+type Store struct {
+	root string
+	mu   sync.Mutex
+}
+
+var pathRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)*$`)
+
+func checkPath(path string) error {
+	if !pathRegexp.MatchString(path) {
+		return ErrInvalidPath
+	}
+	for _, p := range strings.Split(path, "/") {
+		if p == "." || p == ".." {
+			return ErrInvalidPath
+		}
+	}
+	return nil
+}
+
+// Open returns a store using the given directory, which is created
+// if it does not exist yet.
+func Open(root string) (*Store, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{root: root}, nil
+}
+
+func (s *Store) dir(path string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path))
+}
+
+func (info ObjectInfo) filename() string {
+	return fmt.Sprintf("%d_%d_%d", info.Validity.Start, info.Validity.End, info.Version)
+}
+
+func parseFilename(path, name string) (ObjectInfo, bool) {
+	parts := strings.Split(name, "_")
+	if len(parts) != 3 {
+		return ObjectInfo{}, false
+	}
+	var values [3]int64
+	for i, p := range parts {
+		v, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return ObjectInfo{}, false
+		}
+		values[i] = v
+	}
+	return ObjectInfo{Path: path, Validity: Validity{values[0], values[1]}, Version: int(values[2])}, true
+}
+
+// list returns the objects of one path, by increasing version.
+func (s *Store) list(path string) ([]ObjectInfo, error) {
+	files, err := ioutil.ReadDir(s.dir(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var objects []ObjectInfo
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if info, ok := parseFilename(path, f.Name()); ok {
+			objects = append(objects, info)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Version < objects[j].Version
+	})
+	return objects, nil
+}
+
+// List returns the objects stored under a path, by increasing version.
+func (s *Store) List(path string) ([]ObjectInfo, error) {
+	if err := checkPath(path); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list(path)
+}
+
+// Put stores an object under a path with a given validity.
+func (s *Store) Put(path string, v Validity, data []byte) (ObjectInfo, error) {
+	if err := checkPath(path); err != nil {
+		return ObjectInfo{}, err
+	}
+	if !v.isValid() {
+		return ObjectInfo{}, ErrInvalidValidity
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects, err := s.list(path)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info := ObjectInfo{Path: path, Validity: v, Version: 1}
+	if len(objects) > 0 {
+		info.Version = objects[len(objects)-1].Version + 1
+	}
+	if err := os.MkdirAll(s.dir(path), 0755); err != nil {
+		return ObjectInfo{}, err
+	}
+	// write to a temporary file first so readers never see a partial object
+	tmp := filepath.Join(s.dir(path), "."+info.filename())
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir(path), info.filename())); err != nil {
+		return ObjectInfo{}, err
+	}
+	return info, nil
+}
+
+// Find returns the description of the object valid at time t (a run number
+// or a timestamp) for the given path.
+func (s *Store) Find(path string, t int64) (ObjectInfo, error) {
+	objects, err := s.List(path)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	for i := len(objects) - 1; i >= 0; i-- {
+		if objects[i].Validity.Contains(t) {
+			return objects[i], nil
+		}
+	}
+	return ObjectInfo{}, ErrNotFound
+}
+
+// Get returns the content of the object valid at time t for the given path.
+func (s *Store) Get(path string, t int64) ([]byte, ObjectInfo, error) {
+	info, err := s.Find(path, t)
+	if err != nil {
+		return nil, info, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(s.dir(path), info.filename()))
+	return data, info, err
+}
+
+// PutJSON stores the JSON encoding of v.
+func (s *Store) PutJSON(path string, validity Validity, v interface{}) (ObjectInfo, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return s.Put(path, validity, data)
+}
+
+// GetJSON decodes into v the object valid at time t.
+func (s *Store) GetJSON(path string, t int64, v interface{}) (ObjectInfo, error) {
+	data, info, err := s.Get(path, t)
+	if err != nil {
+		return info, err
+	}
+	return info, json.Unmarshal(data, v)
+}
@@ -0,0 +1,110 @@
+package conditions
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func newTestStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "conditions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func TestValidityContains(t *testing.T) {
+	v := Validity{10, 20}
+	for _, tc := range []struct {
+		t    int64
+		want bool
+	}{{9, false}, {10, true}, {19, true}, {20, false}} {
+		if got := v.Contains(tc.t); got != tc.want {
+			t.Errorf("Contains(%d): want %v, got %v", tc.t, tc.want, got)
+		}
+	}
+	if !(Validity{10, 0}).Contains(1 << 40) {
+		t.Error("unbounded validity should contain large times")
+	}
+}
+
+func TestPutGet(t *testing.T) {
+	s, clean := newTestStore(t)
+	defer clean()
+	if _, err := s.Put("MCH/Calib/BadChannel", Validity{100, 200}, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	data, info, err := s.Get("MCH/Calib/BadChannel", 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a" || info.Version != 1 || info.Validity != (Validity{100, 200}) {
+		t.Errorf("unexpected object %q %v", data, info)
+	}
+	if _, _, err := s.Get("MCH/Calib/BadChannel", 200); err != ErrNotFound {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+	if _, _, err := s.Get("MCH/Calib/Other", 150); err != ErrNotFound {
+		t.Errorf("want ErrNotFound for unknown path, got %v", err)
+	}
+}
+
+func TestLatestVersionWins(t *testing.T) {
+	s, clean := newTestStore(t)
+	defer clean()
+	s.Put("a/b", Validity{0, 0}, []byte("default"))
+	s.Put("a/b", Validity{100, 200}, []byte("override"))
+	for _, tc := range []struct {
+		t    int64
+		want string
+	}{{50, "default"}, {100, "override"}, {199, "override"}, {200, "default"}} {
+		data, _, err := s.Get("a/b", tc.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.want {
+			t.Errorf("t=%d: want %q, got %q", tc.t, tc.want, data)
+		}
+	}
+	objects, err := s.List("a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].Version != 1 || objects[1].Version != 2 {
+		t.Errorf("unexpected list %v", objects)
+	}
+}
+
+func TestInvalidPathAndValidity(t *testing.T) {
+	s, clean := newTestStore(t)
+	defer clean()
+	for _, p := range []string{"", "/a", "a/", "a//b", "a/../b", "a b"} {
+		if _, err := s.Put(p, Validity{}, nil); err != ErrInvalidPath {
+			t.Errorf("path %q: want ErrInvalidPath, got %v", p, err)
+		}
+	}
+	if _, err := s.Put("a", Validity{10, 5}, nil); err != ErrInvalidValidity {
+		t.Errorf("want ErrInvalidValidity, got %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	s, clean := newTestStore(t)
+	defer clean()
+	in := map[string]int{"deid": 100}
+	if _, err := s.PutJSON("x", Validity{}, in); err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]int
+	if _, err := s.GetJSON("x", 3, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out["deid"] != 100 {
+		t.Errorf("want 100, got %v", out)
+	}
+}
@@ -0,0 +1,140 @@
+package digit
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// BCPerOrbit is the number of bunch crossings within one LHC orbit.
+const BCPerOrbit = 3564
+
+// Flag is a bit set describing some properties of a digit.
+type Flag uint8
+
+const (
+	// Saturated indicates that (at least) one ADC sample of the digit
+	// reached the maximum value of the ADC.
+	Saturated Flag = 1 << iota
+	// Noisy indicates that the digit comes from a channel known to be noisy.
+	Noisy
+	// Simulated indicates that the digit was not read out but simulated.
+	Simulated
+)
+
+// Digit is the charge measured on one pad at a given time.
+type Digit struct {
+	DEID       mapping.DEID
+	PadUID     mapping.PadUID
+	ADC        uint32
+	Orbit      uint32
+	BC         uint16
+	NofSamples uint16
+	Flags      Flag
+}
+
+// IsSaturated returns true if the Saturated flag of the digit is set.
+func (d Digit) IsSaturated() bool {
+	return d.Flags&Saturated != 0
+}
+
+// Time returns the time of the digit expressed in number of
+// bunch crossings since the orbit 0.
+func (d Digit) Time() int64 {
+	return int64(d.Orbit)*BCPerOrbit + int64(d.BC)
+}
+
+func (d Digit) String() string {
+	return fmt.Sprintf("DE %4d PAD %6d ADC %6d ORBIT %10d BC %4d NS %3d FLAGS %02x",
+		d.DEID, d.PadUID, d.ADC, d.Orbit, d.BC, d.NofSamples, d.Flags)
+}
+
+// less defines the order of digits within one detection element :
+// by increasing time, then by increasing pad uid.
+func less(a, b Digit) bool {
+	if a.Orbit != b.Orbit {
+		return a.Orbit < b.Orbit
+	}
+	if a.BC != b.BC {
+		return a.BC < b.BC
+	}
+	return a.PadUID < b.PadUID
+}
+
+// Sort sorts the digits in place, in time order first and
+// pad uid order second.
+func Sort(digits []Digit) {
+	sort.SliceStable(digits, func(i, j int) bool {
+		return less(digits[i], digits[j])
+	})
+}
+
+// IsSorted returns true if the digits are in the order defined by Sort.
+func IsSorted(digits []Digit) bool {
+	return sort.SliceIsSorted(digits, func(i, j int) bool {
+		return less(digits[i], digits[j])
+	})
+}
+
+// Container holds digits organized per detection element.
+// Within each detection element the digits are kept sorted (see Sort).
+type Container struct {
+	digits   map[mapping.DEID][]Digit
+	unsorted map[mapping.DEID]bool
+}
+
+// NewContainer returns an empty container.
+func NewContainer() *Container {
+	return &Container{
+		digits:   make(map[mapping.DEID][]Digit),
+		unsorted: make(map[mapping.DEID]bool),
+	}
+}
+
+// Add adds one digit to the container.
+func (c *Container) Add(d Digit) {
+	dd := c.digits[d.DEID]
+	if len(dd) > 0 && less(d, dd[len(dd)-1]) {
+		c.unsorted[d.DEID] = true
+	}
+	c.digits[d.DEID] = append(dd, d)
+}
+
+// Len returns the total number of digits in the container.
+func (c *Container) Len() int {
+	n := 0
+	for _, dd := range c.digits {
+		n += len(dd)
+	}
+	return n
+}
+
+// DEIDs returns the (sorted) list of detection elements
+// which have at least one digit.
+func (c *Container) DEIDs() []mapping.DEID {
+	deids := make([]mapping.DEID, 0, len(c.digits))
+	for deid := range c.digits {
+		deids = append(deids, deid)
+	}
+	sort.Slice(deids, func(i, j int) bool { return deids[i] < deids[j] })
+	return deids
+}
+
+// Digits returns the sorted digits of one detection element.
+// The returned slice is owned by the container and should not be modified.
+func (c *Container) Digits(deid mapping.DEID) []Digit {
+	if c.unsorted[deid] {
+		Sort(c.digits[deid])
+		delete(c.unsorted, deid)
+	}
+	return c.digits[deid]
+}
+
+// ForEachDE calls the handler for each detection element, in increasing
+// detection element id order, with the sorted digits of that detection element.
+func (c *Container) ForEachDE(handler func(deid mapping.DEID, digits []Digit)) {
+	for _, deid := range c.DEIDs() {
+		handler(deid, c.Digits(deid))
+	}
+}
@@ -0,0 +1,72 @@
+package digit
+
+import (
+	"testing"
+
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func TestDigitTime(t *testing.T) {
+	d := Digit{Orbit: 2, BC: 10}
+	if d.Time() != 2*BCPerOrbit+10 {
+		t.Errorf("expected time %d and got %d", 2*BCPerOrbit+10, d.Time())
+	}
+}
+
+func TestDigitIsSaturated(t *testing.T) {
+	d := Digit{Flags: Noisy}
+	if d.IsSaturated() {
+		t.Errorf("digit should not be saturated")
+	}
+	d.Flags |= Saturated
+	if !d.IsSaturated() {
+		t.Errorf("digit should be saturated")
+	}
+}
+
+func TestSort(t *testing.T) {
+	digits := []Digit{
+		{PadUID: 3, Orbit: 1, BC: 5},
+		{PadUID: 1, Orbit: 1, BC: 5},
+		{PadUID: 2, Orbit: 0, BC: 100},
+		{PadUID: 0, Orbit: 1, BC: 2},
+	}
+	if IsSorted(digits) {
+		t.Fatalf("digits should not be sorted")
+	}
+	Sort(digits)
+	expected := []mapping.PadUID{2, 0, 1, 3}
+	for i, d := range digits {
+		if d.PadUID != expected[i] {
+			t.Errorf("digit %d : expected pad %d and got %d", i, expected[i], d.PadUID)
+		}
+	}
+	if !IsSorted(digits) {
+		t.Errorf("digits should be sorted")
+	}
+}
+
+func TestContainer(t *testing.T) {
+	c := NewContainer()
+	c.Add(Digit{DEID: 501, PadUID: 10, Orbit: 3})
+	c.Add(Digit{DEID: 100, PadUID: 12, Orbit: 2})
+	c.Add(Digit{DEID: 501, PadUID: 11, Orbit: 1})
+	if c.Len() != 3 {
+		t.Errorf("expected 3 digits and got %d", c.Len())
+	}
+	deids := c.DEIDs()
+	if len(deids) != 2 || deids[0] != 100 || deids[1] != 501 {
+		t.Errorf("expected deids [100 501] and got %v", deids)
+	}
+	dd := c.Digits(501)
+	if len(dd) != 2 || dd[0].PadUID != 11 || dd[1].PadUID != 10 {
+		t.Errorf("digits of DE 501 not sorted : %v", dd)
+	}
+	var visited []mapping.DEID
+	c.ForEachDE(func(deid mapping.DEID, digits []Digit) {
+		visited = append(visited, deid)
+	})
+	if len(visited) != 2 || visited[0] != 100 {
+		t.Errorf("ForEachDE should visit DE in order : got %v", visited)
+	}
+}
@@ -0,0 +1,489 @@
+package digit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// The digit file format is a compact binary format organized as follows :
+//
+//	header  : magic "MCHD" (4 bytes), version (uint16), reserved (uint16)
+//	blocks  : one or more blocks of digits of a single detection element
+//	index   : the list of (deid, offset, number of digits) of all the blocks
+//	trailer : offset of the index (uint64), magic "MCHI" (4 bytes)
+//
+// Fixed size integers are little endian.
+// Each block starts with a 'D' tag, followed by the deid, the number
+// of digits and the size in bytes of the payload, all as uvarints.
+// Within the payload the (sorted) digits are delta encoded :
+//
+//	orbit      : uvarint, difference with previous digit's orbit
+//	bc         : uvarint, difference with previous digit's bc if same orbit,
+//	             absolute value otherwise
+//	paduid     : varint, difference with previous digit's paduid
+//	adc        : uvarint
+//	nofsamples : uvarint
+//	flags      : one byte
+//
+// The index starts with an 'I' tag followed by the number of entries
+// and then, for each entry, deid, offset and number of digits as uvarints.
+//
+// A plain io.Reader is enough to stream over the blocks (see Reader) while
+// an io.ReaderAt gives random access to the digits of one detection element
+// (see IndexedReader).
+
+// FileFormatVersion is the version of the format written by Writer.
+const FileFormatVersion = 1
+
+const (
+	headerMagic  = "MCHD"
+	trailerMagic = "MCHI"
+	headerSize   = 8
+	trailerSize  = 12
+	blockTag     = 'D'
+	indexTag     = 'I'
+	// minDigitSize is the size of an encoded digit whose fields
+	// all fit in one byte
+	minDigitSize = 6
+)
+
+var (
+	// ErrInvalidMagic signals that the input is not a digit file.
+	ErrInvalidMagic = errors.New("not a digit file (invalid magic)")
+	// ErrUnsupportedVersion signals a digit file with a version this code does not know about.
+	ErrUnsupportedVersion = errors.New("unsupported digit file version")
+	// ErrCorrupted signals a digit file with an unexpected structure.
+	ErrCorrupted = errors.New("corrupted digit file")
+	// ErrWriterClosed signals an attempt to write to a closed writer.
+	ErrWriterClosed = errors.New("digit writer is closed")
+	// errMixedDEIDs signals an attempt to write a block with digits of
+	// several detection elements.
+	errMixedDEIDs = errors.New("all digits of a block must belong to the same detection element")
+)
+
+// IndexEntry locates one block of digits within a digit file.
+type IndexEntry struct {
+	DEID      mapping.DEID
+	Offset    int64
+	NofDigits int
+}
+
+// countingWriter keeps track of the number of bytes written.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// Writer writes digits into the digit file format.
+// The index and trailer are only written by Close.
+type Writer struct {
+	out     *bufio.Writer
+	cw      *countingWriter
+	index   []IndexEntry
+	payload bytes.Buffer
+	closed  bool
+}
+
+// NewWriter writes the file header to out and returns a Writer
+// ready to accept blocks of digits.
+func NewWriter(out io.Writer) (*Writer, error) {
+	cw := &countingWriter{w: out}
+	w := &Writer{out: bufio.NewWriter(cw), cw: cw}
+	var header [headerSize]byte
+	copy(header[:], headerMagic)
+	binary.LittleEndian.PutUint16(header[4:], FileFormatVersion)
+	if _, err := w.out.Write(header[:]); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) offset() int64 {
+	return w.cw.n + int64(w.out.Buffered())
+}
+
+func (w *Writer) putUvarint(v uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	_, err := w.out.Write(buf[:n])
+	return err
+}
+
+// WriteDE writes one block with the digits of one detection element.
+// The digits are written in the order defined by Sort,
+// the input slice itself is not modified.
+func (w *Writer) WriteDE(deid mapping.DEID, digits []Digit) error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	for _, d := range digits {
+		if d.DEID != deid {
+			return errMixedDEIDs
+		}
+	}
+	if !IsSorted(digits) {
+		digits = append([]Digit(nil), digits...)
+		Sort(digits)
+	}
+	w.payload.Reset()
+	encodeDigits(&w.payload, digits)
+
+	w.index = append(w.index, IndexEntry{DEID: deid, Offset: w.offset(), NofDigits: len(digits)})
+
+	if err := w.out.WriteByte(blockTag); err != nil {
+		return err
+	}
+	for _, v := range []uint64{uint64(deid), uint64(len(digits)), uint64(w.payload.Len())} {
+		if err := w.putUvarint(v); err != nil {
+			return err
+		}
+	}
+	_, err := w.out.Write(w.payload.Bytes())
+	return err
+}
+
+// WriteContainer writes one block per detection element of the container.
+func (w *Writer) WriteContainer(c *Container) error {
+	for _, deid := range c.DEIDs() {
+		if err := w.WriteDE(deid, c.Digits(deid)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Index returns the index entries of the blocks written so far.
+func (w *Writer) Index() []IndexEntry {
+	return append([]IndexEntry(nil), w.index...)
+}
+
+// Close writes the index and the trailer and flushes the output.
+// It does not close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	w.closed = true
+	indexOffset := w.offset()
+	if err := w.out.WriteByte(indexTag); err != nil {
+		return err
+	}
+	if err := w.putUvarint(uint64(len(w.index))); err != nil {
+		return err
+	}
+	for _, e := range w.index {
+		for _, v := range []uint64{uint64(e.DEID), uint64(e.Offset), uint64(e.NofDigits)} {
+			if err := w.putUvarint(v); err != nil {
+				return err
+			}
+		}
+	}
+	var trailer [trailerSize]byte
+	binary.LittleEndian.PutUint64(trailer[:], uint64(indexOffset))
+	copy(trailer[8:], trailerMagic)
+	if _, err := w.out.Write(trailer[:]); err != nil {
+		return err
+	}
+	return w.out.Flush()
+}
+
+func encodeDigits(buf *bytes.Buffer, digits []Digit) {
+	var tmp [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) {
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+	}
+	var prev Digit
+	for _, d := range digits {
+		putUvarint(uint64(d.Orbit - prev.Orbit))
+		if d.Orbit == prev.Orbit {
+			putUvarint(uint64(d.BC - prev.BC))
+		} else {
+			putUvarint(uint64(d.BC))
+		}
+		buf.Write(tmp[:binary.PutVarint(tmp[:], int64(d.PadUID)-int64(prev.PadUID))])
+		putUvarint(uint64(d.ADC))
+		putUvarint(uint64(d.NofSamples))
+		buf.WriteByte(byte(d.Flags))
+		prev = d
+	}
+}
+
+func decodeDigits(r io.ByteReader, deid mapping.DEID, n int, digits []Digit) ([]Digit, error) {
+	var prev Digit
+	for i := 0; i < n; i++ {
+		var v [2]uint64
+		dorbit, err := binary.ReadUvarint(r)
+		if err != nil {
+			return digits, err
+		}
+		bc, err := binary.ReadUvarint(r)
+		if err != nil {
+			return digits, err
+		}
+		dpad, err := binary.ReadVarint(r)
+		if err != nil {
+			return digits, err
+		}
+		for j := range v {
+			v[j], err = binary.ReadUvarint(r)
+			if err != nil {
+				return digits, err
+			}
+		}
+		flags, err := r.ReadByte()
+		if err != nil {
+			return digits, err
+		}
+		d := Digit{
+			DEID:       deid,
+			Orbit:      prev.Orbit + uint32(dorbit),
+			PadUID:     mapping.PadUID(int64(prev.PadUID) + dpad),
+			ADC:        uint32(v[0]),
+			NofSamples: uint16(v[1]),
+			Flags:      Flag(flags),
+		}
+		if dorbit == 0 {
+			d.BC = prev.BC + uint16(bc)
+		} else {
+			d.BC = uint16(bc)
+		}
+		digits = append(digits, d)
+		prev = d
+	}
+	return digits, nil
+}
+
+func readHeader(r io.Reader) error {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	if string(header[:4]) != headerMagic {
+		return ErrInvalidMagic
+	}
+	if binary.LittleEndian.Uint16(header[4:]) != FileFormatVersion {
+		return ErrUnsupportedVersion
+	}
+	return nil
+}
+
+// Reader reads a digit file sequentially, one block at a time.
+type Reader struct {
+	in      *bufio.Reader
+	payload bytes.Buffer
+	done    bool
+}
+
+// NewReader reads and checks the file header from in.
+func NewReader(in io.Reader) (*Reader, error) {
+	r := &Reader{in: bufio.NewReader(in)}
+	if err := readHeader(r.in); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func corrupted(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrCorrupted
+	}
+	return err
+}
+
+// Next returns the digits of the next block.
+// The returned digits are appended to buf (which can be nil) so that
+// callers can reuse memory between calls.
+// At the end of the blocks Next returns io.EOF.
+func (r *Reader) Next(buf []Digit) (mapping.DEID, []Digit, error) {
+	if r.done {
+		return 0, buf, io.EOF
+	}
+	tag, err := r.in.ReadByte()
+	if err != nil {
+		return 0, buf, corrupted(err)
+	}
+	if tag == indexTag {
+		r.done = true
+		return 0, buf, io.EOF
+	}
+	if tag != blockTag {
+		return 0, buf, ErrCorrupted
+	}
+	var v [3]uint64
+	for i := range v {
+		v[i], err = binary.ReadUvarint(r.in)
+		if err != nil {
+			return 0, buf, corrupted(err)
+		}
+	}
+	deid := mapping.DEID(v[0])
+	if v[1] > v[2]/minDigitSize {
+		return deid, buf, ErrCorrupted
+	}
+	// the payload buffer only grows with the bytes actually read, so that
+	// a corrupted size does not trigger a huge allocation
+	r.payload.Reset()
+	if _, err := io.CopyN(&r.payload, r.in, int64(v[2])); err != nil {
+		return deid, buf, corrupted(err)
+	}
+	digits, err := decodeDigits(&r.payload, deid, int(v[1]), buf)
+	return deid, digits, corrupted(err)
+}
+
+// ForEachDigit calls the handler for each digit of the remaining blocks.
+func (r *Reader) ForEachDigit(handler func(d Digit)) error {
+	var buf []Digit
+	for {
+		_, digits, err := r.Next(buf[:0])
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, d := range digits {
+			handler(d)
+		}
+		buf = digits
+	}
+}
+
+// IndexedReader gives random access to the digits of a given
+// detection element, using the index stored at the end of the file.
+type IndexedReader struct {
+	in    io.ReaderAt
+	size  int64
+	index []IndexEntry
+}
+
+// NewIndexedReader reads the header, trailer and index of a digit file of the given size.
+func NewIndexedReader(in io.ReaderAt, size int64) (*IndexedReader, error) {
+	if size < headerSize+trailerSize {
+		return nil, ErrCorrupted
+	}
+	if err := readHeader(io.NewSectionReader(in, 0, headerSize)); err != nil {
+		return nil, err
+	}
+	var trailer [trailerSize]byte
+	if _, err := in.ReadAt(trailer[:], size-trailerSize); err != nil {
+		return nil, err
+	}
+	if string(trailer[8:]) != trailerMagic {
+		return nil, ErrInvalidMagic
+	}
+	indexOffset := int64(binary.LittleEndian.Uint64(trailer[:]))
+	if indexOffset < headerSize || indexOffset >= size-trailerSize {
+		return nil, ErrCorrupted
+	}
+	br := bufio.NewReader(io.NewSectionReader(in, indexOffset, size-trailerSize-indexOffset))
+	tag, err := br.ReadByte()
+	if err != nil || tag != indexTag {
+		return nil, ErrCorrupted
+	}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, corrupted(err)
+	}
+	// each index entry is at least 3 bytes long
+	if n > uint64(size-trailerSize-indexOffset)/3 {
+		return nil, ErrCorrupted
+	}
+	ir := &IndexedReader{in: in, size: size, index: make([]IndexEntry, n)}
+	for i := range ir.index {
+		var v [3]uint64
+		for j := range v {
+			v[j], err = binary.ReadUvarint(br)
+			if err != nil {
+				return nil, corrupted(err)
+			}
+		}
+		ir.index[i] = IndexEntry{DEID: mapping.DEID(v[0]), Offset: int64(v[1]), NofDigits: int(v[2])}
+	}
+	return ir, nil
+}
+
+// Index returns the index entries of the file.
+func (ir *IndexedReader) Index() []IndexEntry {
+	return append([]IndexEntry(nil), ir.index...)
+}
+
+// DEIDs returns the (unique) detection element ids present in the file,
+// in the order of their first appearance.
+func (ir *IndexedReader) DEIDs() []mapping.DEID {
+	var deids []mapping.DEID
+	seen := make(map[mapping.DEID]bool)
+	for _, e := range ir.index {
+		if !seen[e.DEID] {
+			seen[e.DEID] = true
+			deids = append(deids, e.DEID)
+		}
+	}
+	return deids
+}
+
+// Digits returns all the digits of one detection element.
+// If several blocks of the file refer to that detection element,
+// their digits are merged and sorted.
+func (ir *IndexedReader) Digits(deid mapping.DEID) ([]Digit, error) {
+	var digits []Digit
+	nblocks := 0
+	for _, e := range ir.index {
+		if e.DEID != deid {
+			continue
+		}
+		nblocks++
+		r := &Reader{in: bufio.NewReader(io.NewSectionReader(ir.in, e.Offset, ir.size-e.Offset))}
+		blockDEID, dd, err := r.Next(digits)
+		if err != nil {
+			return nil, corrupted(err)
+		}
+		if blockDEID != deid || len(dd)-len(digits) != e.NofDigits {
+			return nil, ErrCorrupted
+		}
+		digits = dd
+	}
+	if nblocks > 1 {
+		Sort(digits)
+	}
+	return digits, nil
+}
+
+// Write writes all the digits of the container into out,
+// using the digit file format.
+func Write(out io.Writer, c *Container) error {
+	w, err := NewWriter(out)
+	if err != nil {
+		return err
+	}
+	if err := w.WriteContainer(c); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Read reads all the digits of a digit file into a container.
+func Read(in io.Reader) (*Container, error) {
+	r, err := NewReader(in)
+	if err != nil {
+		return nil, err
+	}
+	c := NewContainer()
+	err = r.ForEachDigit(func(d Digit) {
+		c.Add(d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,227 @@
+package digit
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func generateDigits(deids []mapping.DEID, n int) *Container {
+	r := rand.New(rand.NewSource(42))
+	c := NewContainer()
+	for _, deid := range deids {
+		for i := 0; i < n; i++ {
+			d := Digit{
+				DEID:       deid,
+				PadUID:     mapping.PadUID(r.Intn(28000)),
+				ADC:        uint32(r.Intn(1 << 20)),
+				Orbit:      uint32(1000 + r.Intn(10)),
+				BC:         uint16(r.Intn(BCPerOrbit)),
+				NofSamples: uint16(r.Intn(20)),
+			}
+			if d.ADC > 1000000 {
+				d.Flags |= Saturated
+			}
+			c.Add(d)
+		}
+	}
+	return c
+}
+
+func equalDigits(a, b []Digit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	c := generateDigits([]mapping.DEID{100, 502, 1025}, 1000)
+	var buf bytes.Buffer
+	if err := Write(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	back, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Len() != c.Len() {
+		t.Fatalf("expected %d digits and got %d", c.Len(), back.Len())
+	}
+	for _, deid := range c.DEIDs() {
+		if !equalDigits(c.Digits(deid), back.Digits(deid)) {
+			t.Errorf("DE %d : digits differ after round trip", deid)
+		}
+	}
+}
+
+func TestFileIsCompact(t *testing.T) {
+	c := generateDigits([]mapping.DEID{100}, 1000)
+	var buf bytes.Buffer
+	if err := Write(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	// a naive encoding would be ~20 bytes per digit
+	if buf.Len() > 12*c.Len() {
+		t.Errorf("file is not compact enough : %d bytes for %d digits", buf.Len(), c.Len())
+	}
+}
+
+func TestIndexedReader(t *testing.T) {
+	c := generateDigits([]mapping.DEID{100, 502, 1025}, 100)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteContainer(c); err != nil {
+		t.Fatal(err)
+	}
+	// a second block for DE 502
+	extra := []Digit{{DEID: 502, PadUID: 1, Orbit: 1}}
+	if err := w.WriteDE(502, extra); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ir, err := NewIndexedReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ir.Index()) != 4 {
+		t.Errorf("expected 4 index entries and got %d", len(ir.Index()))
+	}
+	deids := ir.DEIDs()
+	if len(deids) != 3 {
+		t.Errorf("expected 3 deids and got %v", deids)
+	}
+	dd, err := ir.Digits(1025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalDigits(dd, c.Digits(1025)) {
+		t.Errorf("DE 1025 : digits differ")
+	}
+	dd, err = ir.Digits(502)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dd) != 101 || dd[0] != extra[0] {
+		t.Errorf("DE 502 : expected both blocks to be merged and sorted")
+	}
+	dd, err = ir.Digits(900)
+	if err != nil || len(dd) != 0 {
+		t.Errorf("DE 900 : expected no digits and no error, got %v %v", dd, err)
+	}
+}
+
+func TestReaderNextReusesBuffer(t *testing.T) {
+	c := generateDigits([]mapping.DEID{100, 101}, 10)
+	var buf bytes.Buffer
+	if err := Write(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var digits []Digit
+	n := 0
+	for {
+		var deid mapping.DEID
+		deid, digits, err = r.Next(digits[:0])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalDigits(digits, c.Digits(deid)) {
+			t.Errorf("DE %d : digits differ", deid)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected 2 blocks and got %d", n)
+	}
+}
+
+func TestInvalidFiles(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte("ABCD\x01\x00\x00\x00"))); err != ErrInvalidMagic {
+		t.Errorf("expected ErrInvalidMagic and got %v", err)
+	}
+	if _, err := NewReader(bytes.NewReader([]byte("MCHD\x02\x00\x00\x00"))); err != ErrUnsupportedVersion {
+		t.Errorf("expected ErrUnsupportedVersion and got %v", err)
+	}
+
+	c := generateDigits([]mapping.DEID{100}, 10)
+	var buf bytes.Buffer
+	if err := Write(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	truncated := buf.Bytes()[:buf.Len()/2]
+	r, err := NewReader(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Next(nil); err != ErrCorrupted {
+		t.Errorf("expected ErrCorrupted and got %v", err)
+	}
+	if _, err := NewIndexedReader(bytes.NewReader(truncated), int64(len(truncated))); err == nil {
+		t.Errorf("expected an error for a truncated file")
+	}
+}
+
+func TestCorruptedSizes(t *testing.T) {
+	header := "MCHD\x01\x00\x00\x00"
+	// a block announcing a huge payload
+	r, err := NewReader(bytes.NewReader([]byte(header + "D\x64\x01\xff\xff\xff\xff\xff\xff\xff\x7f\x00")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Next(nil); err != ErrCorrupted {
+		t.Errorf("expected ErrCorrupted for a huge payload and got %v", err)
+	}
+	// a block announcing more digits than its payload can hold
+	r, err = NewReader(bytes.NewReader([]byte(header + "D\x64\x02\x06\x00\x00\x00\x00\x00\x00")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Next(nil); err != ErrCorrupted {
+		t.Errorf("expected ErrCorrupted for too many digits and got %v", err)
+	}
+	// an index announcing a huge number of entries
+	file := []byte(header + "I\xff\xff\xff\xff\xff\xff\xff\x7f" + "\x08\x00\x00\x00\x00\x00\x00\x00" + "MCHI")
+	if _, err := NewIndexedReader(bytes.NewReader(file), int64(len(file))); err != ErrCorrupted {
+		t.Errorf("expected ErrCorrupted for a huge index and got %v", err)
+	}
+}
+
+func TestClosedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteDE(100, nil); err != ErrWriterClosed {
+		t.Errorf("expected ErrWriterClosed and got %v", err)
+	}
+}
+
+func TestWriteDEWithMixedDEIDs(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf)
+	if err := w.WriteDE(100, []Digit{{DEID: 100}, {DEID: 101}}); err == nil {
+		t.Errorf("expected an error when mixing deids in one block")
+	}
+}
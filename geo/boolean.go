@@ -0,0 +1,228 @@
+package geo
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrNotManhattan indicates that an attempt was made to use polygons with
+	// edges that are neither horizontal nor vertical
+	ErrNotManhattan = errors.New("polygons should only have horizontal and vertical edges for this algorithm")
+)
+
+// booleanOp tells if a point belongs to the result of a boolean operation,
+// given whether it is inside the first and inside the second contour.
+type booleanOp func(inA, inB bool) bool
+
+// Intersection returns the part of the plane covered by both contours.
+func Intersection(a, b Contour) (Contour, error) {
+	return booleanContour(a, b, func(inA, inB bool) bool { return inA && inB })
+}
+
+// Difference returns the part of the plane covered by a but not by b.
+func Difference(a, b Contour) (Contour, error) {
+	return booleanContour(a, b, func(inA, inB bool) bool { return inA && !inB })
+}
+
+// XOR returns the part of the plane covered by exactly one of the contours.
+func XOR(a, b Contour) (Contour, error) {
+	return booleanContour(a, b, func(inA, inB bool) bool { return inA != inB })
+}
+
+func getPolygonSliceXPositions(polygons []Polygon) []float64 {
+	xpos := []float64{}
+	for _, p := range polygons {
+		for _, vtx := range p {
+			xpos = append(xpos, vtx.X)
+		}
+	}
+	sort.Float64s(xpos)
+	return removeDuplicates(xpos)
+}
+
+// positionIndex returns the index of v within the sorted positions.
+func positionIndex(positions []float64, v float64) int {
+	return sort.Search(len(positions), func(i int) bool {
+		return positions[i] > v || EqualFloat(positions[i], v)
+	})
+}
+
+// booleanContour computes the result of a boolean operation on two contours
+// with the same kind of sweep as NewContour : the vertical edges, sorted in
+// x, are swept from left to right, each one updating the winding numbers of
+// the elementary y intervals it spans (+1 for the left, top to bottom, edges
+// and -1 for the right ones). Unlike the union, the state of both contours
+// has to be known, so the result is first computed for each (slab,interval)
+// cell and its edges are then those separating the cells in and out of it.
+//
+// Holes (clockwise polygons) are handled, and the polygons of a contour may
+// overlap. The resulting polygons are counter-clockwise, the holes clockwise,
+// and polygons touching at a corner are returned as separate polygons.
+func booleanContour(a, b Contour, op booleanOp) (Contour, error) {
+	polygons := make([]Polygon, 0, len(a)+len(b))
+	for _, c := range []Contour{a, b} {
+		for _, p := range c {
+			if len(p) == 0 {
+				return nil, errEmptyPolygon
+			}
+			if !p.isManhattan() {
+				return nil, ErrNotManhattan
+			}
+			polygons = append(polygons, p)
+		}
+	}
+
+	xs := getPolygonSliceXPositions(polygons)
+	ys := getPolygonSliceYPositions(polygons)
+	if len(xs) < 2 || len(ys) < 2 {
+		return Contour{}, nil
+	}
+
+	type operandEdge struct {
+		edge    verticalEdge
+		operand int
+	}
+	var edges []operandEdge
+	for i, c := range []Contour{a, b} {
+		for _, e := range getPolygonSliceVerticalEdges(c) {
+			edges = append(edges, operandEdge{e, i})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].edge.x < edges[j].edge.x
+	})
+
+	// sweep : inside[i][j] tells if the cell between xs[i] and xs[i+1]
+	// and between ys[j] and ys[j+1] is part of the result
+	var windings [2][]int
+	windings[0] = make([]int, len(ys)-1)
+	windings[1] = make([]int, len(ys)-1)
+	inside := make([][]bool, len(xs)-1)
+	next := 0
+	for i := range inside {
+		for ; next < len(edges) && positionIndex(xs, edges[next].edge.x) == i; next++ {
+			e := edges[next]
+			w := 1
+			if !isLeftEdge(e.edge) {
+				w = -1
+			}
+			for j := positionIndex(ys, bottom(e.edge)); j < positionIndex(ys, top(e.edge)); j++ {
+				windings[e.operand][j] += w
+			}
+		}
+		inside[i] = make([]bool, len(ys)-1)
+		for j := range inside[i] {
+			inside[i][j] = op(windings[0][j] > 0, windings[1][j] > 0)
+		}
+	}
+	in := func(i, j int) bool {
+		return i >= 0 && i < len(inside) && j >= 0 && j < len(ys)-1 && inside[i][j]
+	}
+
+	// the edges of the result, oriented so that the inside is on their left
+	var contourEdges []manhattanEdge
+	for i, x := range xs {
+		var current *verticalEdge
+		for j := 0; j < len(ys)-1; j++ {
+			left, right := in(i-1, j), in(i, j)
+			if left == right {
+				current = nil
+				continue
+			}
+			if current != nil && right == isLeftEdge(*current) {
+				// same direction as the piece below, extend it
+				if right {
+					current.y1 = ys[j+1]
+				} else {
+					current.y2 = ys[j+1]
+				}
+				continue
+			}
+			e := verticalEdge{x, ys[j], ys[j+1]}
+			if right {
+				e = verticalEdge{x, ys[j+1], ys[j]}
+			}
+			contourEdges = append(contourEdges, &e)
+			current = &e
+		}
+	}
+	for j, y := range ys {
+		var current *horizontalEdge
+		for i := 0; i < len(xs)-1; i++ {
+			below, above := in(i, j-1), in(i, j)
+			if below == above {
+				current = nil
+				continue
+			}
+			if current != nil && above == isLeftToRight(*current) {
+				// same direction as the piece on the left, extend it
+				if above {
+					current.x2 = xs[i+1]
+				} else {
+					current.x1 = xs[i+1]
+				}
+				continue
+			}
+			e := horizontalEdge{y, xs[i], xs[i+1]}
+			if !above {
+				e = horizontalEdge{y, xs[i+1], xs[i]}
+			}
+			contourEdges = append(contourEdges, &e)
+			current = &e
+		}
+	}
+	return chainEdges(contourEdges)
+}
+
+// chainEdges links the edges into closed polygons. At vertices shared
+// by two polygons touching at a corner, the leftmost turn is taken,
+// which keeps the polygons apart.
+func chainEdges(edges []manhattanEdge) (Contour, error) {
+	outgoing := make(map[Vertex][]int, len(edges))
+	for i, e := range edges {
+		outgoing[e.begin()] = append(outgoing[e.begin()], i)
+	}
+	used := make([]bool, len(edges))
+	contour := Contour{}
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+		var vertices []Vertex
+		current := start
+		for {
+			e := edges[current]
+			used[current] = true
+			vertices = append(vertices, e.begin())
+			dx, dy := e.end().X-e.begin().X, e.end().Y-e.begin().Y
+			chosen := -1
+			for _, k := range outgoing[e.end()] {
+				if used[k] && k != start {
+					continue
+				}
+				if chosen < 0 {
+					chosen = k
+					continue
+				}
+				n := edges[k]
+				if dx*(n.end().Y-n.begin().Y)-dy*(n.end().X-n.begin().X) > 0 {
+					chosen = k
+				}
+			}
+			if chosen < 0 {
+				return nil, errDisconnectedEdge
+			}
+			if chosen == start {
+				break
+			}
+			current = chosen
+		}
+		p, err := closePolygon(vertices)
+		if err != nil {
+			return nil, errClosingPolygon
+		}
+		contour = append(contour, p)
+	}
+	return contour, nil
+}
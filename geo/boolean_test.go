@@ -0,0 +1,132 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func square(xmin, ymin, xmax, ymax float64) Polygon {
+	return Polygon{{xmin, ymin}, {xmax, ymin}, {xmax, ymax}, {xmin, ymax}, {xmin, ymin}}
+}
+
+func contourArea(c Contour) float64 {
+	a := 0.0
+	for _, p := range c {
+		a += p.signedArea()
+	}
+	return a
+}
+
+func TestIntersection(t *testing.T) {
+	c, err := Intersection(Contour{square(0, 0, 2, 2)}, Contour{square(1, 1, 3, 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContour(t, c, Contour{square(1, 1, 2, 2)})
+	if !c[0].isCounterClockwiseOriented() {
+		t.Error("expected a counter-clockwise polygon")
+	}
+}
+
+func TestIntersectionOfDisjointContoursIsEmpty(t *testing.T) {
+	c, err := Intersection(Contour{square(0, 0, 1, 1)}, Contour{square(2, 0, 3, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c) != 0 {
+		t.Errorf("expected an empty contour, got %v", c)
+	}
+}
+
+func TestDifferenceMakesHoles(t *testing.T) {
+	c, err := Difference(Contour{square(0, 0, 3, 3)}, Contour{square(1, 1, 2, 2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c) != 2 {
+		t.Fatalf("expected an outer polygon and a hole, got %v", c)
+	}
+	checkContour(t, c, Contour{square(0, 0, 3, 3), square(1, 1, 2, 2)})
+	if c[0].isCounterClockwiseOriented() == c[1].isCounterClockwiseOriented() {
+		t.Error("expected a counter-clockwise outer polygon and a clockwise hole")
+	}
+	if math.Abs(contourArea(c)-8) > 1e-9 {
+		t.Errorf("expected an area of 8, got %v", contourArea(c))
+	}
+	// a contour with a hole can be used as input
+	d, err := Difference(Contour{square(0, 0, 3, 3)}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContour(t, d, Contour{square(1, 1, 2, 2)})
+}
+
+func TestDifferenceSplits(t *testing.T) {
+	c, err := Difference(Contour{square(0, 0, 3, 1)}, Contour{square(1, -1, 2, 2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContour(t, c, Contour{square(0, 0, 1, 1), square(2, 0, 3, 1)})
+}
+
+func TestXORTouchingAtCorners(t *testing.T) {
+	c, err := XOR(Contour{square(0, 0, 2, 2)}, Contour{square(1, 1, 3, 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Contour{
+		{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}, {0, 0}},
+		{{2, 1}, {3, 1}, {3, 3}, {1, 3}, {1, 2}, {2, 2}, {2, 1}},
+	}
+	checkContour(t, c, expected)
+	for _, p := range c {
+		if !p.isCounterClockwiseOriented() || len(p) != 7 {
+			t.Errorf("expected two separate counter-clockwise L shapes, got %v", p)
+		}
+	}
+}
+
+func TestBooleanOperationsWithOverlappingPolygons(t *testing.T) {
+	// the polygons of one contour may overlap, as for a list of pads
+	a := Contour{square(0, 0, 2, 1), square(1, 0, 3, 1)}
+	c, err := Intersection(a, Contour{square(0, 0, 3, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContour(t, c, Contour{square(0, 0, 3, 1)})
+}
+
+func TestBooleanOperationsAreConsistent(t *testing.T) {
+	a := Contour{square(0, 0, 4, 4), {{1, 1}, {1, 3}, {3, 3}, {3, 1}, {1, 1}}, square(1.5, 1.5, 2.5, 2.5)}
+	b := Contour{square(2, -1, 5, 2), square(-1, 3.5, 6, 5)}
+	inter, err := Intersection(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab, err := Difference(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ba, err := Difference(b, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xor, err := XOR(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	areaA := contourArea(a)
+	if math.Abs(contourArea(ab)+contourArea(inter)-areaA) > 1e-9 {
+		t.Errorf("area(a-b)+area(a&b) = %v, expected area(a) = %v", contourArea(ab)+contourArea(inter), areaA)
+	}
+	if math.Abs(contourArea(xor)-contourArea(ab)-contourArea(ba)) > 1e-9 {
+		t.Errorf("area(a^b) = %v, expected area(a-b)+area(b-a) = %v", contourArea(xor), contourArea(ab)+contourArea(ba))
+	}
+}
+
+func TestBooleanOperationsRequireManhattanPolygons(t *testing.T) {
+	triangle := Contour{{{0, 0}, {1, 0}, {0, 1}, {0, 0}}}
+	if _, err := Intersection(triangle, Contour{square(0, 0, 1, 1)}); err != ErrNotManhattan {
+		t.Errorf("expected ErrNotManhattan, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package geo
+
+// Clip returns the part of the polygon within the bounding box
+// (Sutherland-Hodgman algorithm). The orientation of the polygon is kept.
+// As the clipped polygon of a concave one stays a single polygon, it might
+// contain zero-width parts along the box edges, which is fine for drawing.
+// An empty polygon is returned if nothing is left.
+func (p Polygon) Clip(b BBox) Polygon {
+	if len(p) == 0 {
+		return nil
+	}
+	pts := []Vertex(p)
+	if p.isClosed() {
+		pts = pts[:len(pts)-1]
+	}
+	type edge struct {
+		inside    func(v Vertex) bool
+		intersect func(a, c Vertex) Vertex
+	}
+	lerpX := func(a, c Vertex, x float64) Vertex {
+		return Vertex{x, a.Y + (c.Y-a.Y)*(x-a.X)/(c.X-a.X)}
+	}
+	lerpY := func(a, c Vertex, y float64) Vertex {
+		return Vertex{a.X + (c.X-a.X)*(y-a.Y)/(c.Y-a.Y), y}
+	}
+	edges := []edge{
+		{func(v Vertex) bool { return v.X >= b.Xmin() }, func(a, c Vertex) Vertex { return lerpX(a, c, b.Xmin()) }},
+		{func(v Vertex) bool { return v.X <= b.Xmax() }, func(a, c Vertex) Vertex { return lerpX(a, c, b.Xmax()) }},
+		{func(v Vertex) bool { return v.Y >= b.Ymin() }, func(a, c Vertex) Vertex { return lerpY(a, c, b.Ymin()) }},
+		{func(v Vertex) bool { return v.Y <= b.Ymax() }, func(a, c Vertex) Vertex { return lerpY(a, c, b.Ymax()) }},
+	}
+	for _, e := range edges {
+		if len(pts) == 0 {
+			break
+		}
+		in := pts
+		pts = make([]Vertex, 0, len(in)+4)
+		prev := in[len(in)-1]
+		for _, v := range in {
+			switch {
+			case e.inside(v) && e.inside(prev):
+				pts = append(pts, v)
+			case e.inside(v):
+				pts = append(pts, e.intersect(prev, v), v)
+			case e.inside(prev):
+				pts = append(pts, e.intersect(prev, v))
+			}
+			prev = v
+		}
+	}
+	if len(pts) < 3 {
+		return nil
+	}
+	return append(Polygon(pts), pts[0])
+}
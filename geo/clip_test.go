@@ -0,0 +1,38 @@
+package geo
+
+import "testing"
+
+func TestClipInside(t *testing.T) {
+	p := Polygon{{1, 1}, {2, 1}, {2, 2}, {1, 2}, {1, 1}}
+	if c := p.Clip(NewBBoxUnchecked(0, 0, 10, 10)); !EqualPolygon(c, p) {
+		t.Errorf("want %v, got %v", p, c)
+	}
+}
+
+func TestClipOutside(t *testing.T) {
+	p := Polygon{{1, 1}, {2, 1}, {2, 2}, {1, 2}, {1, 1}}
+	if c := p.Clip(NewBBoxUnchecked(5, 5, 10, 10)); c != nil {
+		t.Errorf("want nothing, got %v", c)
+	}
+}
+
+func TestClipPartial(t *testing.T) {
+	p := Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+	c := p.Clip(NewBBoxUnchecked(5, -1, 20, 5))
+	want := Polygon{{5, 0}, {10, 0}, {10, 5}, {5, 5}, {5, 0}}
+	if !EqualPolygon(c, want) {
+		t.Errorf("want %v, got %v", want, c)
+	}
+	if !c.isCounterClockwiseOriented() || !c.isClosed() {
+		t.Errorf("orientation or closure not kept")
+	}
+}
+
+func TestClipConcave(t *testing.T) {
+	// U shape, whose clipping keeps the two arms
+	p := Polygon{{0, 0}, {3, 0}, {3, 3}, {2, 3}, {2, 1}, {1, 1}, {1, 3}, {0, 3}, {0, 0}}
+	c := p.Clip(NewBBoxUnchecked(-1, 2, 4, 4))
+	if a := c.signedArea(); a != 2 {
+		t.Errorf("want an area of 2, got %v", a)
+	}
+}
@@ -3,6 +3,7 @@ package geo
 import (
 	"fmt"
 	"log"
+	"math"
 )
 
 // Contour is a set of polygons
@@ -81,3 +82,55 @@ func (c Contour) String() string {
 func (c Contour) BBox() BBox {
 	return getVerticesBBox(c.getVertices())
 }
+
+// Centroid returns the center of mass of the contour, i.e. the
+// area-weighted mean of the centroids of its polygons (holes, being
+// oriented the other way, have negative weights).
+func (c Contour) Centroid() Vertex {
+	var cx, cy, area float64
+	for _, p := range c {
+		a := p.signedArea()
+		v := p.Centroid()
+		cx += a * v.X
+		cy += a * v.Y
+		area += a
+	}
+	if math.Abs(area) < tiny {
+		b := c.BBox()
+		return Vertex{b.Xcenter(), b.Ycenter()}
+	}
+	return Vertex{cx / area, cy / area}
+}
+
+// withHoles groups the polygons of the contour as lists made of one
+// (counter-clockwise) outer polygon followed by the (clockwise) holes
+// it contains, as expected by the GeoJSON and WKT formats.
+func (c Contour) withHoles() [][]Polygon {
+	var groups [][]Polygon
+	var holes []Polygon
+	for _, p := range c {
+		if p.isCounterClockwiseOriented() {
+			groups = append(groups, []Polygon{p})
+		} else {
+			holes = append(holes, p)
+		}
+	}
+	for _, h := range holes {
+		hb := h.BBox()
+		best := -1
+		bestArea := math.MaxFloat64
+		for i, g := range groups {
+			pb := g[0].BBox()
+			if hb.Xmin() >= pb.Xmin() && hb.Xmax() <= pb.Xmax() &&
+				hb.Ymin() >= pb.Ymin() && hb.Ymax() <= pb.Ymax() &&
+				g[0].signedArea() < bestArea {
+				best = i
+				bestArea = g[0].signedArea()
+			}
+		}
+		if best >= 0 {
+			groups[best] = append(groups[best], h)
+		}
+	}
+	return groups
+}
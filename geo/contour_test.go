@@ -12,3 +12,32 @@ func TestContoursAreEqualAsLongAsTheyContainTheSameSetOfVertices(t *testing.T) {
 		t.Error("contours should be equal")
 	}
 }
+
+func TestContourCentroid(t *testing.T) {
+	contour, err := NewContour([]Polygon{
+		{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}},
+		{{4, 0}, {6, 0}, {6, 2}, {4, 2}, {4, 0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := contour.Centroid()
+	if !EqualFloat(c.X, 3) || !EqualFloat(c.Y, 1) {
+		t.Errorf("expected centroid (3,1) and got (%v,%v)", c.X, c.Y)
+	}
+	// a 4x4 square with a 1x1 hole at (1,1)
+	withHole, err := NewContour([]Polygon{
+		{{0, 0}, {4, 0}, {4, 1}, {0, 1}, {0, 0}},
+		{{0, 2}, {4, 2}, {4, 4}, {0, 4}, {0, 2}},
+		{{0, 1}, {1, 1}, {1, 2}, {0, 2}, {0, 1}},
+		{{2, 1}, {4, 1}, {4, 2}, {2, 2}, {2, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = withHole.Centroid()
+	want := (16*2 - 1.5) / 15
+	if !EqualFloat(c.X, want) || !EqualFloat(c.Y, want) {
+		t.Errorf("expected centroid (%v,%v) and got (%v,%v)", want, want, c.X, c.Y)
+	}
+}
@@ -0,0 +1,300 @@
+package geo
+
+import (
+	"math"
+)
+
+// Drawing is a backend-neutral list of shapes (polygons, rectangles, texts...),
+// possibly grouped and styled with CSS classes, that can be output in several
+// formats (SVG, PDF, EPS).
+type Drawing struct {
+	styleBuffer                  string
+	elements                     []element
+	xleft, xright, ybottom, ytop float64
+}
+
+// NewDrawing returns an empty drawing.
+func NewDrawing() *Drawing {
+	return &Drawing{}
+}
+
+func (d *Drawing) assertViewBox() {
+	if d.xleft < d.xright && d.ybottom < d.ytop {
+		return
+	}
+	// loop over all elements to find the boundaries
+	xmin := math.MaxFloat64
+	xmax := -xmin
+	ymin := xmin
+	ymax := -ymin
+	for _, e := range d.elements {
+		b := e.bbox()
+		if b.Width() > tiny && b.Height() > tiny {
+			xmin = math.Min(xmin, b.Xmin())
+			xmax = math.Max(xmax, b.Xmax())
+			ymin = math.Min(ymin, b.Ymin())
+			ymax = math.Max(ymax, b.Ymax())
+		}
+	}
+	d.xleft = xmin
+	d.xright = xmax
+	d.ybottom = ymin
+	d.ytop = ymax
+}
+
+func (d *Drawing) Translate(x0, y0 float64) {
+	for _, e := range d.elements {
+		e.translate(x0, y0)
+	}
+	d.xleft += x0
+	d.xright += x0
+	d.ytop += y0
+	d.ybottom += y0
+}
+
+func (d *Drawing) MoveToOrigin() {
+	d.assertViewBox()
+	d.Translate(-d.xleft, -d.ybottom)
+}
+
+func (d *Drawing) ViewBox(xleft, xright, ybottom, ytop float64) {
+	d.xleft = xleft
+	d.xright = xright
+	d.ytop = ytop
+	d.ybottom = ybottom
+}
+
+func (d *Drawing) ViewBoxHeight() float64 {
+	return d.ytop - d.ybottom
+}
+
+func (d *Drawing) ViewBoxWidth() float64 {
+	return d.xright - d.xleft
+}
+
+// GroupStart starts a group tag with a given classname.
+func (d *Drawing) GroupStart(classname string) {
+	d.appendElement(grouptag(classname))
+}
+
+// GroupEnd ends a group tag
+func (d *Drawing) GroupEnd() {
+	d.appendElement(groupendtag{})
+}
+
+// GroupStartWithAttrs starts a group tag with a given classname
+// and some extra attributes.
+func (d *Drawing) GroupStartWithAttrs(classname string, attrs ...Attr) {
+	d.appendElement(attrgrouptag{classname, attrs})
+}
+
+// Rect adds a rectangle object
+func (d *Drawing) Rect(x, y, width, height float64) {
+	d.RectWithClass(x, y, width, height, "")
+}
+
+// Rect adds a rectangle object
+func (d *Drawing) RectWithClass(x, y, width, height float64, class string) {
+	d.appendElement(&rectag{x, y, width, height, class})
+}
+
+// Text adds a text object
+func (d *Drawing) Text(text string, x, y float64) {
+	d.appendElement(&textag{x, y, text})
+}
+
+// CenteredText adds a text object of a given font size (in user units)
+// and CSS class, centered on (x,y)
+func (d *Drawing) CenteredText(text string, x, y, fontSize float64, class string) {
+	d.appendElement(&labeltag{x, y, fontSize, text, class})
+}
+
+// Polygon adds a polygon object
+func (d *Drawing) Polygon(p *Polygon) {
+	d.PolygonWithClass(p, "")
+}
+
+// PolygonWithClass adds a polygon object with a given CSS class
+func (d *Drawing) PolygonWithClass(p *Polygon, class string) {
+	vertices := p.getVertices()
+	x := make([]float64, len(vertices))
+	y := make([]float64, len(vertices))
+	for i, v := range vertices {
+		x[i] = v.X
+		y[i] = v.Y
+	}
+	d.appendElement(&poltag{x, y, class, nil})
+}
+
+// PolygonWithAttrs adds a polygon object with a given CSS class
+// and some extra attributes (e.g. id or data-*)
+func (d *Drawing) PolygonWithAttrs(p *Polygon, class string, attrs ...Attr) {
+	d.PolygonWithClass(p, class)
+	d.elements[len(d.elements)-1].(*poltag).attrs = attrs
+}
+
+func (d *Drawing) Circle(x, y, radius float64) {
+	d.appendElement(&cirtag{x, y, radius})
+}
+
+// Contour adds one polygon object per sub-contour
+func (d *Drawing) Contour(c *Contour) {
+	for _, p := range *c {
+		d.Polygon(&p)
+	}
+}
+
+// Style add some style
+func (d *Drawing) Style(style string) {
+	d.styleBuffer += style
+}
+
+// element is one item (shape, text or group delimiter) of a drawing.
+// The output formats (see svgwriter.go and vector.go) know how to
+// render each kind of element.
+type element interface {
+	translate(x0, y0 float64)
+	bbox() BBox
+}
+
+var (
+	tiny       float64 = 1e-9
+	tinyBox, _         = NewBBox(-tiny/2.0, -tiny/2.0, tiny/2.0, tiny/2.0)
+)
+
+type grouptag string
+type groupendtag struct{}
+type rectag struct {
+	x, y, width, height float64
+	class               string
+}
+type textag struct {
+	x, y float64
+	text string
+}
+type labeltag struct {
+	x, y, size  float64
+	text, class string
+}
+type poltag struct {
+	x     []float64
+	y     []float64
+	class string
+	attrs []Attr
+}
+
+// Attr is an extra attribute of an SVG element.
+type Attr struct {
+	Name, Value string
+}
+
+type attrgrouptag struct {
+	class string
+	attrs []Attr
+}
+type cirtag struct {
+	x, y, radius float64
+}
+
+func (g grouptag) translate(x, y float64) {
+}
+
+func (g grouptag) bbox() BBox {
+	return tinyBox
+}
+
+func (g attrgrouptag) translate(x, y float64) {
+}
+
+func (g attrgrouptag) bbox() BBox {
+	return tinyBox
+}
+
+func (g groupendtag) bbox() BBox {
+	return tinyBox
+}
+
+func (g groupendtag) translate(x, y float64) {
+}
+
+func (r *rectag) translate(x0, y0 float64) {
+	r.x += x0
+	r.y += y0
+}
+
+func (r *rectag) bbox() BBox {
+	b, err := NewBBox(r.x, r.y, r.x+r.width, r.y+r.height)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (t *textag) translate(x0, y0 float64) {
+	t.x += x0
+	t.y += y0
+}
+
+func (t *textag) bbox() BBox {
+	//FIXME: is there a way to actually get the size here ??
+	return tinyBox
+}
+
+func (l *labeltag) translate(x0, y0 float64) {
+	l.x += x0
+	l.y += y0
+}
+
+func (l *labeltag) bbox() BBox {
+	// approximate extent, assuming an average character width of 0.6 em
+	dx := TextWidth(l.text, l.size) / 2
+	dy := l.size / 2
+	return NewBBoxUnchecked(l.x-dx, l.y-dy, l.x+dx, l.y+dy)
+}
+
+// TextWidth returns an estimate of the width of a text for a given font size.
+func TextWidth(text string, fontSize float64) float64 {
+	return 0.6 * fontSize * float64(len([]rune(text)))
+}
+
+func (p *poltag) translate(x0, y0 float64) {
+	for i, _ := range p.x {
+		p.x[i] += x0
+		p.y[i] += y0
+	}
+}
+
+func (p *poltag) bbox() BBox {
+	xmin := math.MaxFloat64
+	xmax := -xmin
+	ymin := xmin
+	ymax := -ymin
+	for i, _ := range p.x {
+		xmin = math.Min(xmin, p.x[i])
+		xmax = math.Max(xmax, p.x[i])
+		ymin = math.Min(ymin, p.y[i])
+		ymax = math.Max(ymax, p.y[i])
+	}
+	b, err := NewBBox(xmin, ymin, xmax, ymax)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (c *cirtag) translate(x0, y0 float64) {
+	c.x += x0
+	c.y += y0
+}
+
+func (c *cirtag) bbox() BBox {
+	b, err := NewBBox(c.x-c.radius, c.y-c.radius, c.x+c.radius, c.y+c.radius)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (d *Drawing) appendElement(e element) {
+	d.elements = append(d.elements, e)
+}
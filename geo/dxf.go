@@ -0,0 +1,211 @@
+package geo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidDXF = errors.New("invalid DXF file")
+
+// DXF colour indices (AutoCAD Color Index)
+const (
+	DXFRed   = 1
+	DXFBlue  = 5
+	DXFWhite = 7
+	DXFGray  = 8
+)
+
+type dxfPolyline struct {
+	layer string
+	p     Polygon
+}
+
+// DXFWriter accumulates closed polylines on named layers and writes them
+// as an ASCII DXF (AutoCAD R12) file, readable by most CAD tools.
+// The R12 format has no variable to declare the unit of the drawing
+// ($INSUNITS was introduced later) so the unit is only a convention
+// between the writer and the reader of the file.
+type DXFWriter struct {
+	// Scale is applied to all the coordinates, e.g. 10 to get
+	// millimetres from centimetres.
+	Scale     float64
+	layers    []string
+	colors    map[string]int
+	polylines []dxfPolyline
+}
+
+// NewDXFWriter returns a writer in millimetres, for coordinates given
+// in centimetres.
+func NewDXFWriter() *DXFWriter {
+	return &DXFWriter{Scale: 10, colors: make(map[string]int)}
+}
+
+// Layer declares a layer with a given colour. Layers used without being
+// declared are white.
+func (w *DXFWriter) Layer(name string, color int) {
+	if _, ok := w.colors[name]; !ok {
+		w.layers = append(w.layers, name)
+	}
+	w.colors[name] = color
+}
+
+// Polygon adds a closed polyline to a layer.
+func (w *DXFWriter) Polygon(layer string, p Polygon) {
+	if _, ok := w.colors[layer]; !ok {
+		w.Layer(layer, DXFWhite)
+	}
+	w.polylines = append(w.polylines, dxfPolyline{layer, p})
+}
+
+// Contour adds one closed polyline per polygon of the contour to a layer.
+func (w *DXFWriter) Contour(layer string, c Contour) {
+	for _, p := range c {
+		w.Polygon(layer, p)
+	}
+}
+
+// Write outputs the DXF file.
+func (w *DXFWriter) Write(out io.Writer) error {
+	b := bufio.NewWriter(out)
+	pair := func(code int, value string) {
+		fmt.Fprintf(b, "%d\n%s\n", code, value)
+	}
+	number := func(code int, v float64) {
+		pair(code, strconv.FormatFloat(v*w.Scale, 'f', -1, 64))
+	}
+	pair(0, "SECTION")
+	pair(2, "HEADER")
+	pair(9, "$ACADVER")
+	pair(1, "AC1009")
+	pair(0, "ENDSEC")
+	pair(0, "SECTION")
+	pair(2, "TABLES")
+	pair(0, "TABLE")
+	pair(2, "LAYER")
+	pair(70, strconv.Itoa(len(w.layers)))
+	for _, l := range w.layers {
+		pair(0, "LAYER")
+		pair(2, l)
+		pair(70, "0")
+		pair(62, strconv.Itoa(w.colors[l]))
+		pair(6, "CONTINUOUS")
+	}
+	pair(0, "ENDTAB")
+	pair(0, "ENDSEC")
+	pair(0, "SECTION")
+	pair(2, "ENTITIES")
+	for _, pl := range w.polylines {
+		p := pl.p
+		if len(p) > 1 && p.isClosed() {
+			// the closing vertex is implied by the closed flag
+			p = p[:len(p)-1]
+		}
+		pair(0, "POLYLINE")
+		pair(8, pl.layer)
+		pair(66, "1")
+		pair(70, "1")
+		for _, v := range p {
+			pair(0, "VERTEX")
+			pair(8, pl.layer)
+			number(10, v.X)
+			number(20, v.Y)
+		}
+		pair(0, "SEQEND")
+		pair(8, pl.layer)
+	}
+	pair(0, "ENDSEC")
+	pair(0, "EOF")
+	return b.Flush()
+}
+
+// ReadDXF reads the closed polylines (POLYLINE and LWPOLYLINE entities)
+// of a DXF file and returns them grouped by layer, as closed polygons
+// with the coordinates of the file. A polyline is closed if its closed
+// flag (bit 1 of group code 70) is set or if its last vertex is its first
+// one. Other polylines are ignored.
+func ReadDXF(in io.Reader) (map[string]Contour, error) {
+	scanner := bufio.NewScanner(in)
+	var code int
+	var value string
+	readPair := func() bool {
+		if !scanner.Scan() {
+			return false
+		}
+		c, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil || !scanner.Scan() {
+			return false
+		}
+		code, value = c, strings.TrimSpace(scanner.Text())
+		return true
+	}
+	layers := make(map[string]Contour)
+	var current *Polygon
+	var layer string
+	var closed bool
+	closePolyline := func() {
+		if current != nil && len(*current) > 1 {
+			p := *current
+			if p.isClosed() {
+				layers[layer] = append(layers[layer], p)
+			} else if closed {
+				layers[layer] = append(layers[layer], append(p, p[0]))
+			}
+		}
+		current = nil
+		closed = false
+	}
+	var entity string
+	var x float64
+	for readPair() {
+		if code == 0 && value == "EOF" {
+			closePolyline()
+			return layers, scanner.Err()
+		}
+		switch code {
+		case 0:
+			entity = value
+			if value == "VERTEX" && current != nil {
+				continue
+			}
+			closePolyline()
+			if value == "POLYLINE" || value == "LWPOLYLINE" {
+				current = &Polygon{}
+			}
+		case 8:
+			if entity == "POLYLINE" || entity == "LWPOLYLINE" {
+				layer = value
+			}
+		case 70:
+			// for vertices, group code 70 holds the vertex flags
+			if current == nil || (entity != "POLYLINE" && entity != "LWPOLYLINE") {
+				continue
+			}
+			flags, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, ErrInvalidDXF
+			}
+			closed = flags&1 != 0
+		case 10, 20:
+			if current == nil || (entity != "VERTEX" && entity != "LWPOLYLINE") {
+				continue
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, ErrInvalidDXF
+			}
+			if code == 10 {
+				x = v
+			} else {
+				*current = append(*current, Vertex{x, v})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrInvalidDXF
+}
@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDXFWrite(t *testing.T) {
+	w := NewDXFWriter()
+	w.Layer("DE100", DXFRed)
+	w.Polygon("DE100", Polygon{{0, 0}, {1, 0}, {1, 1}, {0, 0}})
+	var buf bytes.Buffer
+	if err := w.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"9\n$ACADVER\n1\nAC1009\n",
+		"0\nLAYER\n2\nDE100\n70\n0\n62\n1\n",
+		"0\nPOLYLINE\n8\nDE100\n66\n1\n70\n1\n",
+		"0\nVERTEX\n8\nDE100\n10\n10\n20\n10\n",
+		"0\nSEQEND\n",
+		"0\nEOF\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not found in:\n%s", want, out)
+		}
+	}
+	// closing vertex is implied by the closed flag
+	if n := strings.Count(out, "VERTEX"); n != 3 {
+		t.Errorf("want 3 vertices, got %d", n)
+	}
+}
+
+func TestDXFRoundTrip(t *testing.T) {
+	w := NewDXFWriter()
+	w.Scale = 1
+	square := Polygon{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}
+	hole := Polygon{{0.25, 0.25}, {0.25, 0.75}, {0.75, 0.75}, {0.75, 0.25}, {0.25, 0.25}}
+	w.Contour("a", Contour{square, hole})
+	w.Polygon("b", square)
+	var buf bytes.Buffer
+	w.Write(&buf)
+	layers, err := ReadDXF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 2 || len(layers["a"]) != 2 || len(layers["b"]) != 1 {
+		t.Fatalf("unexpected layers %v", layers)
+	}
+	if !EqualPolygon(layers["a"][1], hole) || !EqualPolygon(layers["b"][0], square) {
+		t.Errorf("round trip failed: %v", layers)
+	}
+}
+
+func TestReadLWPolyline(t *testing.T) {
+	dxf := "0\nSECTION\n2\nENTITIES\n0\nLWPOLYLINE\n8\nL\n90\n3\n70\n1\n10\n0\n20\n0\n10\n2\n20\n0\n10\n2\n20\n2\n0\nENDSEC\n0\nEOF\n"
+	layers, err := ReadDXF(strings.NewReader(dxf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Polygon{{0, 0}, {2, 0}, {2, 2}, {0, 0}}
+	if len(layers["L"]) != 1 || !EqualPolygon(layers["L"][0], want) {
+		t.Errorf("want %v, got %v", want, layers)
+	}
+}
+
+func TestReadOpenPolylines(t *testing.T) {
+	// an open LWPOLYLINE, an open POLYLINE and an open LWPOLYLINE
+	// ending at its first vertex
+	dxf := "0\nSECTION\n2\nENTITIES\n" +
+		"0\nLWPOLYLINE\n8\nL\n90\n3\n70\n0\n10\n0\n20\n0\n10\n2\n20\n0\n10\n2\n20\n2\n" +
+		"0\nPOLYLINE\n8\nL\n66\n1\n70\n0\n0\nVERTEX\n8\nL\n10\n0\n20\n0\n70\n1\n0\nVERTEX\n8\nL\n10\n1\n20\n0\n0\nSEQEND\n" +
+		"0\nLWPOLYLINE\n8\nM\n90\n4\n70\n0\n10\n0\n20\n0\n10\n2\n20\n0\n10\n2\n20\n2\n10\n0\n20\n0\n" +
+		"0\nENDSEC\n0\nEOF\n"
+	layers, err := ReadDXF(strings.NewReader(dxf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers["L"]) != 0 {
+		t.Errorf("open polylines should be ignored, got %v", layers["L"])
+	}
+	want := Polygon{{0, 0}, {2, 0}, {2, 2}, {0, 0}}
+	if len(layers["M"]) != 1 || !EqualPolygon(layers["M"][0], want) {
+		t.Errorf("want %v, got %v", want, layers["M"])
+	}
+}
+
+func TestReadInvalidDXF(t *testing.T) {
+	for _, dxf := range []string{"", "0\nSECTION\n", "x\ny\n", "0\nPOLYLINE\n0\nVERTEX\n10\nx\n0\nEOF\n"} {
+		if _, err := ReadDXF(strings.NewReader(dxf)); err != ErrInvalidDXF {
+			t.Errorf("%q: want ErrInvalidDXF, got %v", dxf, err)
+		}
+	}
+}
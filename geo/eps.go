@@ -0,0 +1,70 @@
+package geo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+)
+
+// epsWriter writes the PostScript program of an EPS document.
+type epsWriter struct {
+	out *bufio.Writer
+}
+
+func (w *epsWriter) path(x, y []float64, s shapeStyle, strokeWidth float64) {
+	if len(x) < 2 {
+		return
+	}
+	fr, fg, fb, fill := s.fillColor()
+	sr, sg, sb, stroke := s.strokeColor()
+	if !fill && !stroke {
+		return
+	}
+	fmt.Fprintf(w.out, "newpath %s %s moveto", psNum(x[0]), psNum(y[0]))
+	for i := 1; i < len(x); i++ {
+		fmt.Fprintf(w.out, " %s %s lineto", psNum(x[i]), psNum(y[i]))
+	}
+	w.out.WriteString(" closepath\n")
+	if fill {
+		fmt.Fprintf(w.out, "gsave %s %s %s setrgbcolor eofill grestore\n", psNum(fr), psNum(fg), psNum(fb))
+	}
+	if stroke {
+		fmt.Fprintf(w.out, "%s %s %s setrgbcolor %s setlinewidth stroke\n", psNum(sr), psNum(sg), psNum(sb), psNum(strokeWidth))
+	} else {
+		w.out.WriteString("newpath\n")
+	}
+}
+
+func (w *epsWriter) text(text string, x, y, size float64, s shapeStyle, centered bool) {
+	r, g, b, ok := s.fillColor()
+	if !ok || len(text) == 0 {
+		return
+	}
+	fmt.Fprintf(w.out, "%s %s %s setrgbcolor /Helvetica findfont %s scalefont setfont\n",
+		psNum(r), psNum(g), psNum(b), psNum(size))
+	if centered {
+		fmt.Fprintf(w.out, "(%s) dup stringwidth pop 2 div neg %s add %s moveto show\n",
+			escapeString(text), psNum(x), psNum(y-0.35*size))
+		return
+	}
+	fmt.Fprintf(w.out, "%s %s moveto (%s) show\n", psNum(x), psNum(y), escapeString(text))
+}
+
+// WriteEPS outputs the drawing as an Encapsulated PostScript document
+// width points wide, the y axis pointing up (see page).
+func (d *Drawing) WriteEPS(out io.Writer, width float64) error {
+	p, err := d.page(width)
+	if err != nil {
+		return err
+	}
+	w := &epsWriter{bufio.NewWriter(out)}
+	fmt.Fprintf(w.out, "%%!PS-Adobe-3.0 EPSF-3.0\n")
+	fmt.Fprintf(w.out, "%%%%BoundingBox: 0 0 %d %d\n", int(math.Ceil(p.width)), int(math.Ceil(p.height)))
+	fmt.Fprintf(w.out, "%%%%HiResBoundingBox: 0 0 %s %s\n", psNum(p.width), psNum(p.height))
+	fmt.Fprintf(w.out, "%%%%Creator: pigiron\n%%%%Pages: 1\n%%%%EndComments\n")
+	fmt.Fprintf(w.out, "1 setlinejoin\n")
+	d.render(p, w)
+	fmt.Fprintf(w.out, "showpage\n%%%%EOF\n")
+	return w.out.Flush()
+}
@@ -0,0 +1,37 @@
+package geo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEPS(t *testing.T) {
+	d := NewDrawing()
+	d.ViewBox(0, 10, 0, 5)
+	d.Style("polygon { fill: none; stroke: black }")
+	d.Polygon(&Polygon{{0, 0}, {10, 0}, {10, 1}, {0, 0}})
+	d.CenteredText("a\\b", 5, 2.5, 1, "")
+	var buf bytes.Buffer
+	if err := d.WriteEPS(&buf, 100.5); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"%!PS-Adobe-3.0 EPSF-3.0\n",
+		"%%BoundingBox: 0 0 101 51\n",
+		"%%HiResBoundingBox: 0 0 100.5 50.25\n",
+		// y=0 at the bottom of the page
+		"newpath 0 0 moveto 100.5 0 lineto 100.5 10.05 lineto closepath\n",
+		"0 0 0 setrgbcolor 10.05 setlinewidth stroke\n",
+		"(a\\\\b) dup stringwidth pop 2 div neg 50.25 add",
+		"showpage\n%%EOF\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q not found in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "eofill") {
+		t.Errorf("unexpected fill")
+	}
+}
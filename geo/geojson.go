@@ -0,0 +1,196 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrInvalidGeoJSON     = errors.New("invalid GeoJSON geometry")
+	ErrGeoJSONHasHoles    = errors.New("GeoJSON polygon with holes can not be converted to a single Polygon")
+	ErrGeoJSONInvalidBBox = errors.New("GeoJSON bbox should be [xmin,ymin,xmax,ymax]")
+)
+
+// GeoJSONGeometry is a GeoJSON (RFC 7946) geometry object.
+// Only the Polygon and MultiPolygon types are used.
+type GeoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	BBox        []float64       `json:"bbox,omitempty"`
+}
+
+// GeoJSONFeature is a GeoJSON feature, i.e. a geometry with properties.
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONFeatureCollection is a list of GeoJSON features.
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+	BBox     []float64        `json:"bbox,omitempty"`
+}
+
+// NewGeoJSONFeature returns a feature with the given geometry and properties.
+func NewGeoJSONFeature(g GeoJSONGeometry, properties map[string]interface{}) GeoJSONFeature {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return GeoJSONFeature{Type: "Feature", Geometry: g, Properties: properties}
+}
+
+// NewGeoJSONFeatureCollection returns a collection of features.
+func NewGeoJSONFeatureCollection(features []GeoJSONFeature) GeoJSONFeatureCollection {
+	if features == nil {
+		features = []GeoJSONFeature{}
+	}
+	return GeoJSONFeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+type position [2]float64
+type ring []position
+
+// ring returns the (closed) GeoJSON linear ring of the polygon.
+func (p Polygon) ring() ring {
+	r := make(ring, 0, len(p)+1)
+	for _, v := range p {
+		r = append(r, position{v.X, v.Y})
+	}
+	if len(p) > 0 && !p.isClosed() {
+		r = append(r, position{p[0].X, p[0].Y})
+	}
+	return r
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err) // can not happen for slices of numbers
+	}
+	return b
+}
+
+// GeoJSON returns the polygon as a GeoJSON Polygon.
+func (p Polygon) GeoJSON() GeoJSONGeometry {
+	return GeoJSONGeometry{
+		Type:        "Polygon",
+		Coordinates: mustMarshal([]ring{p.ring()}),
+		BBox:        GeoJSONBBox(p.BBox()),
+	}
+}
+
+// GeoJSON returns the contour as a GeoJSON MultiPolygon, where each
+// (counter-clockwise) polygon of the contour carries the (clockwise)
+// holes it contains.
+func (c Contour) GeoJSON() GeoJSONGeometry {
+	groups := c.withHoles()
+	polygons := make([][]ring, len(groups))
+	for i, g := range groups {
+		for _, p := range g {
+			polygons[i] = append(polygons[i], p.ring())
+		}
+	}
+	g := GeoJSONGeometry{Type: "MultiPolygon", Coordinates: mustMarshal(polygons)}
+	if len(c) > 0 {
+		g.BBox = GeoJSONBBox(c.BBox())
+	}
+	return g
+}
+
+// GeoJSONBBox returns the bounding box as a GeoJSON bbox member.
+func GeoJSONBBox(b BBox) []float64 {
+	return []float64{b.Xmin(), b.Ymin(), b.Xmax(), b.Ymax()}
+}
+
+// BBoxFromGeoJSON decodes a GeoJSON bbox member.
+func BBoxFromGeoJSON(b []float64) (BBox, error) {
+	if len(b) != 4 {
+		return nil, ErrGeoJSONInvalidBBox
+	}
+	return NewBBox(b[0], b[1], b[2], b[3])
+}
+
+// polygon converts a GeoJSON linear ring into a closed polygon
+// with the requested orientation.
+func (r ring) polygon(counterClockwise bool) (Polygon, error) {
+	if len(r) < 4 || r[0] != r[len(r)-1] {
+		return nil, ErrInvalidGeoJSON
+	}
+	p := make(Polygon, len(r))
+	for i, pos := range r {
+		p[i] = Vertex{pos[0], pos[1]}
+	}
+	if p.isCounterClockwiseOriented() != counterClockwise {
+		for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+			p[i], p[j] = p[j], p[i]
+		}
+	}
+	return p, nil
+}
+
+// rings converts a list of GeoJSON rings (exterior then holes) into
+// polygons, counter-clockwise for the exterior and clockwise for the holes.
+func rings(rs []ring) (Contour, error) {
+	if len(rs) == 0 {
+		return nil, ErrInvalidGeoJSON
+	}
+	var c Contour
+	for i, r := range rs {
+		p, err := r.polygon(i == 0)
+		if err != nil {
+			return nil, err
+		}
+		c = append(c, p)
+	}
+	return c, nil
+}
+
+// Polygon decodes a GeoJSON Polygon without holes.
+func (g GeoJSONGeometry) Polygon() (Polygon, error) {
+	if g.Type != "Polygon" {
+		return nil, ErrInvalidGeoJSON
+	}
+	var rs []ring
+	if err := json.Unmarshal(g.Coordinates, &rs); err != nil {
+		return nil, ErrInvalidGeoJSON
+	}
+	if len(rs) > 1 {
+		return nil, ErrGeoJSONHasHoles
+	}
+	c, err := rings(rs)
+	if err != nil {
+		return nil, err
+	}
+	return c[0], nil
+}
+
+// Contour decodes a GeoJSON Polygon or MultiPolygon, the holes
+// becoming clockwise polygons of the contour.
+func (g GeoJSONGeometry) Contour() (Contour, error) {
+	var polygons [][]ring
+	switch g.Type {
+	case "Polygon":
+		var rs []ring
+		if err := json.Unmarshal(g.Coordinates, &rs); err != nil {
+			return nil, ErrInvalidGeoJSON
+		}
+		polygons = [][]ring{rs}
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return nil, ErrInvalidGeoJSON
+		}
+	default:
+		return nil, ErrInvalidGeoJSON
+	}
+	var contour Contour
+	for _, rs := range polygons {
+		c, err := rings(rs)
+		if err != nil {
+			return nil, err
+		}
+		contour = append(contour, c...)
+	}
+	return contour, nil
+}
@@ -0,0 +1,122 @@
+package geo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPolygonGeoJSON(t *testing.T) {
+	p := Polygon{{0, 0}, {2, 0}, {2, 1}, {0, 1}, {0, 0}}
+	b, err := json.Marshal(p.GeoJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"Polygon","coordinates":[[[0,0],[2,0],[2,1],[0,1],[0,0]]],"bbox":[0,0,2,1]}`
+	if string(b) != want {
+		t.Errorf("want %s, got %s", want, b)
+	}
+	var g GeoJSONGeometry
+	if err := json.Unmarshal(b, &g); err != nil {
+		t.Fatal(err)
+	}
+	back, err := g.Polygon()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualPolygon(p, back) {
+		t.Errorf("want %v, got %v", p, back)
+	}
+}
+
+func TestPolygonGeoJSONIsClosed(t *testing.T) {
+	p := Polygon{{0, 0}, {2, 0}, {2, 1}}
+	var rs []ring
+	json.Unmarshal(p.GeoJSON().Coordinates, &rs)
+	if len(rs[0]) != 4 || rs[0][0] != rs[0][3] {
+		t.Errorf("ring not closed: %v", rs)
+	}
+}
+
+func TestContourGeoJSONWithHole(t *testing.T) {
+	outer := Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+	hole := Polygon{{4, 4}, {4, 6}, {6, 6}, {6, 4}, {4, 4}}
+	island := Polygon{{20, 0}, {21, 0}, {21, 1}, {20, 1}, {20, 0}}
+	c := Contour{outer, island, hole}
+	b, err := json.Marshal(c.GeoJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"MultiPolygon","coordinates":[` +
+		`[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[4,4],[4,6],[6,6],[6,4],[4,4]]],` +
+		`[[[20,0],[21,0],[21,1],[20,1],[20,0]]]],"bbox":[0,0,21,10]}`
+	if string(b) != want {
+		t.Errorf("want %s, got %s", want, b)
+	}
+	var g GeoJSONGeometry
+	json.Unmarshal(b, &g)
+	back, err := g.Contour()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualContour(c, back) {
+		t.Errorf("want %v, got %v", c, back)
+	}
+	if _, err := g.Polygon(); err != ErrInvalidGeoJSON {
+		t.Errorf("want ErrInvalidGeoJSON for a MultiPolygon, got %v", err)
+	}
+}
+
+func TestGeoJSONOrientationIsNormalized(t *testing.T) {
+	// clockwise exterior, counter-clockwise hole
+	g := GeoJSONGeometry{
+		Type:        "Polygon",
+		Coordinates: json.RawMessage(`[[[0,0],[0,10],[10,10],[10,0],[0,0]],[[4,4],[6,4],[6,6],[4,6],[4,4]]]`),
+	}
+	c, err := g.Contour()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c[0].isCounterClockwiseOriented() || c[1].isCounterClockwiseOriented() {
+		t.Errorf("wrong orientations")
+	}
+	if _, err := g.Polygon(); err != ErrGeoJSONHasHoles {
+		t.Errorf("want ErrGeoJSONHasHoles, got %v", err)
+	}
+}
+
+func TestInvalidGeoJSON(t *testing.T) {
+	for _, g := range []GeoJSONGeometry{
+		{Type: "Point", Coordinates: json.RawMessage(`[0,0]`)},
+		{Type: "Polygon", Coordinates: json.RawMessage(`[[[0,0],[1,0],[0,0]]]`)},
+		{Type: "Polygon", Coordinates: json.RawMessage(`[[[0,0],[1,0],[1,1],[0,1]]]`)},
+		{Type: "Polygon", Coordinates: json.RawMessage(`[]`)},
+		{Type: "MultiPolygon", Coordinates: json.RawMessage(`"x"`)},
+	} {
+		if _, err := g.Contour(); err != ErrInvalidGeoJSON {
+			t.Errorf("%s: want ErrInvalidGeoJSON, got %v", g.Coordinates, err)
+		}
+	}
+}
+
+func TestBBoxGeoJSON(t *testing.T) {
+	b := NewBBoxUnchecked(-1, -2, 3, 4)
+	back, err := BBoxFromGeoJSON(GeoJSONBBox(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualBBox(b, back) {
+		t.Errorf("want %v, got %v", b, back)
+	}
+	if _, err := BBoxFromGeoJSON([]float64{0, 0, 1}); err != ErrGeoJSONInvalidBBox {
+		t.Errorf("want ErrGeoJSONInvalidBBox, got %v", err)
+	}
+}
+
+func TestGeoJSONFeatureCollection(t *testing.T) {
+	f := NewGeoJSONFeature(Polygon{{0, 0}, {1, 0}, {1, 1}, {0, 0}}.GeoJSON(), map[string]interface{}{"DEID": 100})
+	b, _ := json.Marshal(NewGeoJSONFeatureCollection([]GeoJSONFeature{f}))
+	want := `{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]],"bbox":[0,0,1,1]},"properties":{"DEID":100}}]}`
+	if string(b) != want {
+		t.Errorf("want %s, got %s", want, b)
+	}
+}
@@ -0,0 +1,116 @@
+package geo
+
+import (
+	"fmt"
+	"io"
+)
+
+// The interactive HTML output relies on a few conventions for the
+// attributes of the SVG elements :
+//
+// data-tooltip : text shown when hovering the element
+//
+// data-group : clicking an element highlights all the elements of the same group
+//
+// data-layer : groups (see GroupStartWithAttrs) with this attribute get a
+// checkbox to show/hide them. Groups with the "hidden" class are initially hidden.
+
+const interactiveStyle = `
+svg { cursor: move; border: 1px solid #ccc; }
+.hidden { display: none; }
+.highlight { fill: orange !important; fill-opacity: 0.7; }
+#controls { font: 14px sans-serif; margin-bottom: 4px; }
+#controls label { margin-right: 1em; }
+#tooltip { position: absolute; display: none; pointer-events: none; white-space: pre;
+  background: #ffe; border: 1px solid #888; padding: 2px 4px; font: 12px monospace; }
+`
+
+const interactiveScript = `
+(function() {
+  var svg = document.querySelector("svg");
+  var vb = svg.viewBox.baseVal;
+  var tooltip = document.getElementById("tooltip");
+  var controls = document.getElementById("controls");
+  function userPoint(e) {
+    var r = svg.getBoundingClientRect();
+    return { x: vb.x + (e.clientX - r.left) * vb.width / r.width,
+             y: vb.y + (e.clientY - r.top) * vb.height / r.height };
+  }
+  function closest(e, attr) {
+    return e.target.closest ? e.target.closest("[" + attr + "]") : null;
+  }
+  svg.addEventListener("wheel", function(e) {
+    e.preventDefault();
+    var p = userPoint(e);
+    var f = e.deltaY < 0 ? 0.8 : 1.25;
+    vb.x = p.x - (p.x - vb.x) * f;
+    vb.y = p.y - (p.y - vb.y) * f;
+    vb.width *= f;
+    vb.height *= f;
+  });
+  var drag = null;
+  svg.addEventListener("mousedown", function(e) {
+    drag = { x: e.clientX, y: e.clientY, moved: false };
+  });
+  window.addEventListener("mousemove", function(e) {
+    if (drag) {
+      var r = svg.getBoundingClientRect();
+      var dx = e.clientX - drag.x, dy = e.clientY - drag.y;
+      if (Math.abs(dx) + Math.abs(dy) > 2) { drag.moved = true; }
+      vb.x -= dx * vb.width / r.width;
+      vb.y -= dy * vb.height / r.height;
+      drag.x = e.clientX;
+      drag.y = e.clientY;
+    }
+    var t = closest(e, "data-tooltip");
+    if (t) {
+      tooltip.textContent = t.getAttribute("data-tooltip");
+      tooltip.style.left = (e.pageX + 12) + "px";
+      tooltip.style.top = (e.pageY + 12) + "px";
+      tooltip.style.display = "block";
+    } else {
+      tooltip.style.display = "none";
+    }
+  });
+  window.addEventListener("mouseup", function(e) {
+    if (drag && !drag.moved) {
+      var t = closest(e, "data-group");
+      if (t) {
+        var group = t.getAttribute("data-group");
+        var on = !t.classList.contains("highlight");
+        document.querySelectorAll(".highlight").forEach(function(el) { el.classList.remove("highlight"); });
+        if (on) {
+          document.querySelectorAll("[data-group='" + group + "']").forEach(function(el) { el.classList.add("highlight"); });
+        }
+      }
+    }
+    drag = null;
+  });
+  document.querySelectorAll("g[data-layer]").forEach(function(g) {
+    var label = document.createElement("label");
+    var check = document.createElement("input");
+    check.type = "checkbox";
+    check.checked = !g.classList.contains("hidden");
+    check.addEventListener("change", function() { g.classList.toggle("hidden", !check.checked); });
+    label.appendChild(check);
+    label.appendChild(document.createTextNode(" " + g.getAttribute("data-layer")));
+    controls.appendChild(label);
+  });
+})();
+`
+
+// Script adds some javascript to the interactive HTML output
+func (w *SVGWriter) Script(script string) {
+	w.scriptBuffer += script
+}
+
+// WriteInteractiveHTML outputs a self-contained HTML page with the SVG
+// and the javascript needed to pan (drag), zoom (mouse wheel), show tooltips,
+// highlight groups of elements and toggle layers.
+func (w *SVGWriter) WriteInteractiveHTML(out io.Writer) {
+	fmt.Fprintf(out, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(out, "<style>%s%s</style>\n</head>\n<body>\n", interactiveStyle, w.styleBuffer)
+	fmt.Fprintf(out, "<div id=\"controls\"></div>\n<div id=\"tooltip\"></div>\n")
+	w.WriteSVG(out)
+	fmt.Fprintf(out, "<script>%s%s</script>\n</body>\n</html>\n", interactiveScript, w.scriptBuffer)
+}
@@ -0,0 +1,276 @@
+package geo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MVTExtent is the default extent (number of integer units
+// along a side) of a vector tile.
+const MVTExtent = 4096
+
+// mvtBuffer is the margin (as a fraction of the tile size, i.e. 64 units
+// for the default extent) kept around the tile when clipping, so that the
+// outlines at tile boundaries are not drawn.
+const mvtBuffer = 1.0 / 64
+
+// MVTTile is a Mapbox Vector Tile (version 2.1) made of polygon layers,
+// encoded without any protobuf library.
+type MVTTile struct {
+	layers []*MVTLayer
+}
+
+// MVTLayer is one layer of a vector tile, covering the area bbox of the
+// user coordinates, the tile y axis pointing down (i.e. to lower user y).
+type MVTLayer struct {
+	name       string
+	bbox       BBox
+	extent     int
+	keys       []string
+	keyIndex   map[string]int
+	values     []interface{}
+	valueIndex map[interface{}]int
+	features   [][]byte
+}
+
+// Layer adds a layer covering the area bbox of the user coordinates.
+func (t *MVTTile) Layer(name string, bbox BBox, extent int) *MVTLayer {
+	l := &MVTLayer{
+		name:       name,
+		bbox:       bbox,
+		extent:     extent,
+		keyIndex:   make(map[string]int),
+		valueIndex: make(map[interface{}]int),
+	}
+	t.layers = append(t.layers, l)
+	return l
+}
+
+// Len returns the number of features of the layer.
+func (l *MVTLayer) Len() int {
+	return len(l.features)
+}
+
+// protobuf wire types
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+)
+
+func pbAppendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func pbAppendKey(b []byte, field, wireType int) []byte {
+	return pbAppendVarint(b, uint64(field<<3|wireType))
+}
+
+func pbAppendBytes(b []byte, field int, data []byte) []byte {
+	b = pbAppendKey(b, field, pbBytes)
+	b = pbAppendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func pbAppendPacked(b []byte, field int, values []uint32) []byte {
+	var data []byte
+	for _, v := range values {
+		data = pbAppendVarint(data, uint64(v))
+	}
+	return pbAppendBytes(b, field, data)
+}
+
+func zigzag(v int32) uint32 {
+	return uint32((v << 1) ^ (v >> 31))
+}
+
+// MVT geometry commands
+const (
+	mvtMoveTo    = 1
+	mvtLineTo    = 2
+	mvtClosePath = 7
+)
+
+func mvtCommand(id, count int) uint32 {
+	return uint32(id&0x7) | uint32(count)<<3
+}
+
+// tileRing converts a polygon (in user coordinates) into a ring of
+// integer tile coordinates, without closing point nor repeated points.
+func (l *MVTLayer) tileRing(p Polygon) [][2]int32 {
+	sx := float64(l.extent) / l.bbox.Width()
+	sy := float64(l.extent) / l.bbox.Height()
+	var r [][2]int32
+	for _, v := range p {
+		pt := [2]int32{
+			int32(math.Round((v.X - l.bbox.Xmin()) * sx)),
+			int32(math.Round((l.bbox.Ymax() - v.Y) * sy)),
+		}
+		if len(r) > 0 && r[len(r)-1] == pt {
+			continue
+		}
+		r = append(r, pt)
+	}
+	for len(r) > 1 && r[len(r)-1] == r[0] {
+		r = r[:len(r)-1]
+	}
+	return r
+}
+
+// ringArea returns the surveyor's formula area of a ring.
+func ringArea(r [][2]int32) int64 {
+	var a int64
+	for i := range r {
+		j := (i + 1) % len(r)
+		a += int64(r[i][0])*int64(r[j][1]) - int64(r[j][0])*int64(r[i][1])
+	}
+	return a
+}
+
+// AddContour clips the contour to the layer area and, if something is left,
+// adds it as one polygon feature with the given properties (whose values
+// should be strings, booleans, integers or floats, other types being
+// converted to strings).
+func (l *MVTLayer) AddContour(c Contour, properties map[string]interface{}) {
+	margin := mvtBuffer * l.bbox.Width()
+	clip := NewBBoxUnchecked(l.bbox.Xmin()-margin, l.bbox.Ymin()-margin, l.bbox.Xmax()+margin, l.bbox.Ymax()+margin)
+	var geometry []uint32
+	var cx, cy int32
+	for _, g := range c.withHoles() {
+		for i, p := range g {
+			r := l.tileRing(p.Clip(clip))
+			a := ringArea(r)
+			if len(r) < 3 || a == 0 {
+				if i == 0 {
+					// no exterior ring, no holes
+					break
+				}
+				continue
+			}
+			// exterior rings have a positive area, holes a negative one
+			if (i == 0) != (a > 0) {
+				for m, n := 0, len(r)-1; m < n; m, n = m+1, n-1 {
+					r[m], r[n] = r[n], r[m]
+				}
+			}
+			geometry = append(geometry, mvtCommand(mvtMoveTo, 1),
+				zigzag(r[0][0]-cx), zigzag(r[0][1]-cy))
+			cx, cy = r[0][0], r[0][1]
+			geometry = append(geometry, mvtCommand(mvtLineTo, len(r)-1))
+			for _, pt := range r[1:] {
+				geometry = append(geometry, zigzag(pt[0]-cx), zigzag(pt[1]-cy))
+				cx, cy = pt[0], pt[1]
+			}
+			geometry = append(geometry, mvtCommand(mvtClosePath, 1))
+		}
+	}
+	if len(geometry) == 0 {
+		return
+	}
+	names := make([]string, 0, len(properties))
+	for k := range properties {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var tags []uint32
+	for _, k := range names {
+		tags = append(tags, uint32(l.key(k)), uint32(l.value(properties[k])))
+	}
+	var f []byte
+	if len(tags) > 0 {
+		f = pbAppendPacked(f, 2, tags)
+	}
+	f = pbAppendKey(f, 3, pbVarint)
+	f = pbAppendVarint(f, 3) // POLYGON
+	f = pbAppendPacked(f, 4, geometry)
+	l.features = append(l.features, f)
+}
+
+func (l *MVTLayer) key(k string) int {
+	if i, ok := l.keyIndex[k]; ok {
+		return i
+	}
+	l.keyIndex[k] = len(l.keys)
+	l.keys = append(l.keys, k)
+	return len(l.keys) - 1
+}
+
+func (l *MVTLayer) value(v interface{}) int {
+	switch x := v.(type) {
+	case int:
+		v = int64(x)
+	case int32:
+		v = int64(x)
+	case uint32:
+		v = int64(x)
+	case float32:
+		v = float64(x)
+	case string, float64, int64, bool:
+	default:
+		v = fmt.Sprint(x)
+	}
+	if i, ok := l.valueIndex[v]; ok {
+		return i
+	}
+	l.valueIndex[v] = len(l.values)
+	l.values = append(l.values, v)
+	return len(l.values) - 1
+}
+
+func mvtValue(v interface{}) []byte {
+	var b []byte
+	switch x := v.(type) {
+	case string:
+		b = pbAppendBytes(b, 1, []byte(x))
+	case float64:
+		b = pbAppendKey(b, 3, pbFixed64)
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(x))
+		b = append(b, buf[:]...)
+	case int64:
+		b = pbAppendKey(b, 6, pbVarint)
+		b = pbAppendVarint(b, uint64((x<<1)^(x>>63)))
+	case bool:
+		b = pbAppendKey(b, 7, pbVarint)
+		if x {
+			b = pbAppendVarint(b, 1)
+		} else {
+			b = pbAppendVarint(b, 0)
+		}
+	}
+	return b
+}
+
+func (l *MVTLayer) marshal() []byte {
+	var b []byte
+	b = pbAppendKey(b, 15, pbVarint)
+	b = pbAppendVarint(b, 2)
+	b = pbAppendBytes(b, 1, []byte(l.name))
+	for _, f := range l.features {
+		b = pbAppendBytes(b, 2, f)
+	}
+	for _, k := range l.keys {
+		b = pbAppendBytes(b, 3, []byte(k))
+	}
+	for _, v := range l.values {
+		b = pbAppendBytes(b, 4, mvtValue(v))
+	}
+	b = pbAppendKey(b, 5, pbVarint)
+	b = pbAppendVarint(b, uint64(l.extent))
+	return b
+}
+
+// Marshal returns the protobuf encoding of the tile. Empty layers are omitted.
+func (t *MVTTile) Marshal() []byte {
+	var b []byte
+	for _, l := range t.layers {
+		if len(l.features) > 0 {
+			b = pbAppendBytes(b, 3, l.marshal())
+		}
+	}
+	return b
+}
@@ -0,0 +1,203 @@
+package geo
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// pbField is one decoded protobuf field.
+type pbField struct {
+	num   int
+	value uint64
+	data  []byte
+}
+
+// pbDecode decodes a protobuf message (varint, fixed64 and bytes fields only).
+func pbDecode(t *testing.T, b []byte) []pbField {
+	var fields []pbField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		b = b[n:]
+		f := pbField{num: int(key >> 3)}
+		switch key & 7 {
+		case pbVarint:
+			f.value, n = binary.Uvarint(b)
+			b = b[n:]
+		case pbFixed64:
+			f.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case pbBytes:
+			l, n := binary.Uvarint(b)
+			f.data = b[n : n+int(l)]
+			b = b[n+int(l):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func pbPacked(b []byte) []uint32 {
+	var v []uint32
+	for len(b) > 0 {
+		x, n := binary.Uvarint(b)
+		v = append(v, uint32(x))
+		b = b[n:]
+	}
+	return v
+}
+
+func unzigzag(v uint32) int32 {
+	return int32(v>>1) ^ -int32(v&1)
+}
+
+type decodedLayer struct {
+	name     string
+	extent   int
+	keys     []string
+	values   [][]pbField
+	features [][]pbField
+}
+
+func decodeTile(t *testing.T, b []byte) map[string]decodedLayer {
+	layers := make(map[string]decodedLayer)
+	for _, f := range pbDecode(t, b) {
+		if f.num != 3 {
+			t.Fatalf("unexpected tile field %d", f.num)
+		}
+		var l decodedLayer
+		for _, lf := range pbDecode(t, f.data) {
+			switch lf.num {
+			case 1:
+				l.name = string(lf.data)
+			case 2:
+				l.features = append(l.features, pbDecode(t, lf.data))
+			case 3:
+				l.keys = append(l.keys, string(lf.data))
+			case 4:
+				l.values = append(l.values, pbDecode(t, lf.data))
+			case 5:
+				l.extent = int(lf.value)
+			case 15:
+				if lf.value != 2 {
+					t.Errorf("unexpected version %d", lf.value)
+				}
+			}
+		}
+		layers[l.name] = l
+	}
+	return layers
+}
+
+// decodeRings returns the rings of a polygon geometry.
+func decodeRings(t *testing.T, g []uint32) [][][2]int32 {
+	var rings [][][2]int32
+	var x, y int32
+	for i := 0; i < len(g); {
+		id, count := g[i]&7, int(g[i]>>3)
+		i++
+		switch id {
+		case mvtMoveTo:
+			x += unzigzag(g[i])
+			y += unzigzag(g[i+1])
+			i += 2
+			rings = append(rings, [][2]int32{{x, y}})
+		case mvtLineTo:
+			for k := 0; k < count; k++ {
+				x += unzigzag(g[i])
+				y += unzigzag(g[i+1])
+				i += 2
+				rings[len(rings)-1] = append(rings[len(rings)-1], [2]int32{x, y})
+			}
+		case mvtClosePath:
+		default:
+			t.Fatalf("unexpected command %d", id)
+		}
+	}
+	return rings
+}
+
+func TestMVTPolygonWithHole(t *testing.T) {
+	var tile MVTTile
+	l := tile.Layer("test", NewBBoxUnchecked(0, 0, 100, 100), MVTExtent)
+	outer := Polygon{{10, 10}, {90, 10}, {90, 90}, {10, 90}, {10, 10}}
+	hole := Polygon{{40, 40}, {40, 60}, {60, 60}, {60, 40}, {40, 40}}
+	l.AddContour(Contour{outer, hole}, map[string]interface{}{"DEID": 100, "name": "x", "area": 1.5, "ok": true})
+	layers := decodeTile(t, tile.Marshal())
+	d, ok := layers["test"]
+	if !ok || d.extent != MVTExtent || len(d.features) != 1 {
+		t.Fatalf("unexpected layer %+v", d)
+	}
+	var tags, geometry []uint32
+	for _, f := range d.features[0] {
+		switch f.num {
+		case 2:
+			tags = pbPacked(f.data)
+		case 3:
+			if f.value != 3 {
+				t.Errorf("want a polygon, got type %d", f.value)
+			}
+		case 4:
+			geometry = pbPacked(f.data)
+		}
+	}
+	// keys are sorted
+	want := []string{"DEID", "area", "name", "ok"}
+	for i, k := range want {
+		if d.keys[tags[2*i]] != k {
+			t.Errorf("tag %d: want key %s, got %s", i, k, d.keys[tags[2*i]])
+		}
+	}
+	if v := d.values[tags[1]][0]; v.num != 6 || v.value != 200 {
+		t.Errorf("DEID should be a sint64 of 100, got %+v", v)
+	}
+	if v := d.values[tags[3]][0]; v.num != 3 || math.Float64frombits(v.value) != 1.5 {
+		t.Errorf("area should be a double of 1.5, got %+v", v)
+	}
+	rings := decodeRings(t, geometry)
+	if len(rings) != 2 || len(rings[0]) != 4 || len(rings[1]) != 4 {
+		t.Fatalf("unexpected rings %v", rings)
+	}
+	// y axis is flipped: (10,90) is at the top left, and the exterior ring
+	// is reversed to have a positive area in tile coordinates
+	if rings[0][0] != [2]int32{410, 410} || rings[0][1] != [2]int32{3686, 410} {
+		t.Errorf("unexpected first point %v", rings[0][0])
+	}
+	if ringArea(rings[0]) <= 0 || ringArea(rings[1]) >= 0 {
+		t.Errorf("exterior ring should have a positive area and holes a negative one")
+	}
+}
+
+func TestMVTClipping(t *testing.T) {
+	var tile MVTTile
+	l := tile.Layer("test", NewBBoxUnchecked(0, 0, 10, 10), 256)
+	l.AddContour(Contour{{{-50, -50}, {50, -50}, {50, 50}, {-50, 50}, {-50, -50}}}, nil)
+	l.AddContour(Contour{{{20, 20}, {30, 20}, {30, 30}, {20, 20}}}, nil)
+	if l.Len() != 1 {
+		t.Fatalf("want 1 feature, got %d", l.Len())
+	}
+	layers := decodeTile(t, tile.Marshal())
+	var geometry []uint32
+	for _, f := range layers["test"].features[0] {
+		if f.num == 4 {
+			geometry = pbPacked(f.data)
+		}
+	}
+	// clipped to the tile plus a buffer of 1/64 of its size
+	rings := decodeRings(t, geometry)
+	for _, pt := range rings[0] {
+		if pt[0] < -4 || pt[0] > 260 || pt[1] < -4 || pt[1] > 260 {
+			t.Errorf("point %v not clipped", pt)
+		}
+	}
+}
+
+func TestMVTEmptyLayersAreOmitted(t *testing.T) {
+	var tile MVTTile
+	tile.Layer("empty", NewBBoxUnchecked(0, 0, 1, 1), MVTExtent)
+	if b := tile.Marshal(); len(b) != 0 {
+		t.Errorf("want an empty tile, got %d bytes", len(b))
+	}
+}
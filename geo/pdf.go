@@ -0,0 +1,89 @@
+package geo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// pdfWriter accumulates the content stream of a single page PDF document.
+type pdfWriter struct {
+	content bytes.Buffer
+}
+
+func (w *pdfWriter) path(x, y []float64, s shapeStyle, strokeWidth float64) {
+	if len(x) < 2 {
+		return
+	}
+	fr, fg, fb, fill := s.fillColor()
+	sr, sg, sb, stroke := s.strokeColor()
+	if !fill && !stroke {
+		return
+	}
+	if fill {
+		fmt.Fprintf(&w.content, "%s %s %s rg\n", psNum(fr), psNum(fg), psNum(fb))
+	}
+	if stroke {
+		fmt.Fprintf(&w.content, "%s %s %s RG %s w\n", psNum(sr), psNum(sg), psNum(sb), psNum(strokeWidth))
+	}
+	fmt.Fprintf(&w.content, "%s %s m", psNum(x[0]), psNum(y[0]))
+	for i := 1; i < len(x); i++ {
+		fmt.Fprintf(&w.content, " %s %s l", psNum(x[i]), psNum(y[i]))
+	}
+	switch {
+	case fill && stroke:
+		w.content.WriteString(" h B*\n")
+	case fill:
+		w.content.WriteString(" h f*\n")
+	default:
+		w.content.WriteString(" h S\n")
+	}
+}
+
+func (w *pdfWriter) text(text string, x, y, size float64, s shapeStyle, centered bool) {
+	r, g, b, ok := s.fillColor()
+	if !ok || len(text) == 0 {
+		return
+	}
+	if centered {
+		x -= TextWidth(latin1(text), size) / 2
+		y -= 0.35 * size
+	}
+	fmt.Fprintf(&w.content, "%s %s %s rg BT /F1 %s Tf %s %s Td (%s) Tj ET\n",
+		psNum(r), psNum(g), psNum(b), psNum(size), psNum(x), psNum(y), escapeString(text))
+}
+
+// WritePDF outputs the drawing as a one page PDF document, the page
+// being width points wide and the y axis pointing up (see page).
+func (d *Drawing) WritePDF(out io.Writer, width float64) error {
+	p, err := d.page(width)
+	if err != nil {
+		return err
+	}
+	w := &pdfWriter{}
+	d.render(p, w)
+
+	var doc bytes.Buffer
+	var offsets []int
+	object := func(format string, a ...interface{}) {
+		offsets = append(offsets, doc.Len())
+		fmt.Fprintf(&doc, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&doc, format, a...)
+		doc.WriteString("\nendobj\n")
+	}
+	doc.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		psNum(p.width), psNum(p.height))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Length %d >>\nstream\n%sendstream", w.content.Len(), w.content.String())
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err = doc.WriteTo(out)
+	return err
+}
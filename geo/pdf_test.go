@@ -0,0 +1,104 @@
+package geo
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDFStructure(t *testing.T) {
+	d := NewDrawing()
+	d.ViewBox(0, 100, 0, 50)
+	d.Rect(10, 10, 20, 20)
+	d.CenteredText("DE(100)", 50, 25, 5, "")
+	var buf bytes.Buffer
+	if err := d.WritePDF(&buf, 200); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-1.4\n") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Errorf("not a PDF document")
+	}
+	if !strings.Contains(out, "/MediaBox [0 0 200 100]") {
+		t.Errorf("wrong media box")
+	}
+	if !strings.Contains(out, "(DE\\(100\\)) Tj") {
+		t.Errorf("text not escaped")
+	}
+	// each xref entry must point to its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	lines := strings.Split(out[xref:], "\n")
+	if lines[0] != "xref" {
+		t.Fatalf("startxref does not point to the xref table")
+	}
+	for i := 1; i <= 5; i++ {
+		offset, _ := strconv.Atoi(lines[2+i][:10])
+		if want := strconv.Itoa(i) + " 0 obj"; !strings.HasPrefix(out[offset:], want) {
+			t.Errorf("xref entry %d does not point to its object", i)
+		}
+	}
+}
+
+func TestPDFYAxisPointsUp(t *testing.T) {
+	d := NewDrawing()
+	d.ViewBox(0, 10, 0, 10)
+	// a rectangle at the lowest y of the drawing must be at the bottom of
+	// the page, as in the DXF and GeoJSON outputs
+	d.Rect(0, 0, 10, 1)
+	var buf bytes.Buffer
+	if err := d.WritePDF(&buf, 10); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "0 0 m 10 0 l 10 1 l 0 1 l h f*") {
+		t.Errorf("unexpected path:\n%s", buf.String())
+	}
+}
+
+func TestPDFStyles(t *testing.T) {
+	d := NewDrawing()
+	d.ViewBox(0, 10, 0, 10)
+	d.Style(".a { fill: none; stroke: red; stroke-width: 0.5 } .b { fill: #00f; opacity: 0.5 }")
+	d.GroupStart("a")
+	d.Rect(0, 0, 1, 1)
+	d.GroupEnd()
+	d.RectWithClass(0, 0, 1, 1, "b")
+	var buf bytes.Buffer
+	if err := d.WritePDF(&buf, 20); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "1 0 0 RG 1 w") || !strings.Contains(out, "h S\n") {
+		t.Errorf("stroked rectangle not found")
+	}
+	if !strings.Contains(out, "0.502 0.502 1 rg") {
+		t.Errorf("semi transparent blue fill not found")
+	}
+}
+
+func TestPDFEmptyDrawing(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewDrawing().WritePDF(&buf, 100); err != ErrEmptyDrawing {
+		t.Errorf("want ErrEmptyDrawing, got %v", err)
+	}
+	d := NewDrawing()
+	d.Rect(0, 0, 1, 1)
+	if err := d.WritePDF(&buf, 0); err != ErrInvalidPage {
+		t.Errorf("want ErrInvalidPage, got %v", err)
+	}
+}
+
+func TestPSNum(t *testing.T) {
+	for v, want := range map[float64]string{
+		0: "0", -0.0001: "0", 1.5: "1.5", 100: "100", 1e-7: "0", 1e7: "10000000", 0.12345: "0.123",
+	} {
+		if got := psNum(v); got != want {
+			t.Errorf("psNum(%v): want %s, got %s", v, want, got)
+		}
+	}
+}
@@ -38,6 +38,22 @@ func (p Polygon) signedArea() float64 {
 	return area * 0.5
 }
 
+// Centroid returns the center of mass of the (closed) polygon.
+func (p Polygon) Centroid() Vertex {
+	a := p.signedArea()
+	if a == 0 {
+		b := p.BBox()
+		return Vertex{b.Xcenter(), b.Ycenter()}
+	}
+	var cx, cy float64
+	for i := 0; i < len(p)-1; i++ {
+		cross := p[i].X*p[i+1].Y - p[i+1].X*p[i].Y
+		cx += (p[i].X + p[i+1].X) * cross
+		cy += (p[i].Y + p[i+1].Y) * cross
+	}
+	return Vertex{cx / (6 * a), cy / (6 * a)}
+}
+
 func (p Polygon) isClosed() bool {
 	return p[0] == p[len(p)-1]
 }
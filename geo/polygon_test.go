@@ -222,3 +222,12 @@ func TestPolygonTranslate(t *testing.T) {
 		t.Errorf("Want %s - Got %s", expected.String(), tr.String())
 	}
 }
+
+func TestPolygonCentroid(t *testing.T) {
+	// L shape made of a 2x1 and a 1x1 squares
+	p := Polygon{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}, {0, 0}}
+	c := p.Centroid()
+	if !EqualFloat(c.X, 5.0/6) || !EqualFloat(c.Y, 5.0/6) {
+		t.Errorf("expected centroid (5/6,5/6) and got (%v,%v)", c.X, c.Y)
+	}
+}
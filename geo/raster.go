@@ -0,0 +1,221 @@
+package geo
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+)
+
+// Rasterizer draws shapes into an image.RGBA, with the same conventions
+// as SVGWriter (user coordinates mapped to the view box, y axis pointing down).
+//
+// Fill and stroke colours are taken from the CSS styles (see Style), of which
+// only simple class (.name) and element (rect, polygon) selectors and the
+// fill, stroke, stroke-width and (fill-)opacity properties are understood.
+// As in SVG, shapes without style are filled in black.
+// Texts are not rendered.
+type Rasterizer struct {
+	img                          *image.RGBA
+	xleft, xright, ybottom, ytop float64
+	styles                       *styleSheet
+}
+
+// NewRasterizer returns a rasterizer of the given size (in pixels)
+// with a white background.
+func NewRasterizer(width, height int) *Rasterizer {
+	r := &Rasterizer{
+		img:    image.NewRGBA(image.Rect(0, 0, width, height)),
+		styles: newStyleSheet(),
+	}
+	for i := range r.img.Pix {
+		r.img.Pix[i] = 255
+	}
+	r.ViewBox(0, float64(width), 0, float64(height))
+	return r
+}
+
+// ViewBox sets the area, in user coordinates, shown by the image
+func (r *Rasterizer) ViewBox(xleft, xright, ybottom, ytop float64) {
+	r.xleft = xleft
+	r.xright = xright
+	r.ybottom = ybottom
+	r.ytop = ytop
+}
+
+// Image returns the image drawn so far.
+func (r *Rasterizer) Image() *image.RGBA {
+	return r.img
+}
+
+// Width returns the width of the image, in pixels
+func (r *Rasterizer) Width() int {
+	return r.img.Bounds().Dx()
+}
+
+// Height returns the height of the image, in pixels
+func (r *Rasterizer) Height() int {
+	return r.img.Bounds().Dy()
+}
+
+func (r *Rasterizer) scale() (float64, float64) {
+	return float64(r.Width()) / (r.xright - r.xleft), float64(r.Height()) / (r.ytop - r.ybottom)
+}
+
+func (r *Rasterizer) toPixel(v Vertex) (float64, float64) {
+	sx, sy := r.scale()
+	return (v.X - r.xleft) * sx, (v.Y - r.ybottom) * sy
+}
+
+// Style adds some CSS style
+func (r *Rasterizer) Style(style string) {
+	r.styles.add(style)
+}
+
+// GroupStart starts a group with a given classname, whose style
+// is inherited by the elements of the group.
+func (r *Rasterizer) GroupStart(classname string) {
+	r.styles.push(classname)
+}
+
+// GroupEnd ends a group
+func (r *Rasterizer) GroupEnd() {
+	r.styles.pop()
+}
+
+// Rect draws a rectangle
+func (r *Rasterizer) Rect(x, y, width, height float64) {
+	r.RectWithClass(x, y, width, height, "")
+}
+
+// RectWithClass draws a rectangle with a given CSS class
+func (r *Rasterizer) RectWithClass(x, y, width, height float64, class string) {
+	p := Polygon{{x, y}, {x + width, y}, {x + width, y + height}, {x, y + height}, {x, y}}
+	r.draw(p, r.styles.styleOf("rect", class))
+}
+
+// Polygon draws a polygon
+func (r *Rasterizer) Polygon(p *Polygon) {
+	r.PolygonWithClass(p, "")
+}
+
+// PolygonWithClass draws a polygon with a given CSS class
+func (r *Rasterizer) PolygonWithClass(p *Polygon, class string) {
+	r.draw(*p, r.styles.styleOf("polygon", class))
+}
+
+// Contour draws one polygon per sub-contour
+func (r *Rasterizer) Contour(c *Contour) {
+	for _, p := range *c {
+		r.Polygon(&p)
+	}
+}
+
+// Text is a no-op : texts are not rendered.
+func (r *Rasterizer) Text(text string, x, y float64) {
+}
+
+// CenteredText is a no-op : texts are not rendered.
+func (r *Rasterizer) CenteredText(text string, x, y, fontSize float64, class string) {
+}
+
+// WritePNG encodes the image in PNG format
+func (r *Rasterizer) WritePNG(out io.Writer) error {
+	return png.Encode(out, r.img)
+}
+
+type pixelVertex struct {
+	x, y float64
+}
+
+func (r *Rasterizer) draw(p Polygon, s shapeStyle) {
+	if len(p) < 2 {
+		return
+	}
+	pts := make([]pixelVertex, len(p))
+	for i, v := range p {
+		pts[i].x, pts[i].y = r.toPixel(v)
+	}
+	if !pts[0].equal(pts[len(pts)-1]) {
+		pts = append(pts, pts[0])
+	}
+	if s.fill.set && !s.fill.none {
+		r.fill(pts, s.fill.c, s.opacity)
+	}
+	if s.stroke.set && !s.stroke.none {
+		sx, _ := r.scale()
+		w := math.Max(1, s.strokeWidth*sx)
+		for i := 0; i < len(pts)-1; i++ {
+			r.fill(thickLine(pts[i], pts[i+1], w), s.stroke.c, 1)
+		}
+	}
+}
+
+func (a pixelVertex) equal(b pixelVertex) bool {
+	return a.x == b.x && a.y == b.y
+}
+
+// thickLine returns the (closed) outline of a segment of width w.
+func thickLine(a, b pixelVertex, w float64) []pixelVertex {
+	dx, dy := b.x-a.x, b.y-a.y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		dx, dy, l = 1, 0, 1
+	}
+	// half width normal, and half width extension along the segment
+	nx, ny := -dy/l*w/2, dx/l*w/2
+	ex, ey := dx/l*w/2, dy/l*w/2
+	return []pixelVertex{
+		{a.x - ex + nx, a.y - ey + ny},
+		{b.x + ex + nx, b.y + ey + ny},
+		{b.x + ex - nx, b.y + ey - ny},
+		{a.x - ex - nx, a.y - ey - ny},
+		{a.x - ex + nx, a.y - ey + ny},
+	}
+}
+
+// fill fills a closed polygon (in pixel coordinates) using the even-odd
+// rule, a pixel being inside if its center is.
+func (r *Rasterizer) fill(pts []pixelVertex, c color.RGBA, opacity float64) {
+	ymin, ymax := math.MaxFloat64, -math.MaxFloat64
+	for _, p := range pts {
+		ymin = math.Min(ymin, p.y)
+		ymax = math.Max(ymax, p.y)
+	}
+	b := r.img.Bounds()
+	jmin := int(math.Max(math.Floor(ymin), float64(b.Min.Y)))
+	jmax := int(math.Min(math.Ceil(ymax), float64(b.Max.Y-1)))
+	var xs []float64
+	for j := jmin; j <= jmax; j++ {
+		yc := float64(j) + 0.5
+		xs = xs[:0]
+		for i := 0; i < len(pts)-1; i++ {
+			a, e := pts[i], pts[i+1]
+			if (a.y <= yc && e.y > yc) || (e.y <= yc && a.y > yc) {
+				xs = append(xs, a.x+(yc-a.y)*(e.x-a.x)/(e.y-a.y))
+			}
+		}
+		sort.Float64s(xs)
+		for k := 0; k+1 < len(xs); k += 2 {
+			imin := int(math.Max(math.Ceil(xs[k]-0.5), float64(b.Min.X)))
+			imax := int(math.Min(math.Ceil(xs[k+1]-0.5), float64(b.Max.X)))
+			for i := imin; i < imax; i++ {
+				r.blend(i, j, c, opacity)
+			}
+		}
+	}
+}
+
+func (r *Rasterizer) blend(i, j int, c color.RGBA, opacity float64) {
+	if opacity >= 1 {
+		r.img.SetRGBA(i, j, c)
+		return
+	}
+	old := r.img.RGBAAt(i, j)
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a)*(1-opacity) + float64(b)*opacity))
+	}
+	r.img.SetRGBA(i, j, color.RGBA{mix(old.R, c.R), mix(old.G, c.G), mix(old.B, c.B), 255})
+}
@@ -0,0 +1,106 @@
+package geo
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+var (
+	white = color.RGBA{255, 255, 255, 255}
+	black = color.RGBA{0, 0, 0, 255}
+	red   = color.RGBA{255, 0, 0, 255}
+)
+
+func TestRasterFill(t *testing.T) {
+	r := NewRasterizer(10, 10)
+	r.ViewBox(0, 20, 0, 20)
+	r.Rect(2, 2, 4, 4)
+	img := r.Image()
+	for _, tc := range []struct {
+		i, j int
+		want color.RGBA
+	}{{0, 0, white}, {1, 1, black}, {2, 2, black}, {3, 3, white}} {
+		if got := img.RGBAAt(tc.i, tc.j); got != tc.want {
+			t.Errorf("pixel (%d,%d): want %v, got %v", tc.i, tc.j, tc.want, got)
+		}
+	}
+}
+
+func TestRasterNonConvexPolygon(t *testing.T) {
+	r := NewRasterizer(4, 4)
+	// L shape covering all pixels but the upper right quadrant
+	r.Polygon(&Polygon{{0, 0}, {2, 0}, {2, 2}, {4, 2}, {4, 4}, {0, 4}, {0, 0}})
+	img := r.Image()
+	if img.RGBAAt(3, 0) != white || img.RGBAAt(0, 0) != black || img.RGBAAt(3, 3) != black {
+		t.Errorf("wrong L shape fill")
+	}
+}
+
+func TestRasterStyles(t *testing.T) {
+	r := NewRasterizer(10, 10)
+	r.Style(".red{fill:#f00;} .outline{fill:none;stroke:red;stroke-width:2} .ignored:hover{fill:#00f}")
+	r.RectWithClass(0, 0, 5, 5, "red")
+	r.RectWithClass(5, 5, 4, 4, "outline")
+	img := r.Image()
+	if got := img.RGBAAt(2, 2); got != red {
+		t.Errorf("want red fill, got %v", got)
+	}
+	if got := img.RGBAAt(7, 7); got != white {
+		t.Errorf("want no fill inside outline, got %v", got)
+	}
+	if got := img.RGBAAt(5, 7); got != red {
+		t.Errorf("want red stroke, got %v", got)
+	}
+}
+
+func TestRasterGroupStyleIsInherited(t *testing.T) {
+	r := NewRasterizer(4, 4)
+	r.Style(".dead{fill:red;opacity:0.5}")
+	r.GroupStart("dead")
+	r.Rect(0, 0, 4, 4)
+	r.GroupEnd()
+	r.Rect(0, 0, 1, 1)
+	img := r.Image()
+	if got := img.RGBAAt(3, 3); got != (color.RGBA{255, 128, 128, 255}) {
+		t.Errorf("want half transparent red, got %v", got)
+	}
+	if got := img.RGBAAt(0, 0); got != black {
+		t.Errorf("want black outside of group, got %v", got)
+	}
+}
+
+func TestRasterPNG(t *testing.T) {
+	r := NewRasterizer(16, 8)
+	r.Rect(0, 0, 8, 8)
+	var buf bytes.Buffer
+	if err := r.WritePNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 8 {
+		t.Errorf("want 16x8 image, got %v", b)
+	}
+}
+
+func TestParsePaint(t *testing.T) {
+	for _, tc := range []struct {
+		v    string
+		want color.RGBA
+		ok   bool
+	}{
+		{"#123456", color.RGBA{0x12, 0x34, 0x56, 255}, true},
+		{"#abc", color.RGBA{0xaa, 0xbb, 0xcc, 255}, true},
+		{"orange !important", color.RGBA{255, 165, 0, 255}, true},
+		{"rgb(1,2,3)", color.RGBA{}, false},
+	} {
+		p, ok := parsePaint(tc.v)
+		if ok != tc.ok || p.c != tc.want {
+			t.Errorf("%s: want (%v,%v), got (%v,%v)", tc.v, tc.want, tc.ok, p.c, ok)
+		}
+	}
+}
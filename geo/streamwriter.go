@@ -0,0 +1,289 @@
+package geo
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+)
+
+// Canvas is the set of drawing methods shared by SVGWriter, SVGStreamWriter
+// and Rasterizer.
+type Canvas interface {
+	Width() int
+	GroupStart(classname string)
+	GroupEnd()
+	RectWithClass(x, y, width, height float64, class string)
+	PolygonWithClass(p *Polygon, class string)
+	Text(text string, x, y float64)
+	CenteredText(text string, x, y, fontSize float64, class string)
+	Style(style string)
+}
+
+var (
+	_ Canvas = (*SVGWriter)(nil)
+	_ Canvas = (*SVGStreamWriter)(nil)
+	_ Canvas = (*Rasterizer)(nil)
+)
+
+// SVGStreamWriter writes SVG elements to an io.Writer as soon as they are
+// added, so that drawings with millions of elements can be produced with
+// a constant memory footprint. Contrary to SVGWriter, the view box must be
+// given upfront.
+//
+// Styles are written at the end (when closing the writer), identical
+// styles being written only once.
+//
+// If BatchPaths is set, consecutive rectangles and polygons with the same
+// class are merged into a single <path> element.
+type SVGStreamWriter struct {
+	out   *bufio.Writer
+	width int
+	bbox  BBox
+	// Precision is the number of decimals used for coordinates
+	// (-1, the default, meaning as many as needed).
+	Precision  int
+	BatchPaths bool
+	styles     []string
+	seen       map[string]bool
+	classes    map[string]string
+	batchClass string
+	batch      []byte
+	buf        []byte
+	err        error
+}
+
+// NewSVGStreamWriter starts an SVG document of the given width (in pixels)
+// showing the given area.
+func NewSVGStreamWriter(out io.Writer, width int, viewBox BBox) *SVGStreamWriter {
+	if width <= 0 {
+		width = 1024
+	}
+	w := &SVGStreamWriter{
+		out:       bufio.NewWriter(out),
+		width:     width,
+		bbox:      viewBox,
+		Precision: -1,
+		seen:      make(map[string]bool),
+		classes:   make(map[string]string),
+	}
+	w.printf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%v\" height=\"%v\" viewBox=\"%v %v %v %v\">\n",
+		w.Width(), w.Height(), viewBox.Xmin(), viewBox.Ymin(), viewBox.Width(), viewBox.Height())
+	return w
+}
+
+// Width returns the width of the SVG, in pixels
+func (w *SVGStreamWriter) Width() int {
+	return w.width
+}
+
+// Height returns the height of the SVG, in pixels
+func (w *SVGStreamWriter) Height() int {
+	return int(float64(w.width) * w.bbox.Height() / w.bbox.Width())
+}
+
+func (w *SVGStreamWriter) printf(format string, a ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.out, format, a...)
+}
+
+func (w *SVGStreamWriter) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.out.Write(b)
+}
+
+func (w *SVGStreamWriter) appendFloat(b []byte, v float64) []byte {
+	return strconv.AppendFloat(b, v, 'f', w.Precision, 64)
+}
+
+func appendClass(b []byte, class string) []byte {
+	if len(class) > 0 {
+		b = append(b, " class=\""...)
+		b = append(b, class...)
+		b = append(b, '"')
+	}
+	return b
+}
+
+// flush writes the pending path, if any.
+func (w *SVGStreamWriter) flush() {
+	if len(w.batch) == 0 {
+		return
+	}
+	b := append(w.buf[:0], "<path d=\""...)
+	b = append(b, w.batch[:len(w.batch)-1]...)
+	b = append(b, '"')
+	b = appendClass(b, w.batchClass)
+	b = append(b, "/>\n"...)
+	w.write(b)
+	w.buf = b
+	w.batch = w.batch[:0]
+}
+
+// maxBatchSize is the size (in bytes) above which a path is written out,
+// to keep the memory footprint bounded.
+const maxBatchSize = 1 << 16
+
+// startBatch prepares the pending path to receive a sub-path of the given class.
+func (w *SVGStreamWriter) startBatch(class string) {
+	if class != w.batchClass || len(w.batch) > maxBatchSize {
+		w.flush()
+		w.batchClass = class
+	}
+}
+
+// GroupStart starts a group tag with a given classname.
+func (w *SVGStreamWriter) GroupStart(classname string) {
+	w.flush()
+	w.printf("<g class=\"%s\">\n", classname)
+}
+
+// GroupEnd ends a group tag
+func (w *SVGStreamWriter) GroupEnd() {
+	w.flush()
+	w.printf("</g>\n")
+}
+
+// Rect adds a rectangle object
+func (w *SVGStreamWriter) Rect(x, y, width, height float64) {
+	w.RectWithClass(x, y, width, height, "")
+}
+
+// RectWithClass adds a rectangle object with a given CSS class
+func (w *SVGStreamWriter) RectWithClass(x, y, width, height float64, class string) {
+	if w.BatchPaths {
+		w.startBatch(class)
+		b := append(w.batch, 'M')
+		b = w.appendFloat(b, x)
+		b = append(b, ' ')
+		b = w.appendFloat(b, y)
+		b = append(b, 'h')
+		b = w.appendFloat(b, width)
+		b = append(b, 'v')
+		b = w.appendFloat(b, height)
+		b = append(b, 'h')
+		b = w.appendFloat(b, -width)
+		b = append(b, "z "...)
+		w.batch = b
+		return
+	}
+	w.flush()
+	b := append(w.buf[:0], "<rect x=\""...)
+	b = w.appendFloat(b, x)
+	b = append(b, "\" y=\""...)
+	b = w.appendFloat(b, y)
+	b = append(b, "\" width=\""...)
+	b = w.appendFloat(b, width)
+	b = append(b, "\" height=\""...)
+	b = w.appendFloat(b, height)
+	b = append(b, '"')
+	b = appendClass(b, class)
+	b = append(b, "/>\n"...)
+	w.write(b)
+	w.buf = b
+}
+
+// Polygon adds a polygon object
+func (w *SVGStreamWriter) Polygon(p *Polygon) {
+	w.PolygonWithClass(p, "")
+}
+
+// PolygonWithClass adds a polygon object with a given CSS class
+func (w *SVGStreamWriter) PolygonWithClass(p *Polygon, class string) {
+	vertices := p.getVertices()
+	if w.BatchPaths {
+		w.startBatch(class)
+		b := w.batch
+		for i, v := range vertices {
+			if i == 0 {
+				b = append(b, 'M')
+			} else {
+				b = append(b, 'L')
+			}
+			b = w.appendFloat(b, v.X)
+			b = append(b, ' ')
+			b = w.appendFloat(b, v.Y)
+		}
+		w.batch = append(b, "z "...)
+		return
+	}
+	w.flush()
+	b := append(w.buf[:0], "<polygon points=\""...)
+	for _, v := range vertices {
+		b = w.appendFloat(b, v.X)
+		b = append(b, ',')
+		b = w.appendFloat(b, v.Y)
+		b = append(b, ' ')
+	}
+	b = append(b, '"')
+	b = appendClass(b, class)
+	b = append(b, "/>\n"...)
+	w.write(b)
+	w.buf = b
+}
+
+// Contour adds one polygon object per sub-contour
+func (w *SVGStreamWriter) Contour(c *Contour) {
+	for _, p := range *c {
+		w.Polygon(&p)
+	}
+}
+
+// Text adds a text object
+func (w *SVGStreamWriter) Text(text string, x, y float64) {
+	w.flush()
+	w.printf("%s\n", textag{x, y, html.EscapeString(text)}.svg())
+}
+
+// CenteredText adds a text object of a given font size (in user units)
+// and CSS class, centered on (x,y)
+func (w *SVGStreamWriter) CenteredText(text string, x, y, fontSize float64, class string) {
+	w.flush()
+	w.printf("%s\n", labeltag{x, y, fontSize, text, class}.svg())
+}
+
+// Style adds some style. Adding several times the same style
+// has the same effect as adding it once.
+func (w *SVGStreamWriter) Style(style string) {
+	if w.seen[style] {
+		return
+	}
+	w.seen[style] = true
+	w.styles = append(w.styles, style)
+}
+
+// ClassFor returns the name of a CSS class with the given declarations
+// (e.g. "fill:red;"), creating it if needed. Identical declarations
+// always get the same class.
+func (w *SVGStreamWriter) ClassFor(declarations string) string {
+	if c, ok := w.classes[declarations]; ok {
+		return c
+	}
+	c := fmt.Sprintf("c%d", len(w.classes))
+	w.classes[declarations] = c
+	w.Style(fmt.Sprintf(".%s{%s}\n", c, declarations))
+	return c
+}
+
+// Close writes the styles and ends the SVG document. It returns the
+// first error encountered while writing, if any.
+func (w *SVGStreamWriter) Close() error {
+	w.flush()
+	if len(w.styles) > 0 {
+		w.printf("<style>\n")
+		for _, s := range w.styles {
+			w.printf("%s", s)
+		}
+		w.printf("</style>\n")
+	}
+	w.printf("</svg>\n")
+	if w.err != nil {
+		return w.err
+	}
+	return w.out.Flush()
+}
@@ -0,0 +1,102 @@
+package geo
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestStreamWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewSVGStreamWriter(&buf, 100, NewBBoxUnchecked(0, 0, 10, 5))
+	w.GroupStart("test")
+	w.Rect(1, 2, 3, 4)
+	w.PolygonWithClass(&Polygon{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}, "big")
+	w.Text("a&b", 1, 1)
+	w.GroupEnd()
+	w.Style(".big{fill:red;}\n")
+	w.Style(".big{fill:red;}\n")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="50" viewBox="0 0 10 5">
+<g class="test">
+<rect x="1" y="2" width="3" height="4"/>
+<polygon points="0,0 1,0 1,1 0,1 " class="big"/>
+<text x="1" y="1">a&amp;b</text>
+</g>
+<style>
+.big{fill:red;}
+</style>
+</svg>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Wanted:\n%v\nand got:\n%v", want, got)
+	}
+}
+
+func TestStreamWriterBatchPaths(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewSVGStreamWriter(&buf, 100, NewBBoxUnchecked(0, 0, 10, 10))
+	w.BatchPaths = true
+	w.Precision = 1
+	w.RectWithClass(0, 0, 1, 1, "a")
+	w.RectWithClass(1, 0, 1, 1, "a")
+	w.PolygonWithClass(&Polygon{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}, "a")
+	w.RectWithClass(2, 0, 1, 1, "b")
+	w.Close()
+	got := buf.String()
+	for _, want := range []string{
+		`<path d="M0.0 0.0h1.0v1.0h-1.0z M1.0 0.0h1.0v1.0h-1.0z M0.0 0.0L1.0 0.0L1.0 1.0L0.0 1.0z" class="a"/>`,
+		`<path d="M2.0 0.0h1.0v1.0h-1.0z" class="b"/>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in:\n%s", want, got)
+		}
+	}
+}
+
+func TestStreamWriterClassFor(t *testing.T) {
+	w := NewSVGStreamWriter(ioutil.Discard, 100, NewBBoxUnchecked(0, 0, 1, 1))
+	a := w.ClassFor("fill:red;")
+	b := w.ClassFor("fill:blue;")
+	if a == b || w.ClassFor("fill:red;") != a {
+		t.Errorf("identical declarations should share a class, and only those")
+	}
+	if len(w.styles) != 2 {
+		t.Errorf("want 2 styles, got %d", len(w.styles))
+	}
+}
+
+func TestStreamWriterDoesNotAllocatePerElement(t *testing.T) {
+	for _, batch := range []bool{false, true} {
+		w := NewSVGStreamWriter(ioutil.Discard, 100, NewBBoxUnchecked(0, 0, 1, 1))
+		w.BatchPaths = batch
+		allocs := testing.AllocsPerRun(100000, func() {
+			w.RectWithClass(0.25, 0.5, 0.125, 1, "pad")
+		})
+		if allocs > 0.01 {
+			t.Errorf("batch=%v: want no allocation per rectangle, got %v", batch, allocs)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+var errFailing = errors.New("write failed")
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errFailing
+}
+
+func TestStreamWriterError(t *testing.T) {
+	w := NewSVGStreamWriter(failingWriter{}, 100, NewBBoxUnchecked(0, 0, 1, 1))
+	for i := 0; i < 10000; i++ {
+		w.Rect(0, 0, 1, 1)
+	}
+	if err := w.Close(); err != errFailing {
+		t.Errorf("want errFailing, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package geo
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// paint is a fill or stroke colour, as given by a CSS declaration.
+type paint struct {
+	set  bool
+	none bool
+	c    color.RGBA
+}
+
+// shapeStyle is the subset of CSS understood by the non SVG outputs
+// (Rasterizer, PDF, EPS).
+type shapeStyle struct {
+	fill, stroke paint
+	strokeWidth  float64
+	opacity      float64
+	hasWidth     bool
+	hasOpacity   bool
+}
+
+// merge overrides s with the properties set in o.
+func (s shapeStyle) merge(o shapeStyle) shapeStyle {
+	if o.fill.set {
+		s.fill = o.fill
+	}
+	if o.stroke.set {
+		s.stroke = o.stroke
+	}
+	if o.hasWidth {
+		s.strokeWidth, s.hasWidth = o.strokeWidth, true
+	}
+	if o.hasOpacity {
+		s.opacity, s.hasOpacity = o.opacity, true
+	}
+	return s
+}
+
+var namedColors = map[string]color.RGBA{
+	"black":  {0, 0, 0, 255},
+	"white":  {255, 255, 255, 255},
+	"red":    {255, 0, 0, 255},
+	"green":  {0, 128, 0, 255},
+	"blue":   {0, 0, 255, 255},
+	"gray":   {128, 128, 128, 255},
+	"grey":   {128, 128, 128, 255},
+	"orange": {255, 165, 0, 255},
+	"yellow": {255, 255, 0, 255},
+}
+
+func parsePaint(v string) (paint, bool) {
+	v = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "!important")))
+	if v == "none" {
+		return paint{set: true, none: true}, true
+	}
+	if c, ok := namedColors[v]; ok {
+		return paint{set: true, c: c}, true
+	}
+	if !strings.HasPrefix(v, "#") {
+		return paint{}, false
+	}
+	hex := v[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return paint{}, false
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return paint{}, false
+	}
+	return paint{set: true, c: color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 255}}, true
+}
+
+func parseDeclarations(decls string) shapeStyle {
+	var s shapeStyle
+	for _, d := range strings.Split(decls, ";") {
+		kv := strings.SplitN(d, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "fill":
+			if p, ok := parsePaint(value); ok {
+				s.fill = p
+			}
+		case "stroke":
+			if p, ok := parsePaint(value); ok {
+				s.stroke = p
+			}
+		case "stroke-width":
+			if w, err := strconv.ParseFloat(strings.TrimSuffix(value, "px"), 64); err == nil {
+				s.strokeWidth, s.hasWidth = w, true
+			}
+		case "fill-opacity", "opacity":
+			if o, err := strconv.ParseFloat(value, 64); err == nil {
+				s.opacity, s.hasOpacity = o, true
+			}
+		}
+	}
+	return s
+}
+
+// styleSheet resolves the style of the elements of a drawing from
+// simple class (.name) and element (rect, polygon, text) CSS selectors,
+// the style of groups being inherited by their elements.
+type styleSheet struct {
+	styles map[string]shapeStyle
+	groups []shapeStyle
+}
+
+func newStyleSheet() *styleSheet {
+	return &styleSheet{styles: make(map[string]shapeStyle)}
+}
+
+// add parses CSS rules. Rules with complex selectors are ignored.
+func (ss *styleSheet) add(css string) {
+	for _, rule := range strings.Split(css, "}") {
+		parts := strings.SplitN(rule, "{", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		decls := parseDeclarations(parts[1])
+		for _, sel := range strings.Split(parts[0], ",") {
+			sel = strings.TrimSpace(sel)
+			if sel == "" || strings.ContainsAny(sel, " :>[") {
+				continue
+			}
+			ss.styles[sel] = ss.styles[sel].merge(decls)
+		}
+	}
+}
+
+// push starts a group with the given classes.
+func (ss *styleSheet) push(classname string) {
+	s := shapeStyle{}
+	if len(ss.groups) > 0 {
+		s = ss.groups[len(ss.groups)-1]
+	}
+	for _, c := range strings.Fields(classname) {
+		s = s.merge(ss.styles["."+c])
+	}
+	ss.groups = append(ss.groups, s)
+}
+
+// pop ends the current group.
+func (ss *styleSheet) pop() {
+	if len(ss.groups) > 0 {
+		ss.groups = ss.groups[:len(ss.groups)-1]
+	}
+}
+
+// styleOf returns the style of an element of the given type and classes,
+// within the current groups. As in SVG, the default is a black fill
+// without stroke.
+func (ss *styleSheet) styleOf(element, class string) shapeStyle {
+	s := shapeStyle{fill: paint{set: true, c: color.RGBA{0, 0, 0, 255}}, strokeWidth: 1, opacity: 1}
+	if len(ss.groups) > 0 {
+		s = s.merge(ss.groups[len(ss.groups)-1])
+	}
+	s = s.merge(ss.styles[element])
+	for _, c := range strings.Fields(class) {
+		s = s.merge(ss.styles["."+c])
+	}
+	return s
+}
+
+// flatten returns the colour of a paint with the given opacity
+// over a white background.
+func flatten(c color.RGBA, opacity float64) color.RGBA {
+	if opacity >= 1 {
+		return c
+	}
+	mix := func(v uint8) uint8 {
+		return uint8(math.Round(255*(1-opacity) + float64(v)*opacity))
+	}
+	return color.RGBA{mix(c.R), mix(c.G), mix(c.B), 255}
+}
@@ -2,17 +2,17 @@ package geo
 
 import (
 	"fmt"
+	"html"
 	"io"
-	"math"
 )
 
 // SVGWriter is a utility object to create SVG output
 // for polygon, contours etc...
+// The underlying Drawing can also be written as PDF or EPS.
 type SVGWriter struct {
-	width                        int
-	styleBuffer                  string
-	elements                     []element
-	xleft, xright, ybottom, ytop float64
+	Drawing
+	width        int
+	scriptBuffer string
 }
 
 func NewSVGWriter(width int) *SVGWriter {
@@ -23,60 +23,6 @@ func NewSVGWriter(width int) *SVGWriter {
 	return &SVGWriter{width: w}
 }
 
-func (w *SVGWriter) assertViewBox() {
-	if w.xleft < w.xright && w.ybottom < w.ytop {
-		return
-	}
-	// loop over all elements to find the boundaries
-	xmin := math.MaxFloat64
-	xmax := -xmin
-	ymin := xmin
-	ymax := -ymin
-	for _, e := range w.elements {
-		b := e.bbox()
-		if b.Width() > tiny && b.Height() > tiny {
-			xmin = math.Min(xmin, b.Xmin())
-			xmax = math.Max(xmax, b.Xmax())
-			ymin = math.Min(ymin, b.Ymin())
-			ymax = math.Max(ymax, b.Ymax())
-		}
-	}
-	w.xleft = xmin
-	w.xright = xmax
-	w.ybottom = ymin
-	w.ytop = ymax
-}
-
-func (w *SVGWriter) Translate(x0, y0 float64) {
-	for _, e := range w.elements {
-		e.translate(x0, y0)
-	}
-	w.xleft += x0
-	w.xright += x0
-	w.ytop += y0
-	w.ybottom += y0
-}
-
-func (w *SVGWriter) MoveToOrigin() {
-	w.assertViewBox()
-	w.Translate(-w.xleft, -w.ybottom)
-}
-
-func (w *SVGWriter) ViewBox(xleft, xright, ybottom, ytop float64) {
-	w.xleft = xleft
-	w.xright = xright
-	w.ytop = ytop
-	w.ybottom = ybottom
-}
-
-func (w *SVGWriter) ViewBoxHeight() float64 {
-	return w.ytop - w.ybottom
-}
-
-func (w *SVGWriter) ViewBoxWidth() float64 {
-	return w.xright - w.xleft
-}
-
 func (w *SVGWriter) Width() int {
 	if w.width > 0 {
 		return w.width
@@ -89,70 +35,12 @@ func (w *SVGWriter) Height() int {
 	return int(float64(w.Width()) * w.ViewBoxHeight() / w.ViewBoxWidth())
 }
 
-// GroupStart starts a group tag with a given classname.
-func (w *SVGWriter) GroupStart(classname string) {
-	w.appendElement(grouptag(classname))
-}
-
-// GroupEnd ends a group tag
-func (w *SVGWriter) GroupEnd() {
-	w.appendElement(groupendtag{})
-}
-
-// Rect adds a rectangle object
-func (w *SVGWriter) Rect(x, y, width, height float64) {
-	w.RectWithClass(x, y, width, height, "")
-}
-
-// Rect adds a rectangle object
-func (w *SVGWriter) RectWithClass(x, y, width, height float64, class string) {
-	w.appendElement(&rectag{x, y, width, height, class})
-}
-
-// Text adds a text object
-func (w *SVGWriter) Text(text string, x, y float64) {
-	w.appendElement(&textag{x, y, text})
-}
-
-// Polygon adds a polygon object
-func (w *SVGWriter) Polygon(p *Polygon) {
-	w.PolygonWithClass(p, "")
-}
-
-// PolygonWithClass adds a polygon object with a given CSS class
-func (w *SVGWriter) PolygonWithClass(p *Polygon, class string) {
-	vertices := p.getVertices()
-	x := make([]float64, len(vertices))
-	y := make([]float64, len(vertices))
-	for i, v := range vertices {
-		x[i] = v.X
-		y[i] = v.Y
-	}
-	w.appendElement(&poltag{x, y, class})
-}
-
-func (w *SVGWriter) Circle(x, y, radius float64) {
-	w.appendElement(&cirtag{x, y, radius})
-}
-
-// Contour adds one polygon object per sub-contour
-func (w *SVGWriter) Contour(c *Contour) {
-	for _, p := range *c {
-		w.Polygon(&p)
-	}
-}
-
-// Style add some style
-func (w *SVGWriter) Style(style string) {
-	w.styleBuffer += style
-}
-
 // WriteStyle outputs <style> options
 func (w *SVGWriter) WriteStyle(out io.Writer) {
 	fmt.Fprintf(out, "<style>%s</style>\n", w.styleBuffer)
 }
 
-// WriteSVG output
+// WriteSVG output. The viewBox attribute is the (xmin,ymin,width,height) of the drawing.
 func (w *SVGWriter) WriteSVG(out io.Writer) {
 
 	w.assertViewBox()
@@ -160,10 +48,31 @@ func (w *SVGWriter) WriteSVG(out io.Writer) {
 	fmt.Fprintf(
 		out,
 		"<svg width=\"%v\" height=\"%v\" viewBox=\"%v %v %v %v\">\n",
-		w.Width(), w.Height(), w.xleft, w.ybottom, w.xright, w.ytop,
+		w.Width(), w.Height(), w.xleft, w.ybottom, w.ViewBoxWidth(), w.ViewBoxHeight(),
 	)
 	for _, e := range w.elements {
-		fmt.Fprintln(out, e)
+		fmt.Fprintln(out, e.(svgElement).svg())
+	}
+	fmt.Fprintf(out, "</svg>\n")
+}
+
+// WriteSVGDocument outputs a standalone SVG document, i.e. with the
+// namespace declaration and the styles embedded in the svg element.
+func (w *SVGWriter) WriteSVGDocument(out io.Writer) {
+
+	w.assertViewBox()
+
+	fmt.Fprintf(out, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(
+		out,
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%v\" height=\"%v\" viewBox=\"%v %v %v %v\">\n",
+		w.Width(), w.Height(), w.xleft, w.ybottom, w.ViewBoxWidth(), w.ViewBoxHeight(),
+	)
+	if len(w.styleBuffer) > 0 {
+		fmt.Fprintf(out, "<style>%s</style>\n", w.styleBuffer)
+	}
+	for _, e := range w.elements {
+		fmt.Fprintln(out, e.(svgElement).svg())
 	}
 	fmt.Fprintf(out, "</svg>\n")
 }
@@ -177,59 +86,36 @@ func (w *SVGWriter) WriteHTML(out io.Writer) {
 	fmt.Fprintf(out, "</body>\n</html>\n")
 }
 
-type element interface {
-	fmt.Stringer
-	translate(x0, y0 float64)
-	bbox() BBox
+// svgElement is implemented by all the elements of a drawing,
+// to be output as SVG.
+type svgElement interface {
+	svg() string
 }
 
-var (
-	tiny       float64 = 1E-9
-	tinyBox, _         = NewBBox(-tiny/2.0, -tiny/2.0, tiny/2.0, tiny/2.0)
-)
+var _ = []svgElement{grouptag(""), attrgrouptag{}, groupendtag{},
+	&rectag{}, &textag{}, &labeltag{}, &poltag{}, &cirtag{}}
 
-type grouptag string
-type groupendtag struct{}
-type rectag struct {
-	x, y, width, height float64
-	class               string
-}
-type textag struct {
-	x, y float64
-	text string
-}
-type poltag struct {
-	x     []float64
-	y     []float64
-	class string
-}
-type cirtag struct {
-	x, y, radius float64
+func (a Attr) String() string {
+	return fmt.Sprintf(" %s=\"%s\"", a.Name, html.EscapeString(a.Value))
 }
 
-func (g grouptag) String() string {
+func (g grouptag) svg() string {
 	return fmt.Sprintf("<g class=\"%s\">", string(g))
 }
 
-func (g grouptag) translate(x, y float64) {
-}
-
-func (g grouptag) bbox() BBox {
-	return tinyBox
+func (g attrgrouptag) svg() string {
+	s := fmt.Sprintf("<g class=\"%s\"", g.class)
+	for _, a := range g.attrs {
+		s += a.String()
+	}
+	return s + ">"
 }
 
-func (g groupendtag) String() string {
+func (g groupendtag) svg() string {
 	return "</g>"
 }
 
-func (g groupendtag) bbox() BBox {
-	return tinyBox
-}
-
-func (g groupendtag) translate(x, y float64) {
-}
-
-func (r rectag) String() string {
+func (r rectag) svg() string {
 	s := fmt.Sprintf("<rect x=\"%v\" y=\"%v\" width=\"%v\" height=\"%v\"", r.x, r.y, r.width, r.height)
 	if len(r.class) > 0 {
 		s += " class=\"" + r.class + "\""
@@ -238,34 +124,20 @@ func (r rectag) String() string {
 	return s
 }
 
-func (r *rectag) translate(x0, y0 float64) {
-	r.x += x0
-	r.y += y0
-}
-
-func (r *rectag) bbox() BBox {
-	b, err := NewBBox(r.x, r.y, r.x+r.width, r.y+r.height)
-	if err != nil {
-		panic(err)
-	}
-	return b
-}
-
-func (t textag) String() string {
+func (t textag) svg() string {
 	return fmt.Sprintf("<text x=\"%v\" y=\"%v\">%s</text>", t.x, t.y, t.text)
 }
 
-func (t *textag) translate(x0, y0 float64) {
-	t.x += x0
-	t.y += y0
-}
-
-func (t *textag) bbox() BBox {
-	//FIXME: is there a way to actually get the size here ??
-	return tinyBox
+func (l labeltag) svg() string {
+	s := fmt.Sprintf("<text x=\"%v\" y=\"%v\" font-size=\"%v\" text-anchor=\"middle\" dominant-baseline=\"central\"", l.x, l.y, l.size)
+	if len(l.class) > 0 {
+		s += " class=\"" + l.class + "\""
+	}
+	s += ">" + html.EscapeString(l.text) + "</text>"
+	return s
 }
 
-func (p poltag) String() string {
+func (p poltag) svg() string {
 	s := fmt.Sprintf("<polygon points=\"")
 	for i, _ := range p.x {
 		s += fmt.Sprintf("%v,%v ", p.x[i], p.y[i])
@@ -274,52 +146,13 @@ func (p poltag) String() string {
 	if len(p.class) > 0 {
 		s += " class=\"" + p.class + "\""
 	}
+	for _, a := range p.attrs {
+		s += a.String()
+	}
 	s += "/>"
 	return s
 }
 
-func (p *poltag) translate(x0, y0 float64) {
-	for i, _ := range p.x {
-		p.x[i] += x0
-		p.y[i] += y0
-	}
-}
-
-func (p *poltag) bbox() BBox {
-	xmin := math.MaxFloat64
-	xmax := -xmin
-	ymin := xmin
-	ymax := -ymin
-	for i, _ := range p.x {
-		xmin = math.Min(xmin, p.x[i])
-		xmax = math.Max(xmax, p.x[i])
-		ymin = math.Min(ymin, p.y[i])
-		ymax = math.Max(ymax, p.y[i])
-	}
-	b, err := NewBBox(xmin, ymin, xmax, ymax)
-	if err != nil {
-		panic(err)
-	}
-	return b
-}
-
-func (c cirtag) String() string {
+func (c cirtag) svg() string {
 	return fmt.Sprintf("<circle cx=\"%f\" cy=\"%f\" r=\"%f\" />", c.x, c.y, c.radius)
 }
-
-func (c *cirtag) translate(x0, y0 float64) {
-	c.x += x0
-	c.y += y0
-}
-
-func (c *cirtag) bbox() BBox {
-	b, err := NewBBox(c.x-c.radius, c.y-c.radius, c.x+c.radius, c.y+c.radius)
-	if err != nil {
-		panic(err)
-	}
-	return b
-}
-
-func (w *SVGWriter) appendElement(e element) {
-	w.elements = append(w.elements, e)
-}
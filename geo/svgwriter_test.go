@@ -11,7 +11,7 @@ func TestBasicWrite(t *testing.T) {
 	want := `<html>
 <style></style>
 <body>
-<svg width="1024" height="1024" viewBox="0.1 0.1 10.01 10.01">
+<svg width="1024" height="1024" viewBox="0.1 0.1 9.91 9.91">
 <g class="test">
 <rect x="1" y="2" width="3" height="4"/>
 <text x="10" y="20">some text</text>
@@ -59,3 +59,54 @@ func TestBasicWrite(t *testing.T) {
 	}
 
 }
+
+func TestCenteredText(t *testing.T) {
+	svg := NewSVGWriter(100)
+	svg.CenteredText("a<b", 0, 20, 5, "label")
+	var buf bytes.Buffer
+	svg.WriteSVG(&buf)
+	want := `<svg width="100" height="55" viewBox="-4.5 17.5 9 5">
+<text x="0" y="20" font-size="5" text-anchor="middle" dominant-baseline="central" class="label">a&lt;b</text>
+</svg>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Wanted:\n%v\nand got:\n%v", want, got)
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	svg := NewSVGWriter(100)
+	svg.GroupStartWithAttrs("layer", Attr{"data-layer", "one"})
+	svg.PolygonWithAttrs(&Polygon{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}, "pad", Attr{"id", "p1"}, Attr{"data-tooltip", `say "hi"`})
+	svg.GroupEnd()
+	var buf bytes.Buffer
+	svg.WriteInteractiveHTML(&buf)
+	got := buf.String()
+	for _, want := range []string{
+		`<g class="layer" data-layer="one">`,
+		`<polygon points="0,0 1,0 1,1 0,1 " class="pad" id="p1" data-tooltip="say &#34;hi&#34;"/>`,
+		`<div id="tooltip"></div>`,
+		`<script>`,
+	} {
+		if !bytes.Contains([]byte(got), []byte(want)) {
+			t.Errorf("missing %s in:\n%s", want, got)
+		}
+	}
+}
+
+func TestSVGDocument(t *testing.T) {
+	svg := NewSVGWriter(100)
+	svg.Style(".pad{fill:red;}")
+	svg.RectWithClass(0, 0, 10, 5, "pad")
+	var buf bytes.Buffer
+	svg.WriteSVGDocument(&buf)
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="100" height="50" viewBox="0 0 10 5">
+<style>.pad{fill:red;}</style>
+<rect x="0" y="0" width="10" height="5" class="pad"/>
+</svg>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
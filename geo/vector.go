@@ -0,0 +1,168 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrEmptyDrawing = errors.New("drawing has an empty view box")
+	ErrInvalidPage  = errors.New("page width must be positive")
+)
+
+// defaultFontSize is the SVG font size (in user units) of texts
+// without explicit size.
+const defaultFontSize = 16
+
+// vectorBackend is the interface implemented by the page description
+// outputs (PDF, EPS) of a Drawing. Coordinates are given in points,
+// with the y axis pointing up.
+type vectorBackend interface {
+	// path fills and/or strokes a closed path
+	path(x, y []float64, s shapeStyle, strokeWidth float64)
+	// text draws a text with its baseline starting at (x,y) or,
+	// if centered, with its center at (x,y)
+	text(text string, x, y, size float64, s shapeStyle, centered bool)
+}
+
+// page maps the user coordinates of a drawing to a page of a given width
+// (in points). The y axis points up, as in the drawing coordinates and
+// in the DXF and GeoJSON outputs. The page is thus the vertical mirror
+// of the SVG and PNG outputs, whose y axis points down.
+type page struct {
+	xleft, ybottom float64
+	scale          float64
+	width, height  float64
+}
+
+func (d *Drawing) page(width float64) (page, error) {
+	if width <= 0 {
+		return page{}, ErrInvalidPage
+	}
+	d.assertViewBox()
+	w := d.xright - d.xleft
+	h := d.ytop - d.ybottom
+	if !(w > 0 && h > 0) || math.IsInf(w, 0) || math.IsInf(h, 0) {
+		return page{}, ErrEmptyDrawing
+	}
+	scale := width / w
+	return page{d.xleft, d.ybottom, scale, width, h * scale}, nil
+}
+
+func (p page) point(x, y float64) (float64, float64) {
+	return (x - p.xleft) * p.scale, (y - p.ybottom) * p.scale
+}
+
+func (p page) polygon(xs, ys []float64) ([]float64, []float64) {
+	px := make([]float64, len(xs))
+	py := make([]float64, len(ys))
+	for i := range xs {
+		px[i], py[i] = p.point(xs[i], ys[i])
+	}
+	return px, py
+}
+
+// render sends all the elements of the drawing, with their resolved styles,
+// to a backend.
+func (d *Drawing) render(p page, b vectorBackend) {
+	ss := newStyleSheet()
+	ss.add(d.styleBuffer)
+	for _, e := range d.elements {
+		switch t := e.(type) {
+		case grouptag:
+			ss.push(string(t))
+		case attrgrouptag:
+			ss.push(t.class)
+		case groupendtag:
+			ss.pop()
+		case *rectag:
+			x, y := p.polygon(
+				[]float64{t.x, t.x + t.width, t.x + t.width, t.x},
+				[]float64{t.y, t.y, t.y + t.height, t.y + t.height})
+			s := ss.styleOf("rect", t.class)
+			b.path(x, y, s, s.strokeWidth*p.scale)
+		case *poltag:
+			x, y := p.polygon(t.x, t.y)
+			s := ss.styleOf("polygon", t.class)
+			b.path(x, y, s, s.strokeWidth*p.scale)
+		case *cirtag:
+			const n = 36
+			xs := make([]float64, n)
+			ys := make([]float64, n)
+			for i := range xs {
+				a := 2 * math.Pi * float64(i) / n
+				xs[i] = t.x + t.radius*math.Cos(a)
+				ys[i] = t.y + t.radius*math.Sin(a)
+			}
+			x, y := p.polygon(xs, ys)
+			s := ss.styleOf("circle", "")
+			b.path(x, y, s, s.strokeWidth*p.scale)
+		case *textag:
+			x, y := p.point(t.x, t.y)
+			b.text(t.text, x, y, defaultFontSize*p.scale, ss.styleOf("text", ""), false)
+		case *labeltag:
+			x, y := p.point(t.x, t.y)
+			b.text(t.text, x, y, t.size*p.scale, ss.styleOf("text", t.class), true)
+		}
+	}
+}
+
+// fillColor returns the fill colour of a style, if any.
+func (s shapeStyle) fillColor() (r, g, b float64, ok bool) {
+	if !s.fill.set || s.fill.none {
+		return 0, 0, 0, false
+	}
+	c := flatten(s.fill.c, s.opacity)
+	return float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255, true
+}
+
+// strokeColor returns the stroke colour of a style, if any.
+func (s shapeStyle) strokeColor() (r, g, b float64, ok bool) {
+	if !s.stroke.set || s.stroke.none {
+		return 0, 0, 0, false
+	}
+	c := s.stroke.c
+	return float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255, true
+}
+
+// psNum formats a number the way PDF and PostScript expect it
+// (no exponent, at most 3 decimals).
+func psNum(v float64) string {
+	s := fmt.Sprintf("%.3f", v)
+	for s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// latin1 returns the printable ASCII version of a text, other characters
+// being replaced by '?', as the outputs only use the standard Helvetica font.
+func latin1(text string) string {
+	b := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r < 32 || r > 126 {
+			r = '?'
+		}
+		b = append(b, byte(r))
+	}
+	return string(b)
+}
+
+// escapeString escapes a text for use as a PDF or PostScript string.
+func escapeString(text string) string {
+	b := make([]byte, 0, len(text))
+	for _, c := range []byte(latin1(text)) {
+		if c == '(' || c == ')' || c == '\\' {
+			b = append(b, '\\')
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
@@ -0,0 +1,193 @@
+package geo
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidWKT = errors.New("invalid WKT (only POLYGON and MULTIPOLYGON are supported)")
+
+func wktNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// wktRing appends the (closed) ring of the polygon to b.
+func (p Polygon) wktRing(b *strings.Builder) {
+	b.WriteByte('(')
+	for i, pos := range p.ring() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(wktNumber(pos[0]))
+		b.WriteByte(' ')
+		b.WriteString(wktNumber(pos[1]))
+	}
+	b.WriteByte(')')
+}
+
+// WKT returns the polygon in Well-Known Text format.
+func (p Polygon) WKT() string {
+	var b strings.Builder
+	b.WriteString("POLYGON(")
+	p.wktRing(&b)
+	b.WriteByte(')')
+	return b.String()
+}
+
+// WKT returns the contour in Well-Known Text format, as a MULTIPOLYGON
+// where each (counter-clockwise) polygon carries the holes it contains.
+func (c Contour) WKT() string {
+	groups := c.withHoles()
+	if len(groups) == 0 {
+		return "MULTIPOLYGON EMPTY"
+	}
+	var b strings.Builder
+	b.WriteString("MULTIPOLYGON(")
+	for i, g := range groups {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('(')
+		for j, p := range g {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			p.wktRing(&b)
+		}
+		b.WriteByte(')')
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) skipSpaces() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// next returns true (and consumes it) if the next non blank character is c.
+func (p *wktParser) next(c byte) bool {
+	p.skipSpaces()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *wktParser) word() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z' || p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z') {
+		p.pos++
+	}
+	return strings.ToUpper(p.s[start:p.pos])
+}
+
+func (p *wktParser) number() (float64, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		return 0, ErrInvalidWKT
+	}
+	return v, nil
+}
+
+// list parses a parenthesized, comma separated, list of items.
+func (p *wktParser) list(item func() error) error {
+	if !p.next('(') {
+		return ErrInvalidWKT
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		if p.next(')') {
+			return nil
+		}
+		if !p.next(',') {
+			return ErrInvalidWKT
+		}
+	}
+}
+
+// polygon parses the rings of one polygon, the first one (exterior)
+// being oriented counter-clockwise and the others (holes) clockwise.
+func (p *wktParser) polygon() (Contour, error) {
+	var c Contour
+	err := p.list(func() error {
+		var r ring
+		err := p.list(func() error {
+			x, err := p.number()
+			if err != nil {
+				return err
+			}
+			y, err := p.number()
+			if err != nil {
+				return err
+			}
+			r = append(r, position{x, y})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		pol, err := r.polygon(len(c) == 0)
+		if err != nil {
+			return ErrInvalidWKT
+		}
+		c = append(c, pol)
+		return nil
+	})
+	return c, err
+}
+
+// ParseWKT decodes a WKT POLYGON or MULTIPOLYGON into a contour,
+// holes becoming clockwise polygons.
+func ParseWKT(s string) (Contour, error) {
+	p := &wktParser{s: s}
+	kind := p.word()
+	if kind != "POLYGON" && kind != "MULTIPOLYGON" {
+		return nil, ErrInvalidWKT
+	}
+	save := p.pos
+	if p.word() == "EMPTY" {
+		return Contour{}, p.end()
+	}
+	p.pos = save
+	var c Contour
+	var err error
+	if kind == "POLYGON" {
+		c, err = p.polygon()
+	} else {
+		err = p.list(func() error {
+			pc, err := p.polygon()
+			c = append(c, pc...)
+			return err
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, p.end()
+}
+
+// end checks that nothing is left to parse.
+func (p *wktParser) end() error {
+	p.skipSpaces()
+	if p.pos != len(p.s) {
+		return ErrInvalidWKT
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package geo
+
+import "testing"
+
+func TestPolygonWKT(t *testing.T) {
+	p := Polygon{{0, 0}, {2.5, 0}, {2.5, 1}, {0, 1}}
+	if got, want := p.WKT(), "POLYGON((0 0,2.5 0,2.5 1,0 1,0 0))"; got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
+
+func TestContourWKT(t *testing.T) {
+	outer := Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+	hole := Polygon{{4, 4}, {4, 6}, {6, 6}, {6, 4}, {4, 4}}
+	island := Polygon{{20, 0}, {21, 0}, {21, 1}, {20, 1}, {20, 0}}
+	c := Contour{outer, island, hole}
+	want := "MULTIPOLYGON(((0 0,10 0,10 10,0 10,0 0),(4 4,4 6,6 6,6 4,4 4)),((20 0,21 0,21 1,20 1,20 0)))"
+	if got := c.WKT(); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+	back, err := ParseWKT(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualContour(c, back) {
+		t.Errorf("want %v, got %v", c, back)
+	}
+	if got := (Contour{}).WKT(); got != "MULTIPOLYGON EMPTY" {
+		t.Errorf("unexpected empty contour WKT %s", got)
+	}
+}
+
+func TestParseWKT(t *testing.T) {
+	// clockwise exterior, spaces and lower case are accepted
+	c, err := ParseWKT(" polygon ( (0 0, 0 1, 1e0 1 , 1 0,0 0) ) ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c) != 1 || !c[0].isCounterClockwiseOriented() {
+		t.Errorf("unexpected polygon %v", c)
+	}
+	if c, err := ParseWKT("MULTIPOLYGON EMPTY"); err != nil || len(c) != 0 {
+		t.Errorf("unexpected empty multipolygon %v (%v)", c, err)
+	}
+	for _, s := range []string{
+		"",
+		"POINT(0 0)",
+		"POLYGON((0 0,1 0,1 1,0 0)",
+		"POLYGON((0 0,1 0,1 1,0 1))",
+		"POLYGON((0 0,1 0,0 0))",
+		"POLYGON((0 0,1 0,1 x,0 0))",
+		"POLYGON((0 0,1 0,1 1,0 0)) x",
+		"MULTIPOLYGON((0 0,1 0,1 1,0 0))",
+	} {
+		if _, err := ParseWKT(s); err != ErrInvalidWKT {
+			t.Errorf("%q: want ErrInvalidWKT, got %v", s, err)
+		}
+	}
+}
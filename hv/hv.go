@@ -0,0 +1,220 @@
+package hv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+var (
+	// ErrInvalidDEID signals an unknown detection element.
+	ErrInvalidDEID = errors.New("invalid detection element id")
+	// ErrInvalidSector signals an HV sector index out of range.
+	ErrInvalidSector = errors.New("invalid HV sector")
+	// ErrNotImplemented signals a detection element whose HV sectors
+	// are not described in this package, i.e. a quadrant of stations 1 and 2.
+	ErrNotImplemented = errors.New("HV sectors of the quadrants are not implemented")
+)
+
+// Channel identifies one HV channel, i.e. one HV sector of one
+// detection element.
+type Channel struct {
+	DEID   mapping.DEID
+	Sector int
+}
+
+func (c Channel) String() string {
+	if isQuadrant(c.DEID) {
+		return fmt.Sprintf("DE%d/Quad/Sect%d", c.DEID, c.Sector)
+	}
+	return fmt.Sprintf("DE%d/Slat/PCB%d", c.DEID, c.Sector)
+}
+
+func isQuadrant(deid mapping.DEID) bool {
+	return deid < 500
+}
+
+// Map gives the HV sector of each pad of one detection element.
+//
+// The slats have one HV sector per PCB, the PCBs being numbered from
+// left (negative x) to right. The PCB boundaries are found from the
+// segmentation itself, as the vertical lines that no dual sampa crosses
+// (see pcbBoundaries).
+//
+// Each dual sampa belongs as a whole to one sector, the one containing
+// the centers of most of its pads, so that the pads read by one
+// front-end card are never split across HV channels.
+//
+// The HV sectors of the quadrants of stations 1 and 2 do not follow the
+// PCBs and are not described in this package.
+type Map struct {
+	seg     mapping.Segmentation
+	sectors []int
+	n       int
+}
+
+// NewMap returns the HV map of a segmentation. It returns
+// ErrNotImplemented for the quadrants of stations 1 and 2.
+func NewMap(seg mapping.Segmentation) (*Map, error) {
+	if seg == nil {
+		return nil, ErrInvalidDEID
+	}
+	if isQuadrant(seg.DetElemID()) {
+		return nil, ErrNotImplemented
+	}
+	m := &Map{seg: seg, sectors: make([]int, seg.NofPads())}
+	boundaries := pcbBoundaries(seg)
+	m.n = len(boundaries) + 1
+	votes := make(map[mapping.DualSampaID][]int)
+	seg.ForEachPad(func(paduid mapping.PadUID) {
+		dsid := seg.PadDualSampaID(paduid)
+		if votes[dsid] == nil {
+			votes[dsid] = make([]int, m.n)
+		}
+		votes[dsid][sort.SearchFloat64s(boundaries, seg.PadPositionX(paduid))]++
+	})
+	dsSectors := make(map[mapping.DualSampaID]int, len(votes))
+	for dsid, v := range votes {
+		best := 0
+		for s := range v {
+			if v[s] > v[best] {
+				best = s
+			}
+		}
+		dsSectors[dsid] = best
+	}
+	seg.ForEachPad(func(paduid mapping.PadUID) {
+		m.sectors[paduid] = dsSectors[seg.PadDualSampaID(paduid)]
+	})
+	return m, nil
+}
+
+// pcbBoundaries returns the (sorted) x positions of the boundaries between
+// the PCBs of a slat. As each PCB has its own front-end cards, those are
+// the vertical lines, within the slat, that no dual sampa of either
+// cathode crosses.
+func pcbBoundaries(seg mapping.Segmentation) []float64 {
+	type extent struct {
+		xmin, xmax float64
+	}
+	extents := make(map[mapping.DualSampaID]*extent)
+	seg.ForEachPad(func(paduid mapping.PadUID) {
+		dsid := seg.PadDualSampaID(paduid)
+		x, dx := seg.PadPositionX(paduid), seg.PadSizeX(paduid)/2
+		e, ok := extents[dsid]
+		if !ok {
+			extents[dsid] = &extent{x - dx, x + dx}
+			return
+		}
+		e.xmin = math.Min(e.xmin, x-dx)
+		e.xmax = math.Max(e.xmax, x+dx)
+	})
+	bbox := mapping.ComputeSegmentationBBox(seg)
+	var boundaries []float64
+	isBoundary := func(x float64) bool {
+		if !geo.IsStrictlyBelowFloat(bbox.Xmin(), x) || !geo.IsStrictlyBelowFloat(x, bbox.Xmax()) {
+			return false
+		}
+		for _, b := range boundaries {
+			if geo.EqualFloat(b, x) {
+				return false
+			}
+		}
+		for _, e := range extents {
+			if geo.IsStrictlyBelowFloat(e.xmin, x) && geo.IsStrictlyBelowFloat(x, e.xmax) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, e := range extents {
+		for _, x := range []float64{e.xmin, e.xmax} {
+			if isBoundary(x) {
+				boundaries = append(boundaries, x)
+			}
+		}
+	}
+	sort.Float64s(boundaries)
+	return boundaries
+}
+
+// NofSectors returns the number of HV sectors of the detection element.
+func (m *Map) NofSectors() int {
+	return m.n
+}
+
+// Channels returns all the HV channels of the detection element.
+func (m *Map) Channels() []Channel {
+	channels := make([]Channel, m.n)
+	for i := range channels {
+		channels[i] = Channel{m.seg.DetElemID(), i}
+	}
+	return channels
+}
+
+// PadChannel returns the HV channel of one pad.
+func (m *Map) PadChannel(paduid mapping.PadUID) (Channel, error) {
+	if paduid < 0 || int(paduid) >= len(m.sectors) {
+		return Channel{}, fmt.Errorf("invalid pad uid %d", paduid)
+	}
+	return Channel{m.seg.DetElemID(), m.sectors[paduid]}, nil
+}
+
+// ForEachPadInSector loops over the pads (of both cathodes)
+// of one HV sector.
+func (m *Map) ForEachPadInSector(sector int, padHandler func(paduid mapping.PadUID)) error {
+	if sector < 0 || sector >= m.n {
+		return ErrInvalidSector
+	}
+	for paduid, s := range m.sectors {
+		if s == sector {
+			padHandler(mapping.PadUID(paduid))
+		}
+	}
+	return nil
+}
+
+// DualSampas returns the (sorted) list of the dual sampas having
+// at least one pad in the HV sector.
+func (m *Map) DualSampas(sector int) ([]mapping.DualSampaID, error) {
+	set := make(map[mapping.DualSampaID]bool)
+	err := m.ForEachPadInSector(sector, func(paduid mapping.PadUID) {
+		set[m.seg.PadDualSampaID(paduid)] = true
+	})
+	if err != nil {
+		return nil, err
+	}
+	var dsids []mapping.DualSampaID
+	for dsid := range set {
+		dsids = append(dsids, dsid)
+	}
+	sort.Slice(dsids, func(i, j int) bool { return dsids[i] < dsids[j] })
+	return dsids, nil
+}
+
+// Contour returns the outline of one HV sector, i.e. the union
+// of the bending pads of that sector.
+func (m *Map) Contour(sector int) (geo.Contour, error) {
+	var polygons []geo.Polygon
+	err := m.ForEachPadInSector(sector, func(paduid mapping.PadUID) {
+		if !m.seg.IsBendingPad(paduid) {
+			return
+		}
+		var xmin, ymin, xmax, ymax float64
+		mapping.ComputePadBBox(m.seg, paduid, &xmin, &ymin, &xmax, &ymax)
+		polygons = append(polygons, geo.Polygon{
+			{X: xmin, Y: ymin},
+			{X: xmax, Y: ymin},
+			{X: xmax, Y: ymax},
+			{X: xmin, Y: ymax},
+			{X: xmin, Y: ymin}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return geo.NewContour(polygons)
+}
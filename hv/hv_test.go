@@ -0,0 +1,156 @@
+package hv
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mrrtf/pigiron/mapping"
+	// must include the specific implementation package of the mapping
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+func TestNofSectors(t *testing.T) {
+	for _, tc := range []struct {
+		deid mapping.DEID
+		n    int
+	}{{500, 4}, {504, 2}, {601, 4}, {700, 5}, {701, 6}, {1025, 6}} {
+		m, err := NewMap(mapping.NewSegmentation(tc.deid))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.NofSectors() != tc.n {
+			t.Errorf("DE %d: want %d sectors, got %d", tc.deid, tc.n, m.NofSectors())
+		}
+	}
+}
+
+// forEachSlat loops over the detection elements of stations 3 to 5.
+func forEachSlat(slatHandler func(deid mapping.DEID)) {
+	mapping.ForEachDetectionElement(func(deid mapping.DEID) {
+		if deid >= 500 {
+			slatHandler(deid)
+		}
+	})
+}
+
+func TestEachPadHasOneSector(t *testing.T) {
+	forEachSlat(func(deid mapping.DEID) {
+		seg := mapping.NewSegmentation(deid)
+		m, err := NewMap(seg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for s := 0; s < m.NofSectors(); s++ {
+			npads := 0
+			m.ForEachPadInSector(s, func(paduid mapping.PadUID) {
+				c, _ := m.PadChannel(paduid)
+				if c.Sector != s || c.DEID != deid {
+					t.Errorf("DE %d: pad %d in sector %d has channel %v", deid, paduid, s, c)
+				}
+				npads++
+			})
+			if npads == 0 {
+				t.Errorf("DE %d: sector %d has no pad", deid, s)
+			}
+			n += npads
+		}
+		if n != seg.NofPads() {
+			t.Errorf("DE %d: want %d pads in sectors, got %d", deid, seg.NofPads(), n)
+		}
+	})
+}
+
+func TestDualSampas(t *testing.T) {
+	seg := mapping.NewSegmentation(706)
+	m, _ := NewMap(seg)
+	seen := make(map[mapping.DualSampaID]bool)
+	for s := 0; s < m.NofSectors(); s++ {
+		dsids, err := m.DualSampas(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dsid := range dsids {
+			seen[dsid] = true
+		}
+	}
+	if len(seen) != seg.NofDualSampas() {
+		t.Errorf("want %d dual sampas, got %d", seg.NofDualSampas(), len(seen))
+	}
+	if _, err := m.DualSampas(m.NofSectors()); err != ErrInvalidSector {
+		t.Errorf("want ErrInvalidSector, got %v", err)
+	}
+}
+
+func TestDualSampasAreNotSplit(t *testing.T) {
+	forEachSlat(func(deid mapping.DEID) {
+		seg := mapping.NewSegmentation(deid)
+		m, _ := NewMap(seg)
+		n := 0
+		for s := 0; s < m.NofSectors(); s++ {
+			dsids, _ := m.DualSampas(s)
+			n += len(dsids)
+		}
+		if n != seg.NofDualSampas() {
+			t.Errorf("DE %d: %d dual sampas in sectors, want %d", deid, n, seg.NofDualSampas())
+		}
+	})
+}
+
+func TestPCBBoundaries(t *testing.T) {
+	for _, tc := range []struct {
+		deid mapping.DEID
+		want []float64
+	}{
+		// DE 500 spans [-75,58.57] : its PCBs are not centered on its bbox
+		{500, []float64{-40, 0, 40}},
+		{503, []float64{-20, 20}},
+		{701, []float64{-80, -40, 0, 40, 80}},
+	} {
+		got := pcbBoundaries(mapping.NewSegmentation(tc.deid))
+		if len(got) != len(tc.want) {
+			t.Errorf("DE %d: want boundaries %v, got %v", tc.deid, tc.want, got)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-tc.want[i]) > 1e-3 {
+				t.Errorf("DE %d: want boundaries %v, got %v", tc.deid, tc.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestContour(t *testing.T) {
+	for _, deid := range []mapping.DEID{500, 706, 1025} {
+		seg := mapping.NewSegmentation(deid)
+		m, _ := NewMap(seg)
+		for s := 0; s < m.NofSectors(); s++ {
+			c, err := m.Contour(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.ForEachPadInSector(s, func(paduid mapping.PadUID) {
+				if seg.IsBendingPad(paduid) && !c.Contains(seg.PadPositionX(paduid), seg.PadPositionY(paduid)) {
+					t.Errorf("DE %d: contour of sector %d does not contain pad %d", deid, s, paduid)
+				}
+			})
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	if _, err := NewMap(nil); err != ErrInvalidDEID {
+		t.Errorf("want ErrInvalidDEID, got %v", err)
+	}
+	if _, err := NewMap(mapping.NewSegmentation(100)); err != ErrNotImplemented {
+		t.Errorf("want ErrNotImplemented for a quadrant, got %v", err)
+	}
+	m, _ := NewMap(mapping.NewSegmentation(500))
+	if _, err := m.PadChannel(-1); err == nil {
+		t.Error("want an error for an invalid pad")
+	}
+	if c := (Channel{100, 2}).String(); c != "DE100/Quad/Sect2" {
+		t.Errorf("unexpected channel name %s", c)
+	}
+}
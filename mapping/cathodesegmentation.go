@@ -10,6 +10,9 @@ import (
 // ErrInvalidPadCID signals that an invalid pad uid was used / returned
 var ErrInvalidPadCID = errors.New("invalid pad uid")
 
+// ErrInvalidArea signals an area whose xmin (ymin) is above its xmax (ymax).
+var ErrInvalidArea = errors.New("invalid area (min above max)")
+
 // PadCID is a pad identifier, valid for one cathode only.
 type PadCID int
 
@@ -34,6 +37,7 @@ type CathodeSegmentation interface {
 	FindPadByPosition(x, y float64) (PadCID, error)
 	ForEachPad(padHandler func(padcid PadCID))
 	ForEachPadInDualSampa(dualSampaID DualSampaID, padHandler func(padcid PadCID))
+	ForEachPadInArea(xmin, ymin, xmax, ymax float64, padHandler func(padcid PadCID)) error
 	PadDualSampaChannel(padcid PadCID) DualSampaChannelID
 	PadDualSampaID(padcid PadCID) DualSampaID
 	PadPositionX(padcid PadCID) float64
@@ -214,6 +214,52 @@ func (seg *cathodeSegmentation4) ForEachPadInDualSampa(dsid mapping.DualSampaID,
 	}
 }
 
+// ForEachPadInArea calls padHandler for each pad overlapping
+// the area [xmin,xmax]x[ymin,ymax]. Pads merely touching the area
+// are not considered.
+func (seg *cathodeSegmentation4) ForEachPadInArea(xmin, ymin, xmax, ymax float64, padHandler func(padcid mapping.PadCID)) error {
+	if xmin > xmax || ymin > ymax {
+		return mapping.ErrInvalidArea
+	}
+	area, err := geo.NewBBox(xmin, ymin, xmax, ymax)
+	if err != nil {
+		// empty area
+		return nil
+	}
+	if _, err := geo.Intersect(area, seg.grid.bbox); err != nil {
+		return nil
+	}
+	ixmin, iymin := seg.grid.cellIndices(xmin, ymin)
+	ixmax, iymax := seg.grid.cellIndices(xmax, ymax)
+	for iy := iymin; iy <= iymax; iy++ {
+		for ix := ixmin; ix <= ixmax; ix++ {
+			for _, pgi := range seg.grid.cells[ix+iy*seg.grid.nx] {
+				inter, err := geo.Intersect(area, seg.padGroupBox(pgi))
+				if err != nil {
+					continue
+				}
+				// a pad group spanning several cells is only considered
+				// in the cell of the bottom-left corner of its overlap
+				// with the area
+				if cx, cy := seg.grid.cellIndices(inter.Xmin(), inter.Ymin()); cx != ix || cy != iy {
+					continue
+				}
+				pgt := seg.padGroupTypes[seg.padGroups[pgi].padGroupTypeID]
+				first := seg.padGroupIndex2PadCIDIndex[pgi]
+				for i := first; i < first+pgt.NofPads; i++ {
+					padcid := mapping.PadCID(i)
+					var pxmin, pymin, pxmax, pymax float64
+					mapping.ComputeCathodePadBBox(seg, padcid, &pxmin, &pymin, &pxmax, &pymax)
+					if pxmin < xmax && pxmax > xmin && pymin < ymax && pymax > ymin {
+						padHandler(padcid)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func (seg *cathodeSegmentation4) PadDualSampaChannel(padcid mapping.PadCID) mapping.DualSampaChannelID {
 	return seg.padGroupType(padcid).idByFastIndex(seg.padcid2PadGroupTypeFastIndex[padcid])
 }
@@ -64,6 +64,26 @@ func (g *padGroupGrid) getGridIndices(x, y float64) (int, int, error) {
 	return ix, iy, nil
 }
 
+// cellIndices returns the indices of the cell containing (x,y), (x,y)
+// being clamped to the grid bounding box.
+func (g *padGroupGrid) cellIndices(x, y float64) (int, int) {
+	ix := int(math.Floor((x - g.bbox.Xmin()) / g.gx))
+	iy := int(math.Floor((y - g.bbox.Ymin()) / g.gy))
+	if ix < 0 {
+		ix = 0
+	}
+	if ix >= g.nx {
+		ix = g.nx - 1
+	}
+	if iy < 0 {
+		iy = 0
+	}
+	if iy >= g.ny {
+		iy = g.ny - 1
+	}
+	return ix, iy
+}
+
 func (g *padGroupGrid) getIndices(index int) (int, int) {
 	//index := ix + iy*g.nx
 	iy := index / g.nx
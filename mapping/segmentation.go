@@ -23,6 +23,7 @@ type Segmentation interface {
 	FindPadPairByPosition(x, y float64) (PadUID, PadUID, error)
 	ForEachPad(padHandler func(paduid PadUID))
 	ForEachPadInDualSampa(dualSampaID DualSampaID, padHandler func(paduid PadUID))
+	ForEachPadInArea(xmin, ymin, xmax, ymax float64, padHandler func(paduid PadUID)) error
 	PadDualSampaChannel(paduid PadUID) DualSampaChannelID
 	PadDualSampaID(paduid PadUID) DualSampaID
 	PadPositionX(paduid PadUID) float64
@@ -166,12 +167,22 @@ func (seg *segmentation) ForEachPad(padHandler func(paduid PadUID)) {
 
 func (seg *segmentation) ForEachPadInDualSampa(dualSampaID DualSampaID, padHandler func(paduid PadUID)) {
 	if dualSampaID < 1024 {
-		seg.bending.ForEachPad(f2cuid(padHandler, 0))
+		seg.bending.ForEachPadInDualSampa(dualSampaID, f2cuid(padHandler, 0))
 	} else {
-		seg.bending.ForEachPad(f2cuid(padHandler, seg.padUIDOffset))
+		seg.nonBending.ForEachPadInDualSampa(dualSampaID, f2cuid(padHandler, seg.padUIDOffset))
 	}
 }
 
+// ForEachPadInArea calls padHandler for each pad, of both cathodes,
+// that overlaps the area [xmin,xmax]x[ymin,ymax].
+// It returns ErrInvalidArea if xmin > xmax or ymin > ymax.
+func (seg *segmentation) ForEachPadInArea(xmin, ymin, xmax, ymax float64, padHandler func(paduid PadUID)) error {
+	if err := seg.bending.ForEachPadInArea(xmin, ymin, xmax, ymax, f2cuid(padHandler, 0)); err != nil {
+		return err
+	}
+	return seg.nonBending.ForEachPadInArea(xmin, ymin, xmax, ymax, f2cuid(padHandler, seg.padUIDOffset))
+}
+
 func (seg *segmentation) GetNeighbourIDs(paduid PadUID, neighbours []int) int {
 	cseg, p, err := seg.getCathSeg(paduid)
 	if err != nil {
@@ -186,6 +186,99 @@ func TestForEachPad(t *testing.T) {
 	})
 }
 
+func TestForEachPadInDualSampa(t *testing.T) {
+	mapping.ForOneDetectionElementOfEachSegmentationType(func(deid mapping.DEID) {
+		seg := mapping.NewSegmentation(deid)
+		npads := 0
+		for _, cseg := range []mapping.CathodeSegmentation{seg.Bending(), seg.NonBending()} {
+			for i := 0; i < cseg.NofDualSampas(); i++ {
+				dsid, _ := cseg.DualSampaID(i)
+				seg.ForEachPadInDualSampa(dsid, func(paduid mapping.PadUID) {
+					npads++
+					if seg.PadDualSampaID(paduid) != dsid {
+						t.Errorf("DE %v pad %v does not belong to dual sampa %v", deid, paduid, dsid)
+					}
+				})
+			}
+		}
+		if npads != seg.NofPads() {
+			t.Errorf("DE %v expected %v pads but got %v from ForEachPadInDualSampa loops", deid, seg.NofPads(), npads)
+		}
+	})
+}
+
+func TestForEachPadInArea(t *testing.T) {
+	mapping.ForOneDetectionElementOfEachSegmentationType(func(deid mapping.DEID) {
+		seg := mapping.NewSegmentation(deid)
+		bbox := mapping.ComputeSegmentationBBox(seg)
+		xmin := bbox.Xcenter() - 5.3
+		xmax := bbox.Xcenter() + 7.1
+		ymin := bbox.Ycenter() - 2.2
+		ymax := bbox.Ycenter() + 3.4
+		expected := make(map[mapping.PadUID]bool)
+		seg.ForEachPad(func(paduid mapping.PadUID) {
+			var pxmin, pymin, pxmax, pymax float64
+			mapping.ComputePadBBox(seg, paduid, &pxmin, &pymin, &pxmax, &pymax)
+			if pxmin < xmax && pxmax > xmin && pymin < ymax && pymax > ymin {
+				expected[paduid] = true
+			}
+		})
+		n := 0
+		err := seg.ForEachPadInArea(xmin, ymin, xmax, ymax, func(paduid mapping.PadUID) {
+			n++
+			if !expected[paduid] {
+				t.Errorf("DE %d pad %d is not in area", deid, paduid)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(expected) {
+			t.Errorf("DE %d expected %d pads in area but got %d", deid, len(expected), n)
+		}
+	})
+}
+
+func TestForEachPadInLargeArea(t *testing.T) {
+	// an area larger than the detection element, spanning all the cells
+	// of the grid, must give each pad exactly once
+	mapping.ForOneDetectionElementOfEachSegmentationType(func(deid mapping.DEID) {
+		seg := mapping.NewSegmentation(deid)
+		bbox := mapping.ComputeSegmentationBBox(seg)
+		seen := make(map[mapping.PadUID]int)
+		err := seg.ForEachPadInArea(bbox.Xmin()-10, bbox.Ymin()-10, bbox.Xmax()+10, bbox.Ymax()+10, func(paduid mapping.PadUID) {
+			seen[paduid]++
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(seen) != seg.NofPads() {
+			t.Errorf("DE %d expected %d pads but got %d", deid, seg.NofPads(), len(seen))
+		}
+		for paduid, n := range seen {
+			if n != 1 {
+				t.Errorf("DE %d pad %d seen %d times", deid, paduid, n)
+			}
+		}
+	})
+}
+
+func TestForEachPadInInvalidArea(t *testing.T) {
+	seg := mapping.NewSegmentation(100)
+	handler := func(paduid mapping.PadUID) {
+		t.Errorf("no pad expected")
+	}
+	if err := seg.ForEachPadInArea(10, 0, 5, 10, handler); err != mapping.ErrInvalidArea {
+		t.Errorf("expected ErrInvalidArea and got %v", err)
+	}
+	if err := seg.ForEachPadInArea(0, 10, 10, 5, handler); err != mapping.ErrInvalidArea {
+		t.Errorf("expected ErrInvalidArea and got %v", err)
+	}
+	if err := seg.ForEachPadInArea(5, 0, 5, 10, handler); err != nil {
+		t.Errorf("expected no error for an empty area and got %v", err)
+	}
+}
+
 func checkSameCathode(seg mapping.Segmentation, paduid mapping.PadUID, nei []int) bool {
 
 	for _, n := range nei {
@@ -0,0 +1,97 @@
+package mathieson
+
+import (
+	"errors"
+	"math"
+
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// Mathieson describes the spatial distribution of the charge induced
+// on the cathode planes by an avalanche on an anode wire.
+//
+// The distribution is expressed in units of the anode-cathode distance (pitch)
+// and, along each direction, is parametrized by a single parameter (sqrtK3).
+// The other parameters (K1, K2, K4) are derived from it.
+type Mathieson struct {
+	Pitch   float64 // anode-cathode distance, in cm
+	SqrtK3X float64
+	SqrtK3Y float64
+	k2x     float64
+	k4x     float64
+	k2y     float64
+	k4y     float64
+}
+
+// ErrInvalidDEID signals an unknown detection element.
+var ErrInvalidDEID = errors.New("invalid detection element id")
+
+// New returns a Mathieson distribution with the given parameters.
+func New(pitch, sqrtK3x, sqrtK3y float64) Mathieson {
+	m := Mathieson{Pitch: pitch, SqrtK3X: sqrtK3x, SqrtK3Y: sqrtK3y}
+	m.k2x, m.k4x = derivedParameters(sqrtK3x)
+	m.k2y, m.k4y = derivedParameters(sqrtK3y)
+	return m
+}
+
+func derivedParameters(sqrtK3 float64) (k2, k4 float64) {
+	k2 = math.Pi / 2 * (1 - sqrtK3/2)
+	k1 := k2 * sqrtK3 / (4 * math.Atan(sqrtK3))
+	k4 = k1 / k2 / sqrtK3
+	return k2, k4
+}
+
+var (
+	// Station1 is the Mathieson distribution for the chambers of station 1.
+	Station1 = New(0.21, 0.7000, 0.7550)
+	// Station2345 is the Mathieson distribution for the chambers of stations 2 to 5.
+	Station2345 = New(0.25, 0.7131, 0.7642)
+)
+
+// ForDetectionElement returns the Mathieson distribution
+// to be used for the given detection element.
+func ForDetectionElement(deid mapping.DEID) (Mathieson, error) {
+	chamber := int(deid) / 100
+	if chamber < 1 || chamber > 10 {
+		return Mathieson{}, ErrInvalidDEID
+	}
+	if chamber <= 2 {
+		return Station1, nil
+	}
+	return Station2345, nil
+}
+
+func primitive(u, k2, sqrtK3, k4 float64) float64 {
+	return 2 * k4 * math.Atan(sqrtK3*math.Tanh(k2*u))
+}
+
+// IntegrateX returns the fraction of the charge, along the x direction,
+// that is contained within [x1,x2]. x1 and x2 are relative to the center
+// of the distribution and are expressed in cm.
+func (m Mathieson) IntegrateX(x1, x2 float64) float64 {
+	return primitive(x2/m.Pitch, m.k2x, m.SqrtK3X, m.k4x) -
+		primitive(x1/m.Pitch, m.k2x, m.SqrtK3X, m.k4x)
+}
+
+// IntegrateY returns the fraction of the charge, along the y direction,
+// that is contained within [y1,y2]. y1 and y2 are relative to the center
+// of the distribution and are expressed in cm.
+func (m Mathieson) IntegrateY(y1, y2 float64) float64 {
+	return primitive(y2/m.Pitch, m.k2y, m.SqrtK3Y, m.k4y) -
+		primitive(y1/m.Pitch, m.k2y, m.SqrtK3Y, m.k4y)
+}
+
+// Integrate returns the fraction of the total charge contained within
+// the rectangle [x1,x2]x[y1,y2] (relative to the center of the distribution,
+// in cm).
+func (m Mathieson) Integrate(x1, y1, x2, y2 float64) float64 {
+	return m.IntegrateX(x1, x2) * m.IntegrateY(y1, y2)
+}
+
+// IntegratePad returns the fraction of the charge of a distribution
+// centered at (x0,y0) that is seen by one pad of the segmentation.
+func (m Mathieson) IntegratePad(padps mapping.PadSizerPositioner, paduid mapping.PadUID, x0, y0 float64) float64 {
+	var xmin, ymin, xmax, ymax float64
+	mapping.ComputePadBBox(padps, paduid, &xmin, &ymin, &xmax, &ymax)
+	return m.Integrate(xmin-x0, ymin-y0, xmax-x0, ymax-y0)
+}
@@ -0,0 +1,61 @@
+package mathieson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func TestIntegralOverEverythingIsOne(t *testing.T) {
+	for _, m := range []Mathieson{Station1, Station2345} {
+		if v := m.Integrate(-100, -100, 100, 100); !geo.EqualFloat(v, 1) {
+			t.Errorf("expected total integral of 1 and got %v", v)
+		}
+	}
+}
+
+func TestIntegralIsSymmetric(t *testing.T) {
+	m := Station2345
+	a := m.Integrate(-1, -0.5, 0, 0)
+	b := m.Integrate(0, 0, 1, 0.5)
+	if !geo.EqualFloat(a, b) {
+		t.Errorf("expected symmetric integrals, got %v and %v", a, b)
+	}
+	if !geo.EqualFloat(m.IntegrateX(-1, 1), 2*m.IntegrateX(0, 1)) {
+		t.Errorf("expected IntegrateX(-1,1) = 2*IntegrateX(0,1)")
+	}
+}
+
+func TestIntegralIsAdditive(t *testing.T) {
+	m := Station1
+	whole := m.IntegrateY(-0.3, 0.8)
+	parts := m.IntegrateY(-0.3, 0.2) + m.IntegrateY(0.2, 0.8)
+	if math.Abs(whole-parts) > 1e-12 {
+		t.Errorf("expected additive integrals, got %v and %v", whole, parts)
+	}
+}
+
+func TestForDetectionElement(t *testing.T) {
+	for _, test := range []struct {
+		deid     mapping.DEID
+		expected Mathieson
+	}{
+		{100, Station1},
+		{203, Station1},
+		{300, Station2345},
+		{1025, Station2345},
+	} {
+		m, err := ForDetectionElement(test.deid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != test.expected {
+			t.Errorf("DE %d : wrong Mathieson parameters %v", test.deid, m)
+		}
+	}
+	if _, err := ForDetectionElement(1100); err != ErrInvalidDEID {
+		t.Errorf("expected ErrInvalidDEID and got %v", err)
+	}
+}
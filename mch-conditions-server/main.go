@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/mrrtf/pigiron/conditions"
+	"github.com/spf13/viper"
+)
+
+func handler(s *conditions.Store) http.Handler {
+	r := http.NewServeMux()
+	r.HandleFunc("/", usage())
+	h := conditions.Handler(s)
+	r.Handle("/objects/", h)
+	r.Handle("/list/", h)
+	return r
+}
+
+func main() {
+	viper.SetEnvPrefix("MCH")
+	viper.BindEnv("CONDITIONS_HOST")
+	viper.BindEnv("CONDITIONS_PORT")
+	viper.BindEnv("CONDITIONS_DIR")
+	// objects can be stored through the server, so only listen
+	// locally unless told otherwise
+	viper.SetDefault("CONDITIONS_HOST", "localhost")
+	viper.SetDefault("CONDITIONS_PORT", 8081)
+	viper.SetDefault("CONDITIONS_DIR", "conditions")
+	host := viper.GetString("CONDITIONS_HOST")
+	port := viper.GetInt("CONDITIONS_PORT")
+	dir := viper.GetString("CONDITIONS_DIR")
+	s, err := conditions.Open(dir)
+	if err != nil {
+		panic(err)
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	fmt.Println("Started server to serve conditions from", dir, "on", addr)
+	if err := http.ListenAndServe(addr, handler(s)); err != nil {
+		panic(err)
+	}
+}
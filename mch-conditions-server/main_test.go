@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/conditions"
+)
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conditions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := conditions.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler(s)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/objects/MCH/Calib?start=1", strings.NewReader("hello")))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("put: want status %d, got %d", http.StatusCreated, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/objects/MCH/Calib?t=42", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "hello" {
+		t.Errorf("get: unexpected answer %d %q", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Valid-From") != "1" {
+		t.Errorf("want Valid-From 1, got %s", rr.Header().Get("Valid-From"))
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/objects/MCH/Calib", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("get without time: want status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/objects/MCH/Calib?t=0", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("get before validity: want status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/list/MCH/Calib", nil))
+	var objects []conditions.ObjectInfo
+	if err := json.NewDecoder(rr.Body).Decode(&objects); err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Version != 1 {
+		t.Errorf("unexpected list %v", objects)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rr.Body.String(), "Conditions") {
+		t.Error("usage message expected")
+	}
+}
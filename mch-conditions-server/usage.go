@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func usage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, usageMsg)
+	}
+}
+
+const usageMsg = `<h1>MCH Conditions Server</h1>
+
+<p>This server gives access to conditions objects (mapping corrections, alignment,
+calibrations, bad channel maps...) stored in a local directory, each with a validity
+range expressed in run numbers or timestamps.</p>
+
+<h2>Object valid at a given time</h2>
+
+<pre>GET /objects/[path]?t=[integer]</pre>
+
+<p>Returns the content of the object of the given path (e.g. MCH/Calib/BadChannel)
+valid at time (or run) t. When several objects are valid, the most recently stored one is returned.
+The validity and version of the object are given by the Valid-From, Valid-Until and Version headers.</p>
+
+<h2>Store an object</h2>
+
+<pre>PUT /objects/[path]?start=[integer](&end=[integer])</pre>
+
+<p>Stores the request body as a new version of the object, valid for [start,end).
+Without end the validity is not bounded. The body is limited to 64 MB.</p>
+
+<h2>List objects</h2>
+
+<pre>GET /list/[path]</pre>
+
+<p>Returns the validity and version of all the objects of the given path.</p>
+`
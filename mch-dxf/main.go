@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/segcontour"
+
+	// must include the specific implementation package of the mapping
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+const usageMsg = `Usage: mch-dxf [options] -deid [number]
+
+Exports the segmentation of one cathode of a detection element to a DXF file
+(in millimetres, local detection element coordinates), with one layer for the
+detection element contour (DE[deid]), one layer per dual sampa (DE[deid]_DS[dsid])
+and one layer for the pads (DE[deid]_PADS).
+
+Options:
+`
+
+var ErrInvalidDEID = errors.New("invalid detection element id")
+
+// export writes the DXF of one cathode of a detection element.
+func export(out io.Writer, deid int, bending, pads bool) error {
+	cseg := mapping.NewCathodeSegmentation(mapping.DEID(deid), bending)
+	if cseg == nil {
+		return ErrInvalidDEID
+	}
+	w := geo.NewDXFWriter()
+	segcontour.DXF(cseg, w, pads)
+	return w.Write(out)
+}
+
+func main() {
+	deid := flag.Int("deid", 0, "detection element id")
+	bending := flag.Bool("bending", true, "export the bending (true) or non-bending (false) cathode")
+	pads := flag.Bool("pads", true, "include the pads")
+	output := flag.String("o", "", "output file (default DE[deid]-[B|NB].dxf)")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usageMsg)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *deid == 0 || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *output == "" {
+		plane := "B"
+		if !*bending {
+			plane = "NB"
+		}
+		*output = fmt.Sprintf("DE%d-%s.dxf", *deid, plane)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := export(f, *deid, *bending, *pads); err != nil {
+		f.Close()
+		os.Remove(*output)
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
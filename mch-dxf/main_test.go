@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/segcontour"
+)
+
+func TestExport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export(&buf, 501, false, false); err != nil {
+		t.Fatal(err)
+	}
+	layers, err := geo.ReadDXF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := layers["DE501"]; !ok {
+		t.Errorf("missing detection element layer")
+	}
+	if _, ok := layers["DE501_PADS"]; ok {
+		t.Errorf("pads not expected")
+	}
+	// coordinates are in millimetres
+	want := segcontour.BBox(mapping.NewCathodeSegmentation(501, false)).Width() * 10
+	if b := layers["DE501"].BBox(); math.Abs(b.Width()-want) > 1e-6 {
+		t.Errorf("want a %v mm wide contour, got %v", want, b.Width())
+	}
+}
+
+func TestExportInvalidDEID(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export(&buf, 42, true, true); err != ErrInvalidDEID {
+		t.Errorf("want ErrInvalidDEID, got %v", err)
+	}
+}
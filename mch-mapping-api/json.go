@@ -74,6 +74,12 @@ func jsonDualSampaPads(w io.Writer, seg mapping.Segmentation, dsid int) {
 }
 
 func jsonDualSampas(w io.Writer, cseg mapping.CathodeSegmentation, bending bool) {
+	jsonDualSampaValues(w, cseg, nil)
+}
+
+// jsonDualSampaValues writes the outline of all the dual sampas of a
+// cathode, together with a value (e.g. an occupancy) for each of them.
+func jsonDualSampaValues(w io.Writer, cseg mapping.CathodeSegmentation, values map[mapping.DualSampaID]float64) {
 
 	de := DE{}
 	var dualSampas []DualSampa
@@ -85,7 +91,7 @@ func jsonDualSampas(w io.Writer, cseg mapping.CathodeSegmentation, bending bool)
 			panic(err)
 		}
 
-		ds := DualSampa{ID: int(dsid)}
+		ds := DualSampa{ID: int(dsid), Value: values[dsid]}
 
 		dsContour := segcontour.GetDualSampaContour(cseg, dsid)
 		for _, c := range dsContour {
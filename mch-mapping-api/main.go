@@ -11,6 +11,7 @@ import (
 
 	"github.com/mrrtf/pigiron/mapping"
 	v2 "github.com/mrrtf/pigiron/mch-mapping-api/v2"
+	"github.com/mrrtf/pigiron/occupancy"
 	"github.com/spf13/viper"
 
 	// must include the specific implementation package of the mapping
@@ -95,23 +96,36 @@ func deGeo(w http.ResponseWriter, r *http.Request, deid int, bending bool) {
 	jsonDEGeo(w, cseg, bending)
 }
 
-func handler() http.Handler {
+func handler(occ *occupancy.Result) http.Handler {
 	r := http.NewServeMux()
 	r.HandleFunc("/", usage())
 	bendingIsRequired := true
 	r.HandleFunc("/dualsampas", makeHandler(dualSampas, bendingIsRequired))
 	r.HandleFunc("/v2/dualsampas", makeHandler(v2.DualSampas, bendingIsRequired))
 	r.HandleFunc("/degeo", makeHandler(deGeo, bendingIsRequired))
+	r.HandleFunc("/v2/png", makeHandler(v2.PNG, bendingIsRequired))
+	r.HandleFunc("/v2/svg", makeHandler(v2.SVG, bendingIsRequired))
+	r.HandleFunc("/v2/chamber", v2.Chamber)
+	r.HandleFunc("/v2/geojson/de", makeHandler(v2.GeoJSONDE, bendingIsRequired))
+	r.HandleFunc("/v2/geojson/dualsampas", makeHandler(v2.GeoJSONDualSampas, bendingIsRequired))
+	r.HandleFunc("/v2/geojson/pads", makeHandler(v2.GeoJSONPads, bendingIsRequired))
+	r.HandleFunc("/v2/tiles/", v2.Tiles)
+	r.HandleFunc("/v2/occupancy", makeHandler(occupancyHandler(occ), bendingIsRequired))
 	return r
 }
 
 func main() {
 	viper.SetEnvPrefix("MCH")
 	viper.BindEnv("MAPPING_API_PORT")
+	viper.BindEnv("OCCUPANCY_FILE")
 	viper.SetDefault("MAPPING_API_PORT", 8080)
 	port := viper.GetInt("MAPPING_API_PORT")
+	occ, err := readOccupancy(viper.GetString("OCCUPANCY_FILE"))
+	if err != nil {
+		panic(err)
+	}
 	fmt.Println("Started server to listen on port", port)
-	if err := http.ListenAndServe(":"+strconv.Itoa(port), handler()); err != nil {
+	if err := http.ListenAndServe(":"+strconv.Itoa(port), handler(occ)); err != nil {
 		panic(err)
 	}
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/occupancy"
+)
+
+var ErrNoOccupancy = errors.New("No occupancy data available")
+
+// readOccupancy reads an occupancy file, if any.
+func readOccupancy(filename string) (*occupancy.Result, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return occupancy.ReadJSON(f)
+}
+
+func occupancyHandler(occ *occupancy.Result) func(w http.ResponseWriter, r *http.Request, deid int, bending bool) {
+	return func(w http.ResponseWriter, r *http.Request, deid int, bending bool) {
+		if occ == nil {
+			http.Error(w, ErrNoOccupancy.Error(), http.StatusNotFound)
+			return
+		}
+		cseg := mapping.NewCathodeSegmentation(mapping.DEID(deid), bending)
+		jsonDualSampaValues(w, cseg, occ.DualSampaRates(mapping.DEID(deid)))
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/occupancy"
+)
+
+func TestOccupancyEndPoint(t *testing.T) {
+	c := occupancy.NewCounter()
+	seg := mapping.NewSegmentation(706)
+	seg.ForEachPadInDualSampa(3, func(paduid mapping.PadUID) {
+		c.Add(digit.Digit{DEID: 706, PadUID: paduid})
+	})
+	occ, err := c.Result(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/v2/occupancy?deid=706&bending=true", nil)
+	makeHandler(occupancyHandler(occ), true)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d. Got %d", http.StatusOK, rec.Code)
+	}
+	var de DE
+	if err := json.NewDecoder(rec.Body).Decode(&de); err != nil {
+		t.Fatal(err)
+	}
+	for _, ds := range de.DualSampas {
+		if (ds.ID == 3) != (ds.Value > 0) {
+			t.Errorf("Unexpected value %v for dual sampa %d", ds.Value, ds.ID)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	makeHandler(occupancyHandler(nil), true)(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d without data. Got %d", http.StatusNotFound, rec.Code)
+	}
+}
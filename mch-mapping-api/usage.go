@@ -28,6 +28,62 @@ const usageMsg = `<h1>MCH Mapping API</h1>
 <p>Returns the vertices of the polygons describing the outline of all the dual sampas 
 of a given detection element plane</p>
 
+<h2>Detection element plane image</h2>
+
+<pre>/v2/png?deid=[number]&bending=[true|false](&width=[number])</pre>
+
+<p>Returns a PNG image (of the given width in pixels, 1024 by default) of the pads
+and dual sampas of a given detection element plane</p>
+
+<h2>Detection element plane drawing</h2>
+
+<pre>/v2/svg?deid=[number]&bending=[true|false](&show=[pads,dualsampas,de,channels,dsids,title])(&width=[number])</pre>
+
+<p>Returns a SVG drawing of the selected layers (by default pads, dualsampas and de)
+of a given detection element plane, or a HTML page embedding it if the
+Accept header prefers text/html.</p>
+
+<p>Per pad values can be POSTed as a JSON array of {"DSID":[number],"DSCH":[number],"Value":[number]}
+objects, in which case the pads are drawn as a heat map.</p>
+
+<h2>Chamber drawings</h2>
+
+<pre>/v2/chamber?chamber=[1..10|all]&bending=[true|false](&format=[json|svg|html])</pre>
+
+<p>Returns the contours of the detection elements (and of their dual sampas) of one chamber,
+in global coordinates, or of all the chambers arranged in a grid of 5 stations by 2 chambers.
+The default format is a JSON array of {"deid","bending","contour","dualsampas"} objects,
+svg and html giving a drawing where each detection element is labelled with its id.</p>
+
+<h2>GeoJSON</h2>
+
+<pre>/v2/geojson/de?deid=[number]&bending=[true|false]
+/v2/geojson/dualsampas?deid=[number]&bending=[true|false]
+/v2/geojson/pads?deid=[number]&bending=[true|false]</pre>
+
+<p>Returns a GeoJSON FeatureCollection (in local detection element coordinates, in cm)
+of the contour of the detection element plane (one MultiPolygon, with DEID and Bending properties),
+of its dual sampas (one MultiPolygon per dual sampa, with an extra DSID property)
+or of its pads (one Polygon per pad, with extra DSID, DSCH, X, Y, SX and SY properties).</p>
+
+<h2>Vector tiles</h2>
+
+<pre>/v2/tiles/{z}/{x}/{y}.mvt?bending=[true|false]</pre>
+
+<p>Returns a Mapbox Vector Tile (0 &lt;= z &lt;= 12) of the whole spectrometer, with the ten chambers
+laid out as in /v2/chamber?chamber=all (bending plane by default).
+The tiles have a detectionelements layer (DEID and Chamber properties) at all zoom levels,
+a dualsampas layer (DEID and DSID properties) from zoom 3
+and a pads layer (DEID, DSID and DSCH properties) from zoom 6.</p>
+
+<h2>Dual sampa occupancies</h2>
+
+<pre>/v2/occupancy?deid=[number]&bending=[true|false]</pre>
+
+<p>Same as /dualsampas, with the Value of each dual sampa being its occupancy
+(number of digits per cm2 and per second), as read from the occupancy file
+given by the MCH_OCCUPANCY_FILE environment variable.</p>
+
 <h2>Pads in area</h2>
 
 <pre>/padsinarea?deid=[integer](&bending=[true|false])&xmin=[float]&ymin=[float]&xmax=[float]&ymax=[float]</pre>
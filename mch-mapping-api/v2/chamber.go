@@ -0,0 +1,93 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/segcontour"
+)
+
+var (
+	ErrInvalidChamber = errors.New("chamber should be an integer between 1 and 10, or all")
+	ErrInvalidBending = errors.New("bending should be true or false")
+	ErrInvalidFormat  = errors.New("format should be svg, html or json")
+)
+
+const chamberStyle = `
+.detectionelements polygon { fill: #f4f4f4; fill-opacity: 0.5; stroke: #000; stroke-width: 0.5; }
+.dualsampas polygon { fill: none; stroke: #36c; stroke-width: 0.2; }
+.delabel, .chamberlabel { font-family: sans-serif; fill: #333; }
+`
+
+// Chamber returns the outlines of the detection elements of one chamber
+// (chamber=[1..10]), or of all of them (chamber=all) arranged in a grid,
+// as JSON, SVG or HTML (format=[json|svg|html]).
+func Chamber(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	q := r.URL.Query()
+	bending, err := strconv.ParseBool(q.Get("bending"))
+	if err != nil {
+		http.Error(w, ErrInvalidBending.Error(), http.StatusBadRequest)
+		return
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "svg" && format != "html" {
+		http.Error(w, ErrInvalidFormat.Error(), http.StatusBadRequest)
+		return
+	}
+	all := q.Get("chamber") == "all"
+	var chamber int
+	if !all {
+		chamber, err = strconv.Atoi(q.Get("chamber"))
+		if err != nil || chamber < 1 || chamber > 10 {
+			http.Error(w, ErrInvalidChamber.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if format == "json" {
+		var outlines []segcontour.DEOutline
+		if all {
+			chambers, _, err := segcontour.SpectrometerOutlines(bending)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, c := range chambers {
+				outlines = append(outlines, c...)
+			}
+		} else {
+			outlines, err = segcontour.ChamberOutlines(chamber, bending)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		w.Header().Set("Content-type", "application/json")
+		segcontour.WriteJSON(w, outlines)
+		return
+	}
+	svg := geo.NewSVGWriter(1024)
+	svg.Style(chamberStyle)
+	if all {
+		err = segcontour.SVGSpectrometer(bending, svg)
+	} else {
+		err = segcontour.SVGChamber(chamber, bending, svg)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	svg.MoveToOrigin()
+	if format == "html" {
+		w.Header().Set("Content-type", "text/html")
+		svg.WriteHTML(w)
+		return
+	}
+	w.Header().Set("Content-type", "image/svg+xml")
+	svg.WriteSVGDocument(w)
+}
@@ -0,0 +1,52 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/segcontour"
+)
+
+func TestChamberJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Chamber(rec, httptest.NewRequest("GET", "/v2/chamber?chamber=3&bending=false", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d. Got %d", http.StatusOK, rec.Code)
+	}
+	var outlines []segcontour.DEOutline
+	if err := json.NewDecoder(rec.Body).Decode(&outlines); err != nil {
+		t.Fatal(err)
+	}
+	if len(outlines) != 4 || outlines[0].DEID != 300 || outlines[0].Bending {
+		t.Errorf("unexpected outlines")
+	}
+}
+
+func TestChamberSVG(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Chamber(rec, httptest.NewRequest("GET", "/v2/chamber?chamber=6&bending=true&format=svg", nil))
+	if ct := rec.Header().Get("Content-type"); ct != "image/svg+xml" {
+		t.Errorf("Expected image/svg+xml content type. Got %s", ct)
+	}
+	if n := strings.Count(rec.Body.String(), `class="delabel"`); n != 18 {
+		t.Errorf("Expected 18 detection element labels. Got %d", n)
+	}
+}
+
+func TestChamberBadRequests(t *testing.T) {
+	for _, q := range []string{
+		"chamber=1",
+		"chamber=0&bending=true",
+		"chamber=x&bending=true",
+		"chamber=1&bending=true&format=pdf",
+	} {
+		rec := httptest.NewRecorder()
+		Chamber(rec, httptest.NewRequest("GET", "/v2/chamber?"+q, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d. Got %d", q, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
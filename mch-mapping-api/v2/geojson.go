@@ -0,0 +1,79 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/segcontour"
+)
+
+// writeGeoJSON outputs a feature collection, with the bounding box
+// of the cathode.
+func writeGeoJSON(w http.ResponseWriter, cseg mapping.CathodeSegmentation, features []geo.GeoJSONFeature) {
+	fc := geo.NewGeoJSONFeatureCollection(features)
+	fc.BBox = geo.GeoJSONBBox(mapping.ComputeBBox(cseg))
+	w.Header().Set("Content-type", "application/geo+json")
+	if err := json.NewEncoder(w).Encode(fc); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GeoJSONDE returns the contour of a detection element plane
+// as a GeoJSON feature collection with a single MultiPolygon feature.
+func GeoJSONDE(w http.ResponseWriter, r *http.Request, deid int, bending bool) {
+	cseg := mapping.NewCathodeSegmentation(mapping.DEID(deid), bending)
+	contour := segcontour.Contour(cseg)
+	writeGeoJSON(w, cseg, []geo.GeoJSONFeature{
+		geo.NewGeoJSONFeature(contour.GeoJSON(), map[string]interface{}{
+			"DEID":    deid,
+			"Bending": bending,
+		}),
+	})
+}
+
+// GeoJSONDualSampas returns the contours of the dual sampas of a
+// detection element plane as a GeoJSON feature collection.
+func GeoJSONDualSampas(w http.ResponseWriter, r *http.Request, deid int, bending bool) {
+	cseg := mapping.NewCathodeSegmentation(mapping.DEID(deid), bending)
+	features := make([]geo.GeoJSONFeature, 0, cseg.NofDualSampas())
+	for i := 0; i < cseg.NofDualSampas(); i++ {
+		dsid, err := cseg.DualSampaID(i)
+		if err != nil {
+			panic(err)
+		}
+		contour := segcontour.GetDualSampaContour(cseg, dsid)
+		features = append(features, geo.NewGeoJSONFeature(contour.GeoJSON(), map[string]interface{}{
+			"DEID":    deid,
+			"Bending": bending,
+			"DSID":    int(dsid),
+		}))
+	}
+	writeGeoJSON(w, cseg, features)
+}
+
+// GeoJSONPads returns the pads of a detection element plane
+// as a GeoJSON feature collection of polygons.
+func GeoJSONPads(w http.ResponseWriter, r *http.Request, deid int, bending bool) {
+	cseg := mapping.NewCathodeSegmentation(mapping.DEID(deid), bending)
+	features := make([]geo.GeoJSONFeature, 0, cseg.NofPads())
+	cseg.ForEachPad(func(padcid mapping.PadCID) {
+		x := cseg.PadPositionX(padcid)
+		y := cseg.PadPositionY(padcid)
+		sx := cseg.PadSizeX(padcid)
+		sy := cseg.PadSizeY(padcid)
+		p := segcontour.RectanglePolygon(x, y, sx, sy)
+		features = append(features, geo.NewGeoJSONFeature(p.GeoJSON(), map[string]interface{}{
+			"DEID":    deid,
+			"Bending": bending,
+			"DSID":    int(cseg.PadDualSampaID(padcid)),
+			"DSCH":    int(cseg.PadDualSampaChannel(padcid)),
+			"X":       x,
+			"Y":       y,
+			"SX":      sx,
+			"SY":      sy,
+		}))
+	})
+	writeGeoJSON(w, cseg, features)
+}
@@ -0,0 +1,77 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func getGeoJSON(t *testing.T, fn func(w http.ResponseWriter, r *http.Request, deid int, bending bool), deid int, bending bool) geo.GeoJSONFeatureCollection {
+	rec := httptest.NewRecorder()
+	fn(rec, httptest.NewRequest("GET", "/v2/geojson", nil), deid, bending)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d. Got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-type"); ct != "application/geo+json" {
+		t.Errorf("Expected application/geo+json content type. Got %s", ct)
+	}
+	var fc geo.GeoJSONFeatureCollection
+	if err := json.NewDecoder(rec.Body).Decode(&fc); err != nil {
+		t.Fatal(err)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.BBox) != 4 {
+		t.Errorf("Not a feature collection with bbox")
+	}
+	return fc
+}
+
+func TestGeoJSONDE(t *testing.T) {
+	fc := getGeoJSON(t, GeoJSONDE, 100, true)
+	if len(fc.Features) != 1 {
+		t.Fatalf("Expected 1 feature. Got %d", len(fc.Features))
+	}
+	f := fc.Features[0]
+	if f.Geometry.Type != "MultiPolygon" || f.Properties["DEID"] != 100.0 || f.Properties["Bending"] != true {
+		t.Errorf("Unexpected feature %v", f.Properties)
+	}
+	if _, err := f.Geometry.Contour(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGeoJSONDualSampas(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, false)
+	fc := getGeoJSON(t, GeoJSONDualSampas, 706, false)
+	if len(fc.Features) != cseg.NofDualSampas() {
+		t.Errorf("Expected %d features. Got %d", cseg.NofDualSampas(), len(fc.Features))
+	}
+	if _, ok := fc.Features[0].Properties["DSID"]; !ok {
+		t.Errorf("Missing DSID property")
+	}
+}
+
+func TestGeoJSONPads(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, true)
+	fc := getGeoJSON(t, GeoJSONPads, 706, true)
+	if len(fc.Features) != cseg.NofPads() {
+		t.Fatalf("Expected %d features. Got %d", cseg.NofPads(), len(fc.Features))
+	}
+	f := fc.Features[0]
+	for _, k := range []string{"DEID", "DSID", "DSCH", "X", "Y", "SX", "SY"} {
+		if _, ok := f.Properties[k]; !ok {
+			t.Errorf("Missing %s property", k)
+		}
+	}
+	p, err := f.Geometry.Polygon()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := p.BBox()
+	if b.Width() != f.Properties["SX"] || b.Height() != f.Properties["SY"] {
+		t.Errorf("Pad polygon %v does not match its size", b)
+	}
+}
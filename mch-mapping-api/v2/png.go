@@ -0,0 +1,56 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/segcontour"
+)
+
+const maxImageWidth = 8192
+
+var ErrInvalidWidth = errors.New("width should be an integer between 1 and 8192")
+
+const pngStyle = `
+.pads { fill: #f4f4f4; stroke: #bbb; stroke-width: 0.02; }
+.detectionelements { fill: none; stroke: #000; stroke-width: 0.3; }
+.dualsampas { fill: none; stroke: #36c; stroke-width: 0.15; }
+`
+
+// imageWidth returns the width (in pixels) requested by the width
+// query parameter, if any.
+func imageWidth(r *http.Request, def int) (int, error) {
+	q := r.URL.Query()
+	if _, ok := q["width"]; !ok {
+		return def, nil
+	}
+	width, err := strconv.Atoi(q.Get("width"))
+	if err != nil || width < 1 || width > maxImageWidth {
+		return 0, ErrInvalidWidth
+	}
+	return width, nil
+}
+
+// PNG returns a PNG image of the pads and dual sampas of a detection element plane.
+func PNG(w http.ResponseWriter, r *http.Request, deid int, bending bool) {
+	width, err := imageWidth(r, 1024)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cseg := mapping.NewCathodeSegmentation(mapping.DEID(deid), bending)
+	bbox := mapping.ComputeBBox(cseg)
+	height := int(float64(width) * bbox.Height() / bbox.Width())
+	if height < 1 {
+		height = 1
+	}
+	rz := geo.NewRasterizer(width, height)
+	rz.ViewBox(bbox.Xmin(), bbox.Xmax(), bbox.Ymin(), bbox.Ymax())
+	rz.Style(pngStyle)
+	segcontour.SVGSegmentation(cseg, rz, segcontour.ShowFlags{Pads: true, DEs: true, DualSampas: true})
+	w.Header().Set("Content-type", "image/png")
+	rz.WritePNG(w)
+}
@@ -0,0 +1,36 @@
+package v2
+
+import (
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPNG(t *testing.T) {
+	rec := httptest.NewRecorder()
+	PNG(rec, httptest.NewRequest("GET", "/v2/png?deid=706&bending=true&width=200", nil), 706, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d. Got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-type"); ct != "image/png" {
+		t.Errorf("Expected image/png content type. Got %s", ct)
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Errorf("Expected a 200x100 image. Got %v", b)
+	}
+}
+
+func TestPNGInvalidWidth(t *testing.T) {
+	for _, width := range []string{"x", "0", "100000"} {
+		rec := httptest.NewRecorder()
+		PNG(rec, httptest.NewRequest("GET", "/v2/png?width="+width, nil), 706, true)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("width=%s: expected status code %d. Got %d", width, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
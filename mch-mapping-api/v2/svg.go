@@ -0,0 +1,156 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/segcontour"
+)
+
+var (
+	ErrInvalidShow      = errors.New("show should be a comma separated list of pads, dualsampas, de, channels, dsids or title")
+	ErrInvalidPadValues = errors.New("pad values should be a JSON array of {\"DSID\":[number],\"DSCH\":[number],\"Value\":[number]}")
+	ErrUnknownPad       = errors.New("no pad found for the given DSID and DSCH")
+)
+
+const svgStyle = `
+.pads polygon { fill: #f4f4f4; stroke: #bbb; stroke-width: 0.02; }
+.heatmap polygon { stroke: #bbb; stroke-width: 0.02; }
+.detectionelements polygon { fill: none; stroke: #000; stroke-width: 0.3; }
+.dualsampas polygon { fill: none; stroke: #36c; stroke-width: 0.15; }
+.padchannel, .dualsampaid, .detitle { font-family: sans-serif; fill: #333; }
+`
+
+// PadValue is the value of one pad, identified by its dual sampa id
+// and channel, as posted to the svg endpoint to get a heat map.
+type PadValue struct {
+	DSID  int     `json:"DSID"`
+	DSCH  int     `json:"DSCH"`
+	Value float64 `json:"Value"`
+}
+
+// maxPadValueSize is the (generous) size in bytes of one encoded PadValue.
+const maxPadValueSize = 128
+
+// maxPadValuesSize returns the maximum size in bytes of the pad values
+// posted for a cathode, i.e. one value for each of the 64 channels of
+// each of its dual sampas.
+func maxPadValuesSize(cseg mapping.CathodeSegmentation) int64 {
+	return int64(64 * cseg.NofDualSampas() * maxPadValueSize)
+}
+
+// showFlags decodes the show query parameter, if any.
+func showFlags(r *http.Request) (segcontour.ShowFlags, error) {
+	q := r.URL.Query()
+	if _, ok := q["show"]; !ok {
+		return segcontour.ShowFlags{Pads: true, DEs: true, DualSampas: true}, nil
+	}
+	var show segcontour.ShowFlags
+	for _, s := range strings.Split(q.Get("show"), ",") {
+		switch strings.TrimSpace(s) {
+		case "pads":
+			show.Pads = true
+		case "dualsampas":
+			show.DualSampas = true
+		case "de":
+			show.DEs = true
+		case "channels":
+			show.PadChannels = true
+		case "dsids":
+			show.DualSampaIDs = true
+		case "title":
+			show.DETitle = true
+		default:
+			return show, ErrInvalidShow
+		}
+	}
+	return show, nil
+}
+
+// padValues decodes the (optional) JSON list of pad values of the request body.
+func padValues(cseg mapping.CathodeSegmentation, body io.Reader) (map[mapping.PadCID]float64, error) {
+	var pv []PadValue
+	if err := json.NewDecoder(body).Decode(&pv); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, ErrInvalidPadValues
+	}
+	dsids := make(map[mapping.DualSampaID]bool, cseg.NofDualSampas())
+	for i := 0; i < cseg.NofDualSampas(); i++ {
+		dsid, err := cseg.DualSampaID(i)
+		if err != nil {
+			panic(err)
+		}
+		dsids[dsid] = true
+	}
+	values := make(map[mapping.PadCID]float64, len(pv))
+	for _, p := range pv {
+		if !dsids[mapping.DualSampaID(p.DSID)] {
+			return nil, ErrUnknownPad
+		}
+		padcid, err := cseg.FindPadByFEE(mapping.DualSampaID(p.DSID), mapping.DualSampaChannelID(p.DSCH))
+		if err != nil {
+			return nil, ErrUnknownPad
+		}
+		values[padcid] = p.Value
+	}
+	return values, nil
+}
+
+// wantsHTML returns true if the client prefers HTML over SVG.
+func wantsHTML(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	html := strings.Index(accept, "text/html")
+	if html < 0 {
+		return false
+	}
+	svg := strings.Index(accept, "image/svg+xml")
+	return svg < 0 || html < svg
+}
+
+// SVG returns a SVG (or HTML) drawing of a detection element plane,
+// showing the layers selected by the show query parameter.
+// If pad values are posted (see PadValue) the pads are drawn as a heat map.
+// The posted body is limited to the size of one value per channel.
+func SVG(w http.ResponseWriter, r *http.Request, deid int, bending bool) {
+	show, err := showFlags(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	width, err := imageWidth(r, 1024)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cseg := mapping.NewCathodeSegmentation(mapping.DEID(deid), bending)
+	var values map[mapping.PadCID]float64
+	if r.Body != nil {
+		values, err = padValues(cseg, http.MaxBytesReader(w, r.Body, maxPadValuesSize(cseg)))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	svg := geo.NewSVGWriter(width)
+	svg.Style(svgStyle)
+	if values != nil && show.Pads {
+		segcontour.SVGHeatMap(cseg, svg, values, segcontour.DefaultHeatMapOptions)
+		show.Pads = false
+	}
+	segcontour.SVGSegmentation(cseg, svg, show)
+	svg.MoveToOrigin()
+	if wantsHTML(r) {
+		w.Header().Set("Content-type", "text/html")
+		svg.WriteHTML(w)
+		return
+	}
+	w.Header().Set("Content-type", "image/svg+xml")
+	svg.WriteSVGDocument(w)
+}
@@ -0,0 +1,98 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func TestSVG(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SVG(rec, httptest.NewRequest("GET", "/v2/svg?deid=706&bending=true&show=de,dualsampas", nil), 706, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d. Got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-type"); ct != "image/svg+xml" {
+		t.Errorf("Expected image/svg+xml content type. Got %s", ct)
+	}
+	out := rec.Body.String()
+	if !strings.Contains(out, `<g class="detectionelements">`) || !strings.Contains(out, `<g class="dualsampas">`) {
+		t.Errorf("missing detection element or dual sampas")
+	}
+	if strings.Contains(out, `<g class="pads">`) {
+		t.Errorf("pads not expected")
+	}
+}
+
+func TestSVGAsHTML(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/v2/svg?deid=706&bending=true", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	SVG(rec, req, 706, true)
+	if ct := rec.Header().Get("Content-type"); ct != "text/html" {
+		t.Errorf("Expected text/html content type. Got %s", ct)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "<html>") {
+		t.Errorf("Expected an HTML document")
+	}
+}
+
+func TestSVGHeatMap(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, true)
+	var padcid mapping.PadCID
+	cseg.ForEachPad(func(p mapping.PadCID) {
+		padcid = p
+	})
+	body := fmt.Sprintf(`[{"DSID":%d,"DSCH":%d,"Value":12}]`, cseg.PadDualSampaID(padcid), cseg.PadDualSampaChannel(padcid))
+	rec := httptest.NewRecorder()
+	SVG(rec, httptest.NewRequest("POST", "/v2/svg?deid=706&bending=true&show=pads", strings.NewReader(body)), 706, true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d. Got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	out := rec.Body.String()
+	if !strings.Contains(out, `<g class="heatmap">`) {
+		t.Errorf("missing heat map")
+	}
+	n := 0
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "<polygon") && strings.Contains(line, `class="hmnovalue"`) {
+			n++
+		}
+	}
+	if n != cseg.NofPads()-1 {
+		t.Errorf("Expected %d pads without value for pad %d. Got %d", cseg.NofPads()-1, padcid, n)
+	}
+}
+
+func TestSVGPadValuesTooLarge(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, true)
+	// a valid (empty) list, padded beyond the size limit
+	body := "[" + strings.Repeat(" ", int(maxPadValuesSize(cseg))) + "]"
+	rec := httptest.NewRecorder()
+	SVG(rec, httptest.NewRequest("POST", "/v2/svg?deid=706&bending=true&show=pads", strings.NewReader(body)), 706, true)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d. Got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestSVGBadRequests(t *testing.T) {
+	for _, tc := range []struct {
+		url, body string
+	}{
+		{"/v2/svg?show=pads,foo", ""},
+		{"/v2/svg?width=0", ""},
+		{"/v2/svg", "not json"},
+		{"/v2/svg", `[{"DSID":1,"DSCH":64,"Value":1}]`},
+		{"/v2/svg", `[{"DSID":9999,"DSCH":0,"Value":1}]`},
+	} {
+		rec := httptest.NewRecorder()
+		SVG(rec, httptest.NewRequest("POST", tc.url, strings.NewReader(tc.body)), 706, true)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: expected status code %d. Got %d", tc.url, tc.body, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
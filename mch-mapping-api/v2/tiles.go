@@ -0,0 +1,214 @@
+package v2
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/segcontour"
+	"github.com/mrrtf/pigiron/transform"
+)
+
+var ErrInvalidTile = errors.New("tile should be given as /v2/tiles/{z}/{x}/{y}.mvt with 0 <= z <= 12 and 0 <= x,y < 2^z")
+
+// Zoom levels at which the layers of the vector tiles appear.
+// The detection element contours are shown at all zoom levels.
+const (
+	DualSampaMinZoom = 3
+	PadMinZoom       = 6
+	MaxZoom          = 12
+)
+
+// tileDE is one detection element placed in the tile world.
+type tileDE struct {
+	outline segcontour.DEOutline
+	bbox    geo.BBox
+	cseg    mapping.CathodeSegmentation
+	t       transform.Transformation
+	// offset of the chamber cell in the grid
+	dx, dy float64
+}
+
+// tileWorld is the area covered by the tile pyramid: the 10 chambers
+// arranged in a grid (see segcontour.SpectrometerOutlines), in a square
+// whose top is at the highest y.
+type tileWorld struct {
+	bbox geo.BBox
+	des  []tileDE
+}
+
+var (
+	tileWorlds      = make(map[bool]*tileWorld)
+	tileWorldsMutex sync.Mutex
+)
+
+// getTileWorld returns the (cached) world of one cathode plane.
+func getTileWorld(bending bool) (*tileWorld, error) {
+	tileWorldsMutex.Lock()
+	defer tileWorldsMutex.Unlock()
+	if w, ok := tileWorlds[bending]; ok {
+		return w, nil
+	}
+	chambers, cells, err := segcontour.SpectrometerOutlines(bending)
+	if err != nil {
+		return nil, err
+	}
+	w := &tileWorld{}
+	xmin, ymin := math.MaxFloat64, math.MaxFloat64
+	xmax, ymax := -xmin, -ymin
+	for i, outlines := range chambers {
+		c := cells[i]
+		xmin = math.Min(xmin, c.Xmin())
+		ymin = math.Min(ymin, c.Ymin())
+		xmax = math.Max(xmax, c.Xmax())
+		ymax = math.Max(ymax, c.Ymax())
+		for _, o := range outlines {
+			deid := mapping.DEID(o.DEID)
+			t, err := transform.ForDetectionElement(deid)
+			if err != nil {
+				return nil, err
+			}
+			w.des = append(w.des, tileDE{
+				outline: o,
+				bbox:    o.Contour.BBox(),
+				cseg:    mapping.NewCathodeSegmentation(deid, bending),
+				t:       t,
+				dx:      c.Xcenter(),
+				dy:      c.Ycenter(),
+			})
+		}
+	}
+	side := math.Max(xmax-xmin, ymax-ymin)
+	xc := (xmin + xmax) / 2
+	yc := (ymin + ymax) / 2
+	w.bbox = geo.NewBBoxUnchecked(xc-side/2, yc-side/2, xc+side/2, yc+side/2)
+	tileWorlds[bending] = w
+	return w, nil
+}
+
+// tileBBox returns the area of the world covered by a tile.
+func (w *tileWorld) tileBBox(z, x, y int) geo.BBox {
+	size := w.bbox.Width() / float64(int(1)<<uint(z))
+	xmin := w.bbox.Xmin() + float64(x)*size
+	ymax := w.bbox.Ymax() - float64(y)*size
+	return geo.NewBBoxUnchecked(xmin, ymax-size, xmin+size, ymax)
+}
+
+func intersects(a, b geo.BBox) bool {
+	return a.Xmin() < b.Xmax() && b.Xmin() < a.Xmax() && a.Ymin() < b.Ymax() && b.Ymin() < a.Ymax()
+}
+
+// pads adds to the layer the pads of the detection element within the area b.
+func (de *tileDE) pads(l *geo.MVTLayer, b geo.BBox) {
+	// the area in local coordinates (the transformations being
+	// rotations of 180 degrees, a box stays a box)
+	x1, y1, _ := de.t.GlobalToLocal(b.Xmin()-de.dx, b.Ymin()-de.dy, de.t.T[2])
+	x2, y2, _ := de.t.GlobalToLocal(b.Xmax()-de.dx, b.Ymax()-de.dy, de.t.T[2])
+	deid := de.outline.DEID
+	// the area being ordered, ForEachPadInArea cannot fail
+	de.cseg.ForEachPadInArea(math.Min(x1, x2), math.Min(y1, y2), math.Max(x1, x2), math.Max(y1, y2), func(padcid mapping.PadCID) {
+		p := de.t.Polygon(segcontour.PadPolygon(de.cseg, padcid))
+		for i := range p {
+			p[i].X += de.dx
+			p[i].Y += de.dy
+		}
+		l.AddContour(geo.Contour{p}, map[string]interface{}{
+			"DEID": deid,
+			"DSID": int(de.cseg.PadDualSampaID(padcid)),
+			"DSCH": int(de.cseg.PadDualSampaChannel(padcid)),
+		})
+	})
+}
+
+// tile returns the vector tile (z,x,y) of the world.
+func (w *tileWorld) tile(z, x, y int) *geo.MVTTile {
+	b := w.tileBBox(z, x, y)
+	tile := &geo.MVTTile{}
+	des := tile.Layer("detectionelements", b, geo.MVTExtent)
+	var dss, pads *geo.MVTLayer
+	if z >= DualSampaMinZoom {
+		dss = tile.Layer("dualsampas", b, geo.MVTExtent)
+	}
+	if z >= PadMinZoom {
+		pads = tile.Layer("pads", b, geo.MVTExtent)
+	}
+	for i := range w.des {
+		de := &w.des[i]
+		if !intersects(de.bbox, b) {
+			continue
+		}
+		deid := de.outline.DEID
+		des.AddContour(de.outline.Contour, map[string]interface{}{
+			"DEID":    deid,
+			"Chamber": deid / 100,
+		})
+		if dss != nil {
+			for _, ds := range de.outline.DualSampas {
+				if intersects(ds.Contour.BBox(), b) {
+					dss.AddContour(ds.Contour, map[string]interface{}{
+						"DEID": deid,
+						"DSID": ds.ID,
+					})
+				}
+			}
+		}
+		if pads != nil {
+			de.pads(pads, b)
+		}
+	}
+	return tile
+}
+
+// parseTile decodes the {z}/{x}/{y}.mvt part of a tile path.
+func parseTile(path string) (z, x, y int, err error) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 || !strings.HasSuffix(parts[2], ".mvt") {
+		return 0, 0, 0, ErrInvalidTile
+	}
+	parts[2] = strings.TrimSuffix(parts[2], ".mvt")
+	var v [3]int
+	for i, s := range parts {
+		v[i], err = strconv.Atoi(s)
+		if err != nil {
+			return 0, 0, 0, ErrInvalidTile
+		}
+	}
+	z, x, y = v[0], v[1], v[2]
+	if z < 0 || z > MaxZoom || x < 0 || y < 0 || x >= 1<<uint(z) || y >= 1<<uint(z) {
+		return 0, 0, 0, ErrInvalidTile
+	}
+	return z, x, y, nil
+}
+
+// Tiles serves the Mapbox Vector Tiles /v2/tiles/{z}/{x}/{y}.mvt of the whole
+// spectrometer (with bending=[true|false], true by default), with layers
+// for the detection elements, the dual sampas (from zoom DualSampaMinZoom)
+// and the pads (from zoom PadMinZoom).
+func Tiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	z, x, y, err := parseTile(strings.TrimPrefix(r.URL.Path, "/v2/tiles/"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	bending := true
+	if b := r.URL.Query().Get("bending"); b != "" {
+		bending, err = strconv.ParseBool(b)
+		if err != nil {
+			http.Error(w, ErrInvalidBending.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	world, err := getTileWorld(bending)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-type", "application/vnd.mapbox-vector-tile")
+	w.Write(world.tile(z, x, y).Marshal())
+}
@@ -0,0 +1,112 @@
+package v2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pbFields splits a protobuf message into its length-delimited fields
+// (the only ones needed here), skipping the varint and fixed64 ones.
+func pbFields(t *testing.T, b []byte) map[int][][]byte {
+	fields := make(map[int][][]byte)
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("invalid protobuf key")
+		}
+		b = b[n:]
+		switch key & 7 {
+		case 0:
+			_, n = binary.Uvarint(b)
+		case 1:
+			n = 8
+		case 2:
+			l, m := binary.Uvarint(b)
+			fields[int(key>>3)] = append(fields[int(key>>3)], b[m:m+int(l)])
+			n = m + int(l)
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		b = b[n:]
+	}
+	return fields
+}
+
+// getTile returns the number of features per layer of a tile.
+func getTile(t *testing.T, path string) map[string]int {
+	rec := httptest.NewRecorder()
+	Tiles(rec, httptest.NewRequest("GET", path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d. Got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-type"); ct != "application/vnd.mapbox-vector-tile" {
+		t.Errorf("Unexpected content type %s", ct)
+	}
+	layers := make(map[string]int)
+	for _, l := range pbFields(t, rec.Body.Bytes())[3] {
+		f := pbFields(t, l)
+		layers[string(f[1][0])] = len(f[2])
+	}
+	return layers
+}
+
+func TestTilesZoomLevels(t *testing.T) {
+	layers := getTile(t, "/v2/tiles/0/0/0.mvt")
+	if len(layers) != 1 || layers["detectionelements"] != 156 {
+		t.Errorf("Expected the 156 detection elements only. Got %v", layers)
+	}
+	found := false
+	for x := 0; x < 8 && !found; x++ {
+		for y := 0; y < 8 && !found; y++ {
+			layers = getTile(t, fmt.Sprintf("/v2/tiles/3/%d/%d.mvt?bending=false", x, y))
+			found = layers["dualsampas"] > 0
+			if layers["pads"] > 0 {
+				t.Errorf("Unexpected pads at zoom 3")
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected dual sampas at zoom 3")
+	}
+}
+
+func TestTilesPads(t *testing.T) {
+	w, err := getTileWorld(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the tile containing the center of DE 100
+	de := w.des[0]
+	if de.outline.DEID != 100 {
+		t.Fatalf("Expected DE 100 first. Got %d", de.outline.DEID)
+	}
+	const z = 6
+	b := de.bbox
+	size := w.bbox.Width() / (1 << z)
+	x := int((b.Xcenter() - w.bbox.Xmin()) / size)
+	y := int((w.bbox.Ymax() - b.Ycenter()) / size)
+	layers := getTile(t, fmt.Sprintf("/v2/tiles/%d/%d/%d.mvt", z, x, y))
+	if layers["pads"] == 0 || layers["dualsampas"] == 0 || layers["detectionelements"] == 0 {
+		t.Errorf("Expected pads, dual sampas and detection elements. Got %v", layers)
+	}
+}
+
+func TestTilesInvalid(t *testing.T) {
+	for _, path := range []string{
+		"/v2/tiles/0/0.mvt",
+		"/v2/tiles/0/0/0.png",
+		"/v2/tiles/1/2/0.mvt",
+		"/v2/tiles/13/0/0.mvt",
+		"/v2/tiles/a/0/0.mvt",
+		"/v2/tiles/0/0/0.mvt?bending=maybe",
+	} {
+		rec := httptest.NewRecorder()
+		Tiles(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status code %d. Got %d", path, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/occupancy"
+	"github.com/mrrtf/pigiron/status"
+
+	// must include the specific implementation package of the mapping
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+const usageMsg = `Usage: mch-occupancy [options] file1 [file2...]
+
+Computes the occupancy (number of digits per cm2 and per second) of each pad
+from binary digit files, and finds the hot and dead channels and dual sampas.
+
+The occupancy is written in JSON format (it can be served by mch-mapping-api
+using the MCH_OCCUPANCY_FILE environment variable) and the hot and dead
+candidates as a status map.
+
+Options:
+`
+
+func count(filename string, c *occupancy.Counter) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := digit.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return err
+	}
+	var cerr error
+	err = r.ForEachDigit(func(d digit.Digit) {
+		if cerr == nil {
+			cerr = c.Add(d)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return cerr
+}
+
+// createFile creates a file and fills it using w.
+func createFile(filename string, w func(f *os.File) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := w(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func main() {
+	cfg := occupancy.DefaultConfig
+	output := flag.String("o", "occupancy.json", "occupancy output file")
+	statusOutput := flag.String("status", "status.json", "status map output file")
+	duration := flag.Float64("duration", 0, "duration (in seconds) used to normalize the rates (default is the time span of the digits)")
+	var validity status.Validity
+	flag.Int64Var(&validity.Start, "start", 0, "start of the validity of the status map")
+	flag.Int64Var(&validity.End, "end", 0, "end of the validity of the status map (0 means unbounded)")
+	flag.Float64Var(&cfg.HotSigmas, "hot-sigmas", cfg.HotSigmas, "number of robust standard deviations above the median of a hot channel")
+	flag.Float64Var(&cfg.HotFactor, "hot-factor", cfg.HotFactor, "minimum ratio to the median of a hot channel")
+	flag.Float64Var(&cfg.DeadFraction, "dead-fraction", cfg.DeadFraction, "maximum ratio to the median of a dead dual sampa")
+	flag.Float64Var(&cfg.MinExpected, "min-expected", cfg.MinExpected, "minimum number of expected digits to flag an empty channel as dead")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usageMsg)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	c := occupancy.NewCounter()
+	for _, filename := range flag.Args() {
+		if err := count(filename, c); err != nil {
+			log.Fatalf("%s: %v", filename, err)
+		}
+	}
+	if *duration <= 0 {
+		*duration = c.Duration()
+	}
+	r, err := c.Result(*duration)
+	if err != nil {
+		log.Fatal(err)
+	}
+	candidates := r.Analyze(cfg)
+	nbad := make(map[status.Flag]int)
+	for _, cand := range candidates {
+		nbad[cand.Flag]++
+	}
+	fmt.Printf("%d pads over %g s : %d hot and %d dead candidates\n", len(r.Pads), *duration, nbad[status.Noisy], nbad[status.Dead])
+
+	m, err := occupancy.StatusMap(candidates, validity)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := createFile(*output, func(f *os.File) error { return r.WriteJSON(f) }); err != nil {
+		log.Fatal(err)
+	}
+	if err := createFile(*statusOutput, func(f *os.File) error { return m.WriteJSON(f) }); err != nil {
+		log.Fatal(err)
+	}
+}
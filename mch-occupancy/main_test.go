@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/occupancy"
+)
+
+func TestCount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "occupancy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	digits := digit.NewContainer()
+	digits.Add(digit.Digit{DEID: 706, PadUID: 1, Orbit: 1})
+	digits.Add(digit.Digit{DEID: 706, PadUID: 1, Orbit: 2})
+	digits.Add(digit.Digit{DEID: 100, PadUID: 10, Orbit: 2})
+	filename := filepath.Join(dir, "digits.bin")
+	err = createFile(filename, func(f *os.File) error { return digit.Write(f, digits) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := occupancy.NewCounter()
+	if err := count(filename, c); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := c.Result(1)
+	if n := r.PadRates(706)[1]; n == 0 {
+		t.Errorf("expected a non zero rate for pad 1 of DE 706")
+	}
+	if len(r.DEIDs()) != 2 {
+		t.Errorf("expected 2 detection elements and got %d", len(r.DEIDs()))
+	}
+	if err := count(filepath.Join(dir, "missing.bin"), c); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mrrtf/pigiron/calib"
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+
+	// must include the specific implementation package of the mapping
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+var errInvalidSampleLine = errors.New("sample lines must be : deid dsid channel sample1 [sample2...]")
+
+const usageMsg = `Usage: mch-pedestal-calib [options] file1 [file2...]
+
+Computes the pedestal mean and noise of each channel from pedestal run data,
+and flags the dead and noisy channels.
+
+Input files are either binary digit files or, if their extension is .txt,
+text files with one line per channel and time window :
+
+  deid dsid channel sample1 [sample2 ...]
+
+The output format (JSON or CSV) is deduced from the output file extension.
+
+Options:
+`
+
+// readSamples accumulates the samples of a text sample file.
+func readSamples(in io.Reader, acc *calib.Accumulator) error {
+	scanner := bufio.NewScanner(in)
+	samples := make([]uint16, 0, 1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			return errInvalidSampleLine
+		}
+		var ids [3]int
+		for i := range ids {
+			v, err := strconv.Atoi(fields[i])
+			if err != nil {
+				return errInvalidSampleLine
+			}
+			ids[i] = v
+		}
+		samples = samples[:0]
+		for _, f := range fields[3:] {
+			v, err := strconv.ParseUint(f, 10, 16)
+			if err != nil {
+				return errInvalidSampleLine
+			}
+			samples = append(samples, uint16(v))
+		}
+		acc.AddSamples(calib.ChannelKey{
+			DEID:        mapping.DEID(ids[0]),
+			DualSampaID: mapping.DualSampaID(ids[1]),
+			Channel:     mapping.DualSampaChannelID(ids[2]),
+		}, samples...)
+	}
+	return scanner.Err()
+}
+
+// readDigits accumulates the digits of a binary digit file.
+func readDigits(in io.Reader, acc *calib.Accumulator) error {
+	c, err := digit.Read(in)
+	if err != nil {
+		return err
+	}
+	for _, deid := range c.DEIDs() {
+		for _, d := range c.Digits(deid) {
+			if err := acc.AddDigit(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func accumulate(filename string, acc *calib.Accumulator) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if filepath.Ext(filename) == ".txt" {
+		return readSamples(f, acc)
+	}
+	return readDigits(bufio.NewReader(f), acc)
+}
+
+func main() {
+	cfg := calib.DefaultConfig
+	output := flag.String("o", "pedestals.json", "output file (.json or .csv)")
+	flag.Int64Var(&cfg.MinEntries, "min-entries", cfg.MinEntries, "minimum number of entries of a good channel")
+	flag.Float64Var(&cfg.MinRMS, "min-rms", cfg.MinRMS, "minimum noise of a good channel")
+	flag.Float64Var(&cfg.MaxRMS, "max-rms", cfg.MaxRMS, "maximum noise of a good channel")
+	flag.Float64Var(&cfg.MinMean, "min-mean", cfg.MinMean, "minimum pedestal of a good channel")
+	flag.Float64Var(&cfg.MaxMean, "max-mean", cfg.MaxMean, "maximum pedestal of a good channel")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usageMsg)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	acc := calib.NewAccumulator()
+	for _, filename := range flag.Args() {
+		if err := accumulate(filename, acc); err != nil {
+			log.Fatalf("%s: %v", filename, err)
+		}
+	}
+
+	c := acc.Pedestals(cfg)
+	nbad := make(map[calib.Status]int)
+	for _, p := range c.Pedestals {
+		nbad[p.Status]++
+	}
+	fmt.Printf("%d channels : %d ok, %d dead, %d noisy\n", len(c.Pedestals), nbad[calib.OK], nbad[calib.Dead], nbad[calib.Noisy])
+
+	out, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer out.Close()
+	if filepath.Ext(*output) == ".csv" {
+		err = c.WriteCSV(out)
+	} else {
+		err = c.WriteJSON(out)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
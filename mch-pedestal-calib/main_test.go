@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/calib"
+)
+
+func TestReadSamples(t *testing.T) {
+	acc := calib.NewAccumulator()
+	err := readSamples(strings.NewReader(`# comment
+100 1 2 50 51 49 50
+100 1 2 50 50
+
+100 1 3 60 60
+`), acc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Len() != 2 {
+		t.Errorf("expected 2 channels and got %d", acc.Len())
+	}
+	if err := readSamples(strings.NewReader("100 1 2\n"), acc); err != errInvalidSampleLine {
+		t.Errorf("expected errInvalidSampleLine and got %v", err)
+	}
+	if err := readSamples(strings.NewReader("100 1 2 x\n"), acc); err != errInvalidSampleLine {
+		t.Errorf("expected errInvalidSampleLine and got %v", err)
+	}
+}
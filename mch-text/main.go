@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/segcontour"
+
+	// must include the specific implementation package of the mapping
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+const usageMsg = `Usage: mch-text [options] -deid [number]
+
+Draws the dual sampas of one cathode of a detection element in the terminal,
+with box-drawing characters for the dual sampa boundaries.
+
+Pad values can be given (-values) as a text file (- for stdin) with one
+"dsid dsch value" line per pad (lines starting with # being ignored),
+the pads being then shaded according to their values.
+
+Options:
+`
+
+var (
+	ErrInvalidDEID   = errors.New("invalid detection element id")
+	ErrInvalidArea   = errors.New("area should be given as xmin,ymin,xmax,ymax")
+	ErrInvalidDSID   = errors.New("invalid dual sampa id")
+	ErrInvalidValues = errors.New("values should be given as dsid dsch value lines")
+	ErrUnknownPad    = errors.New("no pad for this dual sampa channel")
+)
+
+// parseArea decodes a xmin,ymin,xmax,ymax area.
+func parseArea(s string) (geo.BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, ErrInvalidArea
+	}
+	var v [4]float64
+	for i, p := range parts {
+		var err error
+		v[i], err = strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, ErrInvalidArea
+		}
+	}
+	b, err := geo.NewBBox(v[0], v[1], v[2], v[3])
+	if err != nil {
+		return nil, ErrInvalidArea
+	}
+	return b, nil
+}
+
+func dualSampaIDs(cseg mapping.CathodeSegmentation) map[mapping.DualSampaID]bool {
+	dsids := make(map[mapping.DualSampaID]bool, cseg.NofDualSampas())
+	for i := 0; i < cseg.NofDualSampas(); i++ {
+		dsid, err := cseg.DualSampaID(i)
+		if err != nil {
+			panic(err)
+		}
+		dsids[dsid] = true
+	}
+	return dsids
+}
+
+// readValues decodes the dsid dsch value lines of in.
+func readValues(cseg mapping.CathodeSegmentation, in io.Reader) (map[mapping.PadCID]float64, error) {
+	dsids := dualSampaIDs(cseg)
+	values := make(map[mapping.PadCID]float64)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, ErrInvalidValues
+		}
+		dsid, err1 := strconv.Atoi(fields[0])
+		dsch, err2 := strconv.Atoi(fields[1])
+		v, err3 := strconv.ParseFloat(fields[2], 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, ErrInvalidValues
+		}
+		if !dsids[mapping.DualSampaID(dsid)] {
+			return nil, ErrUnknownPad
+		}
+		padcid, err := cseg.FindPadByFEE(mapping.DualSampaID(dsid), mapping.DualSampaChannelID(dsch))
+		if err != nil {
+			return nil, ErrUnknownPad
+		}
+		values[padcid] = v
+	}
+	return values, scanner.Err()
+}
+
+// draw writes the text drawing of one cathode of a detection element,
+// with the pad values read from values (if not nil).
+func draw(out io.Writer, deid int, bending bool, values io.Reader, opts segcontour.TextOptions) error {
+	cseg := mapping.NewCathodeSegmentation(mapping.DEID(deid), bending)
+	if cseg == nil {
+		return ErrInvalidDEID
+	}
+	if opts.Highlight != 0 && !dualSampaIDs(cseg)[opts.Highlight] {
+		return ErrInvalidDSID
+	}
+	var v map[mapping.PadCID]float64
+	if values != nil {
+		var err error
+		v, err = readValues(cseg, values)
+		if err != nil {
+			return err
+		}
+	}
+	return segcontour.WriteText(cseg, out, v, opts)
+}
+
+// terminalWidth returns the width of the terminal, as given by
+// the COLUMNS environment variable, or 80.
+func terminalWidth() int {
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 1 {
+		return w
+	}
+	return 80
+}
+
+func main() {
+	deid := flag.Int("deid", 0, "detection element id")
+	bending := flag.Bool("bending", true, "draw the bending (true) or non-bending (false) cathode")
+	width := flag.Int("width", terminalWidth(), "number of columns")
+	area := flag.String("area", "", "area to draw (zoom), as xmin,ymin,xmax,ymax in local coordinates (cm)")
+	dsid := flag.Int("ds", 0, "id of a dual sampa to highlight")
+	values := flag.String("values", "", "file of pad values (- for stdin)")
+	logScale := flag.Bool("log", false, "use a logarithmic scale for the values")
+	ascii := flag.Bool("ascii", false, "use only ASCII characters")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usageMsg)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *deid == 0 || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	opts := segcontour.TextOptions{
+		Width:     *width,
+		Highlight: mapping.DualSampaID(*dsid),
+		ASCII:     *ascii,
+	}
+	if *logScale {
+		opts.Scale = segcontour.LogScale
+	}
+	if *area != "" {
+		b, err := parseArea(*area)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Area = b
+	}
+	var in io.Reader
+	switch *values {
+	case "":
+	case "-":
+		in = os.Stdin
+	default:
+		f, err := os.Open(*values)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	if err := draw(os.Stdout, *deid, *bending, in, opts); err != nil {
+		log.Fatal(err)
+	}
+}
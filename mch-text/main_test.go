@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/segcontour"
+)
+
+func TestDraw(t *testing.T) {
+	var buf bytes.Buffer
+	values := strings.NewReader("# dsid dsch value\n1025 0 1\n1025 1 2\n\n1026 3 10\n")
+	if err := draw(&buf, 501, false, values, segcontour.TextOptions{Width: 60}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if legend := lines[len(lines)-1]; !strings.HasSuffix(legend, " - 10") {
+		t.Errorf("want a legend up to 10, got %q", legend)
+	}
+}
+
+func TestDrawErrors(t *testing.T) {
+	for _, tc := range []struct {
+		deid   int
+		values string
+		dsid   mapping.DualSampaID
+		want   error
+	}{
+		{42, "", 0, ErrInvalidDEID},
+		{501, "", 1, ErrInvalidDSID},
+		{501, "1025 0", 0, ErrInvalidValues},
+		{501, "1025 0 x", 0, ErrInvalidValues},
+		{501, "1 0 1", 0, ErrUnknownPad},
+	} {
+		var buf bytes.Buffer
+		opts := segcontour.TextOptions{Width: 60, Highlight: tc.dsid}
+		if err := draw(&buf, tc.deid, false, strings.NewReader(tc.values), opts); err != tc.want {
+			t.Errorf("deid %d values %q: want %v, got %v", tc.deid, tc.values, tc.want, err)
+		}
+	}
+}
+
+func TestParseArea(t *testing.T) {
+	b, err := parseArea("-10, 0,10,5.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Xmin() != -10 || b.Ymax() != 5.5 {
+		t.Errorf("unexpected area %v", b)
+	}
+	for _, s := range []string{"", "1,2,3", "1,2,a,4", "10,0,0,5"} {
+		if _, err := parseArea(s); err != ErrInvalidArea {
+			t.Errorf("%q: want ErrInvalidArea, got %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package occupancy
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/status"
+)
+
+// madToSigma converts a median absolute deviation into the standard
+// deviation of a gaussian distribution.
+const madToSigma = 1.4826
+
+// Config holds the thresholds used to find hot and dead channels.
+//
+// A channel (resp. dual sampa) is compared to the other channels of its
+// dual sampa (resp. to the other dual sampas of its detection element),
+// using the median and the median absolute deviation of their rates.
+type Config struct {
+	// HotSigmas is the number of (robust) standard deviations above
+	// the median for a channel or a dual sampa to be considered hot.
+	HotSigmas float64
+	// HotFactor is the minimum ratio to the median for a channel or
+	// a dual sampa to be considered hot.
+	HotFactor float64
+	// DeadFraction is the ratio to the median below which a dual sampa
+	// is considered dead.
+	DeadFraction float64
+	// MinExpected is the minimum number of digits expected (from the median
+	// rate) on a channel to consider it dead if it has none.
+	MinExpected float64
+}
+
+// DefaultConfig are reasonable defaults for the occupancy analysis.
+var DefaultConfig = Config{
+	HotSigmas:    5,
+	HotFactor:    3,
+	DeadFraction: 0.1,
+	MinExpected:  10,
+}
+
+// Candidate is a channel, or a whole dual sampa if Channel is
+// status.AllChannels, found to be hot (status.Noisy) or dead (status.Dead).
+type Candidate struct {
+	Pad
+	Flag status.Flag
+	// Median is the median rate of the reference population.
+	Median float64
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	v := append([]float64(nil), values...)
+	sort.Float64s(v)
+	n := len(v)
+	if n%2 == 1 {
+		return v[n/2]
+	}
+	return (v[n/2-1] + v[n/2]) / 2
+}
+
+// robust returns the median and the robust standard deviation of values.
+func robust(values []float64) (float64, float64) {
+	m := median(values)
+	dev := make([]float64, len(values))
+	for i, v := range values {
+		dev[i] = math.Abs(v - m)
+	}
+	return m, madToSigma * median(dev)
+}
+
+func (cfg Config) isHot(rate, median, sigma float64) bool {
+	return rate > median+cfg.HotSigmas*sigma && rate > cfg.HotFactor*median
+}
+
+type dsKey struct {
+	deid mapping.DEID
+	dsid mapping.DualSampaID
+}
+
+// Analyze returns the hot and dead candidates of the result.
+// The dual sampas are analyzed first, and the channels of the hot
+// or dead dual sampas are not analyzed individually.
+func (r *Result) Analyze(cfg Config) []Candidate {
+	var candidates []Candidate
+	dss := make(map[dsKey][]Pad)
+	var keys []dsKey
+	for _, p := range r.Pads {
+		k := dsKey{p.DEID, p.DualSampaID}
+		if _, ok := dss[k]; !ok {
+			keys = append(keys, k)
+		}
+		dss[k] = append(dss[k], p)
+	}
+	bad := make(map[dsKey]bool)
+	for _, deid := range r.DEIDs() {
+		rates := r.DualSampaRates(deid)
+		var values []float64
+		for _, v := range rates {
+			values = append(values, v)
+		}
+		m, sigma := robust(values)
+		for _, k := range keys {
+			if k.deid != deid {
+				continue
+			}
+			var f status.Flag
+			switch rate := rates[k.dsid]; {
+			case cfg.isHot(rate, m, sigma):
+				f = status.Noisy
+			case rate < cfg.DeadFraction*m && r.expected(dss[k], m) >= cfg.MinExpected:
+				f = status.Dead
+			default:
+				continue
+			}
+			bad[k] = true
+			candidates = append(candidates, Candidate{
+				Pad:    Pad{DEID: deid, PadUID: mapping.InvalidPadUID, DualSampaID: k.dsid, Channel: status.AllChannels, Rate: rates[k.dsid]},
+				Flag:   f,
+				Median: m,
+			})
+		}
+	}
+	for _, k := range keys {
+		if bad[k] {
+			continue
+		}
+		pads := dss[k]
+		values := make([]float64, len(pads))
+		for i, p := range pads {
+			values[i] = p.Rate
+		}
+		m, sigma := robust(values)
+		for _, p := range pads {
+			var f status.Flag
+			switch {
+			case cfg.isHot(p.Rate, m, sigma):
+				f = status.Noisy
+			case p.Count == 0 && m*p.Area*r.Duration >= cfg.MinExpected:
+				f = status.Dead
+			default:
+				continue
+			}
+			candidates = append(candidates, Candidate{Pad: p, Flag: f, Median: m})
+		}
+	}
+	return candidates
+}
+
+// expected returns the number of digits expected on a set of pads
+// for a given rate.
+func (r *Result) expected(pads []Pad, rate float64) float64 {
+	area := 0.0
+	for _, p := range pads {
+		area += p.Area
+	}
+	return rate * area * r.Duration
+}
+
+// StatusMap returns a status map with the candidates, valid for v.
+func StatusMap(candidates []Candidate, v status.Validity) (*status.Map, error) {
+	m := status.NewMap()
+	for _, c := range candidates {
+		err := m.Add(status.Entry{DEID: c.DEID, DualSampaID: c.DualSampaID, Channel: c.Channel, Flags: c.Flag, Validity: v})
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.Sort()
+	return m, nil
+}
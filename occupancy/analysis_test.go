@@ -0,0 +1,81 @@
+package occupancy
+
+import (
+	"testing"
+
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/status"
+)
+
+func TestMedian(t *testing.T) {
+	if m := median([]float64{3, 1, 2}); m != 2 {
+		t.Errorf("want 2, got %v", m)
+	}
+	if m := median([]float64{4, 1, 2, 3}); m != 2.5 {
+		t.Errorf("want 2.5, got %v", m)
+	}
+	m, sigma := robust([]float64{1, 1, 1, 1, 100})
+	if m != 1 || sigma != 0 {
+		t.Errorf("want (1,0), got (%v,%v)", m, sigma)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	const deid = 706
+	const hotPad, deadPad = 10, 20
+	const deadDS = mapping.DualSampaID(4)
+	c := uniformCounter(t, deid, 100, func(seg mapping.Segmentation, paduid mapping.PadUID) int {
+		switch {
+		case seg.PadDualSampaID(paduid) == deadDS:
+			return 0
+		case paduid == hotPad:
+			return 2000
+		case paduid == deadPad:
+			return 0
+		}
+		return -1
+	})
+	r, _ := c.Result(1)
+	candidates := r.Analyze(DefaultConfig)
+	if len(candidates) != 3 {
+		t.Fatalf("want 3 candidates, got %d : %v", len(candidates), candidates)
+	}
+	found := make(map[string]bool)
+	for _, c := range candidates {
+		switch {
+		case c.Channel == status.AllChannels && c.DualSampaID == deadDS && c.Flag == status.Dead:
+			found["deadDS"] = true
+		case c.PadUID == hotPad && c.Flag == status.Noisy:
+			found["hot"] = true
+		case c.PadUID == deadPad && c.Flag == status.Dead:
+			found["dead"] = true
+		}
+	}
+	if len(found) != 3 {
+		t.Errorf("unexpected candidates %v", candidates)
+	}
+	m, err := StatusMap(candidates, status.Validity{Start: 10, End: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seg := mapping.NewSegmentation(deid)
+	if f := m.PadStatus(seg, hotPad, 15); f != status.Noisy {
+		t.Errorf("want hot pad to be noisy, got %v", f)
+	}
+	seg.ForEachPadInDualSampa(deadDS, func(paduid mapping.PadUID) {
+		if f := m.PadStatus(seg, paduid, 15); f != status.Dead {
+			t.Errorf("want pad %d of dead dual sampa to be dead, got %v", paduid, f)
+		}
+	})
+}
+
+func TestAnalyzeLowStatistics(t *testing.T) {
+	// with a very low occupancy, empty channels are expected
+	c := uniformCounter(t, 706, 1, func(mapping.Segmentation, mapping.PadUID) int { return -1 })
+	r, _ := c.Result(1)
+	for _, c := range r.Analyze(DefaultConfig) {
+		if c.Flag == status.Dead {
+			t.Errorf("no dead channel expected at low statistics, got %v", c)
+		}
+	}
+}
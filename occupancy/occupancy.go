@@ -0,0 +1,199 @@
+package occupancy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// FileFormatVersion is the version of the occupancy files written by this package.
+const FileFormatVersion = 1
+
+// SecondsPerBC is the duration of one bunch crossing.
+const SecondsPerBC = 25e-9
+
+var (
+	// ErrInvalidDEID signals an unknown detection element.
+	ErrInvalidDEID = errors.New("invalid detection element id")
+	// ErrInvalidPadUID signals a pad that does not exist in its detection element.
+	ErrInvalidPadUID = errors.New("invalid pad uid")
+	// ErrInvalidDuration signals a non positive duration.
+	ErrInvalidDuration = errors.New("duration must be positive")
+	// ErrUnsupportedVersion signals an occupancy file with an unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported occupancy file version")
+)
+
+// Counter counts the number of digits of each pad.
+type Counter struct {
+	counts     map[mapping.DEID][]int64
+	segs       mapping.SegCache
+	tmin, tmax int64
+}
+
+// NewCounter returns an empty counter.
+func NewCounter() *Counter {
+	return &Counter{counts: make(map[mapping.DEID][]int64), tmin: -1}
+}
+
+// Add counts one digit.
+func (c *Counter) Add(d digit.Digit) error {
+	counts, ok := c.counts[d.DEID]
+	if !ok {
+		seg := c.segs.Segmentation(d.DEID)
+		if seg == nil {
+			return ErrInvalidDEID
+		}
+		counts = make([]int64, seg.NofPads())
+		c.counts[d.DEID] = counts
+	}
+	if d.PadUID < 0 || int(d.PadUID) >= len(counts) {
+		return ErrInvalidPadUID
+	}
+	counts[d.PadUID]++
+	t := d.Time()
+	if c.tmin < 0 || t < c.tmin {
+		c.tmin = t
+	}
+	if t > c.tmax {
+		c.tmax = t
+	}
+	return nil
+}
+
+// AddContainer counts all the digits of a container.
+func (c *Counter) AddContainer(digits *digit.Container) error {
+	for _, deid := range digits.DEIDs() {
+		for _, d := range digits.Digits(deid) {
+			if err := c.Add(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Duration returns the time span (in seconds) covered by the digits
+// counted so far, which is at least one bunch crossing.
+func (c *Counter) Duration() float64 {
+	if c.tmin < 0 {
+		return 0
+	}
+	return float64(c.tmax-c.tmin+1) * SecondsPerBC
+}
+
+// Pad is the occupancy of one pad.
+type Pad struct {
+	DEID        mapping.DEID
+	PadUID      mapping.PadUID
+	DualSampaID mapping.DualSampaID
+	Channel     mapping.DualSampaChannelID
+	Count       int64
+	// Area of the pad in cm2.
+	Area float64
+	// Rate is the number of digits per cm2 and per second.
+	Rate float64
+}
+
+// Result is the occupancy of all the pads of the detection elements
+// seen by a counter.
+type Result struct {
+	Version int
+	// Duration in seconds used to normalize the rates.
+	Duration float64
+	Pads     []Pad
+}
+
+// Result returns the occupancy of all the pads of the detection elements
+// that have at least one digit, the rates being normalized by the pad
+// areas and by the given duration (in seconds).
+func (c *Counter) Result(duration float64) (*Result, error) {
+	if duration <= 0 {
+		return nil, ErrInvalidDuration
+	}
+	r := &Result{Version: FileFormatVersion, Duration: duration}
+	deids := make([]mapping.DEID, 0, len(c.counts))
+	for deid := range c.counts {
+		deids = append(deids, deid)
+	}
+	sort.Slice(deids, func(i, j int) bool { return deids[i] < deids[j] })
+	for _, deid := range deids {
+		seg := c.segs.Segmentation(deid)
+		counts := c.counts[deid]
+		seg.ForEachPad(func(paduid mapping.PadUID) {
+			area := seg.PadSizeX(paduid) * seg.PadSizeY(paduid)
+			r.Pads = append(r.Pads, Pad{
+				DEID:        deid,
+				PadUID:      paduid,
+				DualSampaID: seg.PadDualSampaID(paduid),
+				Channel:     seg.PadDualSampaChannel(paduid),
+				Count:       counts[paduid],
+				Area:        area,
+				Rate:        float64(counts[paduid]) / area / duration,
+			})
+		})
+	}
+	return r, nil
+}
+
+// DEIDs returns the (sorted) list of detection elements of the result.
+func (r *Result) DEIDs() []mapping.DEID {
+	var deids []mapping.DEID
+	for _, p := range r.Pads {
+		if len(deids) == 0 || deids[len(deids)-1] != p.DEID {
+			deids = append(deids, p.DEID)
+		}
+	}
+	return deids
+}
+
+// PadRates returns the rate of each pad of one detection element.
+func (r *Result) PadRates(deid mapping.DEID) map[mapping.PadUID]float64 {
+	rates := make(map[mapping.PadUID]float64)
+	for _, p := range r.Pads {
+		if p.DEID == deid {
+			rates[p.PadUID] = p.Rate
+		}
+	}
+	return rates
+}
+
+// DualSampaRates returns the rate of each dual sampa of one detection
+// element, i.e. its number of digits per cm2 and per second.
+func (r *Result) DualSampaRates(deid mapping.DEID) map[mapping.DualSampaID]float64 {
+	counts := make(map[mapping.DualSampaID]int64)
+	areas := make(map[mapping.DualSampaID]float64)
+	for _, p := range r.Pads {
+		if p.DEID == deid {
+			counts[p.DualSampaID] += p.Count
+			areas[p.DualSampaID] += p.Area
+		}
+	}
+	rates := make(map[mapping.DualSampaID]float64, len(counts))
+	for dsid, n := range counts {
+		rates[dsid] = float64(n) / areas[dsid] / r.Duration
+	}
+	return rates
+}
+
+// WriteJSON writes the occupancy in JSON format.
+func (r *Result) WriteJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", " ")
+	return enc.Encode(r)
+}
+
+// ReadJSON reads an occupancy in JSON format.
+func ReadJSON(in io.Reader) (*Result, error) {
+	var r Result
+	if err := json.NewDecoder(in).Decode(&r); err != nil {
+		return nil, err
+	}
+	if r.Version != FileFormatVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	return &r, nil
+}
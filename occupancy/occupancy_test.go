@@ -0,0 +1,89 @@
+package occupancy
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	// must include the specific implementation package of the mapping
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+// uniformCounter returns a counter where each pad of the detection element
+// has (roughly) density digits per cm2, except for the pads for which
+// the count function returns a non negative value.
+func uniformCounter(t *testing.T, deid mapping.DEID, density float64, count func(seg mapping.Segmentation, paduid mapping.PadUID) int) *Counter {
+	seg := mapping.NewSegmentation(deid)
+	c := NewCounter()
+	seg.ForEachPad(func(paduid mapping.PadUID) {
+		n := count(seg, paduid)
+		if n < 0 {
+			n = int(math.Round(density * seg.PadSizeX(paduid) * seg.PadSizeY(paduid)))
+		}
+		for i := 0; i < n; i++ {
+			if err := c.Add(digit.Digit{DEID: deid, PadUID: paduid, Orbit: uint32(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	return c
+}
+
+func TestCounter(t *testing.T) {
+	c := NewCounter()
+	if c.Duration() != 0 {
+		t.Errorf("empty counter should have a zero duration")
+	}
+	c.Add(digit.Digit{DEID: 706, PadUID: 3, Orbit: 1})
+	c.Add(digit.Digit{DEID: 706, PadUID: 3, Orbit: 2})
+	if err := c.Add(digit.Digit{DEID: 104}); err != ErrInvalidDEID {
+		t.Errorf("want ErrInvalidDEID, got %v", err)
+	}
+	if err := c.Add(digit.Digit{DEID: 706, PadUID: 100000}); err != ErrInvalidPadUID {
+		t.Errorf("want ErrInvalidPadUID, got %v", err)
+	}
+	want := float64(digit.BCPerOrbit+1) * SecondsPerBC
+	if math.Abs(c.Duration()-want) > 1e-12 {
+		t.Errorf("want duration %v, got %v", want, c.Duration())
+	}
+	if _, err := c.Result(0); err != ErrInvalidDuration {
+		t.Errorf("want ErrInvalidDuration, got %v", err)
+	}
+	r, err := c.Result(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seg := mapping.NewSegmentation(706)
+	if len(r.Pads) != seg.NofPads() {
+		t.Fatalf("want %d pads, got %d", seg.NofPads(), len(r.Pads))
+	}
+	p := r.Pads[3]
+	area := seg.PadSizeX(3) * seg.PadSizeY(3)
+	if p.Count != 2 || math.Abs(p.Rate-2/area/2) > 1e-9 {
+		t.Errorf("unexpected pad occupancy %v", p)
+	}
+	if rates := r.PadRates(706); rates[3] != p.Rate {
+		t.Errorf("want pad rate %v, got %v", p.Rate, rates[3])
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	c := uniformCounter(t, 706, 20, func(mapping.Segmentation, mapping.PadUID) int { return -1 })
+	r, _ := c.Result(1)
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	r2, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r2.Pads) != len(r.Pads) || r2.Pads[10] != r.Pads[10] {
+		t.Errorf("round trip failed")
+	}
+	if _, err := ReadJSON(bytes.NewBufferString(`{"Version":42}`)); err != ErrUnsupportedVersion {
+		t.Errorf("want ErrUnsupportedVersion, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package precluster_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+	"github.com/mrrtf/pigiron/precluster"
+)
+
+// generateRandomDigits returns digits for (approximately) a fraction
+// occupancy of the pads of the segmentation, by throwing random
+// positions within the segmentation bounding box.
+func generateRandomDigits(seg mapping.Segmentation, occupancy float64) []digit.Digit {
+	bbox := mapping.ComputeSegmentationBBox(seg)
+	n := int(occupancy * float64(seg.NofPads()) / 2)
+	fired := make(map[mapping.PadUID]bool)
+	var digits []digit.Digit
+	for i := 0; i < n; i++ {
+		x := bbox.Xmin() + rand.Float64()*bbox.Width()
+		y := bbox.Ymin() + rand.Float64()*bbox.Height()
+		b, nb, _ := seg.FindPadPairByPosition(x, y)
+		for _, p := range []mapping.PadUID{b, nb} {
+			if seg.IsValid(p) && !fired[p] {
+				fired[p] = true
+				digits = append(digits, digit.Digit{DEID: seg.DetElemID(), PadUID: p, ADC: 100})
+			}
+		}
+	}
+	return digits
+}
+
+func BenchmarkPreclusterer(b *testing.B) {
+	for _, deid := range []mapping.DEID{100, 300, 501, 1025} {
+		seg := mapping.NewSegmentation(deid)
+		for _, occupancy := range []float64{0.01, 0.05, 0.2} {
+			digits := generateRandomDigits(seg, occupancy)
+			b.Run(fmt.Sprintf("%d/%v", deid, occupancy), func(b *testing.B) {
+				p := precluster.NewPreclusterer(seg)
+				// warm up the internal buffers
+				p.Run(digits)
+				b.ResetTimer()
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_, _, err := p.Run(digits)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRandomOccupancyKeepsAllDigits(t *testing.T) {
+	seg := mapping.NewSegmentation(300)
+	digits := generateRandomDigits(seg, 0.05)
+	p := precluster.NewPreclusterer(seg)
+	pcs, out, err := p.Run(digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, pc := range pcs {
+		n += len(pc.Digits(out))
+	}
+	if n != len(digits) || len(out) != len(digits) {
+		t.Errorf("expected %d digits in preclusters and got %d", len(digits), n)
+	}
+}
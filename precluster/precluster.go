@@ -0,0 +1,317 @@
+package precluster
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+var (
+	// ErrInvalidPad signals a digit with a pad uid that is not valid
+	// for the segmentation used by the preclusterer.
+	ErrInvalidPad = errors.New("invalid pad uid in digit")
+	// ErrWrongDEID signals a digit which does not belong to the detection
+	// element of the preclusterer.
+	ErrWrongDEID = errors.New("digit does not belong to the preclusterer detection element")
+)
+
+// Precluster is a group of digits whose pads are connected, either
+// directly (neighbouring pads of the same cathode) or through the
+// overlap of pads of the other cathode.
+// A precluster refers to a range of the digit slice returned by Run.
+type Precluster struct {
+	FirstIdx         int
+	NofDigits        int
+	NofBendingDigits int
+}
+
+// NofNonBendingDigits returns the number of digits of the precluster
+// that are on the non-bending cathode.
+func (pc Precluster) NofNonBendingDigits() int {
+	return pc.NofDigits - pc.NofBendingDigits
+}
+
+// Digits returns the part of digits which belongs to this precluster.
+func (pc Precluster) Digits(digits []digit.Digit) []digit.Digit {
+	return digits[pc.FirstIdx : pc.FirstIdx+pc.NofDigits]
+}
+
+// Preclusterer groups the fired pads of one detection element into
+// preclusters. It keeps internal buffers that are reused from one
+// call to Run to the next, so one Preclusterer should be created per
+// detection element and reused for all the readout frames.
+// A Preclusterer is not safe for concurrent use.
+type Preclusterer struct {
+	seg        mapping.Segmentation
+	firedIndex []int32 // index of the digit for each paduid, -1 if not fired
+	neighbours []int
+	parent     []int
+	bbox       []padBox
+	group      []int
+	groups     []group
+	// indices of the non-bending groups
+	nonBendingGroups []int
+	counts           []int
+	digits           []digit.Digit
+	pcs              []Precluster
+}
+
+type padBox struct {
+	xmin, ymin, xmax, ymax float64
+}
+
+func (a padBox) overlaps(b padBox) bool {
+	const eps = 1e-4 // same precision as geo.EqualFloat
+	return a.xmin < b.xmax-eps && b.xmin < a.xmax-eps &&
+		a.ymin < b.ymax-eps && b.ymin < a.ymax-eps
+}
+
+func (a *padBox) extend(b padBox) {
+	if b.xmin < a.xmin {
+		a.xmin = b.xmin
+	}
+	if b.ymin < a.ymin {
+		a.ymin = b.ymin
+	}
+	if b.xmax > a.xmax {
+		a.xmax = b.xmax
+	}
+	if b.ymax > a.ymax {
+		a.ymax = b.ymax
+	}
+}
+
+// group is a set of connected digits of one cathode
+type group struct {
+	root      int
+	isBending bool
+	box       padBox
+	members   []int
+}
+
+// NewPreclusterer returns a preclusterer for the detection element
+// described by seg.
+func NewPreclusterer(seg mapping.Segmentation) *Preclusterer {
+	p := &Preclusterer{
+		seg:        seg,
+		firedIndex: make([]int32, seg.NofPads()),
+		neighbours: make([]int, 13),
+	}
+	for i := range p.firedIndex {
+		p.firedIndex[i] = -1
+	}
+	return p
+}
+
+func (p *Preclusterer) find(i int) int {
+	for p.parent[i] != i {
+		p.parent[i] = p.parent[p.parent[i]]
+		i = p.parent[i]
+	}
+	return i
+}
+
+func (p *Preclusterer) union(i, j int) {
+	ri := p.find(i)
+	rj := p.find(j)
+	if ri == rj {
+		return
+	}
+	if ri < rj {
+		p.parent[rj] = ri
+	} else {
+		p.parent[ri] = rj
+	}
+}
+
+func (p *Preclusterer) reset(digits []digit.Digit) {
+	for _, d := range digits {
+		if int(d.PadUID) >= 0 && int(d.PadUID) < len(p.firedIndex) {
+			p.firedIndex[d.PadUID] = -1
+		}
+	}
+}
+
+// Run groups the digits (of one detection element and one readout frame)
+// into preclusters.
+//
+// The returned digits are the input digits reordered so that the digits
+// of each precluster are contiguous. Both returned slices are owned by the
+// preclusterer and are only valid until the next call to Run.
+//
+// The grouping is done in two steps : first the fired pads of each cathode
+// are grouped into connected components using the neighbour relations of the
+// segmentation, then the groups of the bending and non-bending cathodes
+// which overlap spatially are merged together.
+func (p *Preclusterer) Run(digits []digit.Digit) ([]Precluster, []digit.Digit, error) {
+	defer p.reset(digits)
+
+	n := len(digits)
+	p.parent = resizeInts(p.parent, n)
+	p.bbox = p.bbox[:0]
+	deid := p.seg.DetElemID()
+
+	for i, d := range digits {
+		p.parent[i] = i
+		if d.DEID != deid {
+			return nil, nil, ErrWrongDEID
+		}
+		if int(d.PadUID) < 0 || int(d.PadUID) >= len(p.firedIndex) || !p.seg.IsValid(d.PadUID) {
+			return nil, nil, ErrInvalidPad
+		}
+		if j := p.firedIndex[d.PadUID]; j >= 0 {
+			// same pad twice : consider those digits connected
+			p.union(i, int(j))
+		} else {
+			p.firedIndex[d.PadUID] = int32(i)
+		}
+		var b padBox
+		mapping.ComputePadBBox(p.seg, d.PadUID, &b.xmin, &b.ymin, &b.xmax, &b.ymax)
+		p.bbox = append(p.bbox, b)
+	}
+
+	// step 1 : connected components within each cathode
+	for i, d := range digits {
+		nn := p.seg.GetNeighbourIDs(d.PadUID, p.neighbours)
+		for _, nei := range p.neighbours[:nn] {
+			if j := p.firedIndex[nei]; j >= 0 {
+				p.union(i, int(j))
+			}
+		}
+	}
+
+	// step 2 : merge overlapping groups of different cathodes
+	p.makeGroups(digits)
+	for _, gb := range p.groups {
+		if !gb.isBending {
+			continue
+		}
+		// non-bending groups are sorted by xmin (see makeGroups)
+		for _, k := range p.nonBendingGroups {
+			gnb := &p.groups[k]
+			if gnb.box.xmin >= gb.box.xmax {
+				break
+			}
+			if !gb.box.overlaps(gnb.box) || p.find(gb.root) == p.find(gnb.root) {
+				continue
+			}
+			if p.groupsOverlap(gb, gnb) {
+				p.union(gb.root, gnb.root)
+			}
+		}
+	}
+
+	p.fillPreclusters(digits)
+	return p.pcs, p.digits, nil
+}
+
+func (p *Preclusterer) makeGroups(digits []digit.Digit) {
+	n := len(digits)
+	p.group = resizeInts(p.group, n)
+	for i := range p.group {
+		p.group[i] = -1
+	}
+	p.groups = p.groups[:0]
+	ngroups := 0
+	for i := range digits {
+		r := p.find(i)
+		if p.group[r] < 0 {
+			if ngroups < cap(p.groups) {
+				p.groups = p.groups[:ngroups+1]
+			} else {
+				p.groups = append(p.groups, group{})
+			}
+			g := &p.groups[ngroups]
+			g.members = g.members[:0]
+			g.root = r
+			g.isBending = p.seg.IsBendingPad(digits[r].PadUID)
+			g.box = p.bbox[r]
+			p.group[r] = ngroups
+			ngroups++
+		}
+		g := &p.groups[p.group[r]]
+		g.box.extend(p.bbox[i])
+		g.members = append(g.members, i)
+	}
+	p.groups = p.groups[:ngroups]
+
+	p.nonBendingGroups = p.nonBendingGroups[:0]
+	for k := range p.groups {
+		if !p.groups[k].isBending {
+			p.nonBendingGroups = append(p.nonBendingGroups, k)
+		}
+	}
+	sort.Sort(byXmin{p.nonBendingGroups, p.groups})
+}
+
+// byXmin sorts group indices by increasing group bbox xmin
+type byXmin struct {
+	indices []int
+	groups  []group
+}
+
+func (s byXmin) Len() int      { return len(s.indices) }
+func (s byXmin) Swap(i, j int) { s.indices[i], s.indices[j] = s.indices[j], s.indices[i] }
+func (s byXmin) Less(i, j int) bool {
+	return s.groups[s.indices[i]].box.xmin < s.groups[s.indices[j]].box.xmin
+}
+
+func (p *Preclusterer) groupsOverlap(a group, b *group) bool {
+	for _, i := range a.members {
+		if !p.bbox[i].overlaps(b.box) {
+			continue
+		}
+		for _, j := range b.members {
+			if p.bbox[i].overlaps(p.bbox[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Preclusterer) fillPreclusters(digits []digit.Digit) {
+	n := len(digits)
+	// p.group is reused to hold the precluster index of each root
+	for i := range p.group {
+		p.group[i] = -1
+	}
+	p.pcs = p.pcs[:0]
+	p.counts = p.counts[:0]
+	for i := 0; i < n; i++ {
+		r := p.find(i)
+		if p.group[r] < 0 {
+			p.group[r] = len(p.pcs)
+			p.pcs = append(p.pcs, Precluster{})
+			p.counts = append(p.counts, 0)
+		}
+		pc := &p.pcs[p.group[r]]
+		pc.NofDigits++
+		if p.seg.IsBendingPad(digits[i].PadUID) {
+			pc.NofBendingDigits++
+		}
+	}
+	offset := 0
+	for i := range p.pcs {
+		p.pcs[i].FirstIdx = offset
+		offset += p.pcs[i].NofDigits
+	}
+	if cap(p.digits) < n {
+		p.digits = make([]digit.Digit, n)
+	}
+	p.digits = p.digits[:n]
+	for i, d := range digits {
+		k := p.group[p.find(i)]
+		p.digits[p.pcs[k].FirstIdx+p.counts[k]] = d
+		p.counts[k]++
+	}
+}
+
+func resizeInts(s []int, n int) []int {
+	if cap(s) < n {
+		return make([]int, n)
+	}
+	return s[:n]
+}
@@ -0,0 +1,165 @@
+package precluster
+
+import (
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+func digitsAt(t *testing.T, seg mapping.Segmentation, positions [][2]float64, bending bool) []digit.Digit {
+	var digits []digit.Digit
+	for _, pos := range positions {
+		b, nb, err := seg.FindPadPairByPosition(pos[0], pos[1])
+		if err != nil {
+			t.Fatalf("could not find pad at %v: %v", pos, err)
+		}
+		pad := nb
+		if bending {
+			pad = b
+		}
+		digits = append(digits, digit.Digit{DEID: seg.DetElemID(), PadUID: pad, ADC: 100})
+	}
+	return digits
+}
+
+func neighbourDigits(seg mapping.Segmentation, paduid mapping.PadUID) []digit.Digit {
+	nei := make([]int, 13)
+	n := seg.GetNeighbourIDs(paduid, nei)
+	digits := []digit.Digit{{DEID: seg.DetElemID(), PadUID: paduid}}
+	for _, p := range nei[:n] {
+		digits = append(digits, digit.Digit{DEID: seg.DetElemID(), PadUID: mapping.PadUID(p)})
+	}
+	return digits
+}
+
+func TestOnePrecluster(t *testing.T) {
+	seg := mapping.NewSegmentation(501)
+	b, _, err := seg.FindPadPairByPosition(10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digits := neighbourDigits(seg, b)
+	p := NewPreclusterer(seg)
+	pcs, out, err := p.Run(digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcs) != 1 {
+		t.Fatalf("expected 1 precluster and got %d", len(pcs))
+	}
+	if pcs[0].NofDigits != len(digits) || len(out) != len(digits) {
+		t.Errorf("expected %d digits and got %d", len(digits), pcs[0].NofDigits)
+	}
+	if pcs[0].NofNonBendingDigits() != 0 {
+		t.Errorf("expected only bending digits")
+	}
+}
+
+func TestTwoSeparatePreclusters(t *testing.T) {
+	seg := mapping.NewSegmentation(501)
+	digits := digitsAt(t, seg, [][2]float64{{-40, 0}, {40, 0}, {-40.5, 0}}, true)
+	p := NewPreclusterer(seg)
+	pcs, out, err := p.Run(digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcs) != 2 {
+		t.Fatalf("expected 2 preclusters and got %d", len(pcs))
+	}
+	if pcs[0].NofDigits != 2 || pcs[1].NofDigits != 1 {
+		t.Errorf("expected preclusters of 2 and 1 digits and got %v", pcs)
+	}
+	for _, d := range pcs[0].Digits(out) {
+		if seg.PadPositionX(d.PadUID) > 0 {
+			t.Errorf("first precluster should only contain pads at x<0")
+		}
+	}
+}
+
+func TestMergeBothCathodes(t *testing.T) {
+	seg := mapping.NewSegmentation(100)
+	pos := [][2]float64{{30, 30}}
+	digits := append(digitsAt(t, seg, pos, true), digitsAt(t, seg, pos, false)...)
+	p := NewPreclusterer(seg)
+	pcs, _, err := p.Run(digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcs) != 1 {
+		t.Fatalf("expected 1 precluster and got %d", len(pcs))
+	}
+	if pcs[0].NofBendingDigits != 1 || pcs[0].NofNonBendingDigits() != 1 {
+		t.Errorf("expected one digit on each cathode : got %v", pcs[0])
+	}
+}
+
+func TestNoMergeOfDistantCathodes(t *testing.T) {
+	seg := mapping.NewSegmentation(100)
+	digits := append(digitsAt(t, seg, [][2]float64{{30, 30}}, true),
+		digitsAt(t, seg, [][2]float64{{60, 10}}, false)...)
+	p := NewPreclusterer(seg)
+	pcs, _, err := p.Run(digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcs) != 2 {
+		t.Fatalf("expected 2 preclusters and got %d", len(pcs))
+	}
+}
+
+func TestNonBendingGroupBridgesTwoBendingGroups(t *testing.T) {
+	seg := mapping.NewSegmentation(501)
+	// non-bending pads are long in y, bending pads are long in x :
+	// two bending pads on top of each other but separated by one pad
+	// are connected by a single non-bending pad
+	b, nb, err := seg.FindPadPairByPosition(10.8, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dy := seg.PadSizeY(b)
+	if seg.PadSizeY(nb) < 3*dy {
+		t.Skip("test assumes non-bending pads are at least 3 times taller than bending pads")
+	}
+	digits := digitsAt(t, seg, [][2]float64{{10.8, 0.1}, {10.8, 0.1 + 2*dy}}, true)
+	p := NewPreclusterer(seg)
+	pcs, _, _ := p.Run(digits)
+	if len(pcs) != 2 {
+		t.Fatalf("expected 2 preclusters without non-bending pad and got %d", len(pcs))
+	}
+	digits = append(digits, digit.Digit{DEID: 501, PadUID: nb})
+	pcs, _, _ = p.Run(digits)
+	if len(pcs) != 1 {
+		t.Fatalf("expected 1 precluster with the non-bending pad and got %d", len(pcs))
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	seg := mapping.NewSegmentation(100)
+	p := NewPreclusterer(seg)
+	if _, _, err := p.Run([]digit.Digit{{DEID: 101}}); err != ErrWrongDEID {
+		t.Errorf("expected ErrWrongDEID and got %v", err)
+	}
+	if _, _, err := p.Run([]digit.Digit{{DEID: 100, PadUID: -1}}); err != ErrInvalidPad {
+		t.Errorf("expected ErrInvalidPad and got %v", err)
+	}
+	if _, _, err := p.Run([]digit.Digit{{DEID: 100, PadUID: mapping.PadUID(seg.NofPads())}}); err != ErrInvalidPad {
+		t.Errorf("expected ErrInvalidPad and got %v", err)
+	}
+}
+
+func TestRunIsReusable(t *testing.T) {
+	seg := mapping.NewSegmentation(501)
+	p := NewPreclusterer(seg)
+	digits := digitsAt(t, seg, [][2]float64{{-40, 0}, {40, 0}}, true)
+	for i := 0; i < 3; i++ {
+		pcs, _, err := p.Run(digits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pcs) != 2 {
+			t.Fatalf("iteration %d : expected 2 preclusters and got %d", i, len(pcs))
+		}
+	}
+}
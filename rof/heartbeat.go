@@ -0,0 +1,116 @@
+package rof
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/mrrtf/pigiron/digit"
+)
+
+const (
+	// SampaBCCounterRange is the range of the 20-bits bunch crossing
+	// counter of the SAMPA chips. The counter wraps around every
+	// 2^20 bunch crossings (i.e. every ~294 orbits).
+	SampaBCCounterRange = 1 << 20
+	// BCPerSample is the number of bunch crossings per SAMPA time sample
+	// (the SAMPA samples at 10 MHz while bunches cross at 40 MHz).
+	BCPerSample = 4
+)
+
+var (
+	// ErrNoHeartbeatFrame signals that no heartbeat frame could be
+	// found for a given orbit.
+	ErrNoHeartbeatFrame = errors.New("no heartbeat frame for this orbit")
+	// ErrInvalidTimeFrameLength signals a time frame without any orbit.
+	ErrInvalidTimeFrameLength = errors.New("time frame length should be > 0 orbits")
+)
+
+// HeartbeatFrame associates the orbit of a heartbeat frame with
+// the value of the SAMPA bunch crossing counter at the beginning
+// of that orbit. It is the reference used to convert SAMPA
+// timestamps into absolute orbit/bc.
+type HeartbeatFrame struct {
+	Orbit   uint32
+	SampaBC uint32
+}
+
+// SampaToBC converts a SAMPA bunch crossing counter value and a sample
+// index (within the SAMPA cluster starting at that counter value) into
+// a number of bunch crossings since the beginning of the heartbeat frame.
+// The counter wrap-around is taken into account.
+func (hb HeartbeatFrame) SampaToBC(sampaBC uint32, sample int) int64 {
+	delta := (int64(sampaBC) - int64(hb.SampaBC)) % SampaBCCounterRange
+	if delta < 0 {
+		delta += SampaBCCounterRange
+	}
+	return delta + int64(sample)*BCPerSample
+}
+
+// OrbitBC converts a SAMPA bunch crossing counter value and a sample
+// index into an absolute (orbit,bc) pair.
+func (hb HeartbeatFrame) OrbitBC(sampaBC uint32, sample int) (uint32, uint16) {
+	return fromTime(int64(hb.Orbit)*digit.BCPerOrbit + hb.SampaToBC(sampaBC, sample))
+}
+
+// HeartbeatFrames is a list of heartbeat frames, ordered by increasing orbit.
+type HeartbeatFrames []HeartbeatFrame
+
+// NewHeartbeatFrames returns a sorted copy of the given heartbeat frames.
+func NewHeartbeatFrames(hbfs ...HeartbeatFrame) HeartbeatFrames {
+	s := append(HeartbeatFrames(nil), hbfs...)
+	sort.Slice(s, func(i, j int) bool { return s[i].Orbit < s[j].Orbit })
+	return s
+}
+
+// Find returns the latest heartbeat frame starting at or before orbit.
+func (hbfs HeartbeatFrames) Find(orbit uint32) (HeartbeatFrame, error) {
+	i := sort.Search(len(hbfs), func(i int) bool { return hbfs[i].Orbit > orbit })
+	if i == 0 {
+		return HeartbeatFrame{}, ErrNoHeartbeatFrame
+	}
+	return hbfs[i-1], nil
+}
+
+// TimeFrame is a set of consecutive orbits that are
+// processed together.
+type TimeFrame struct {
+	FirstOrbit uint32
+	NofOrbits  uint32
+}
+
+// DefaultNofOrbitsPerTimeFrame is the default length of a time frame.
+const DefaultNofOrbitsPerTimeFrame = 128
+
+// TimeFrameOf returns the time frame containing the orbit, for
+// time frames of nofOrbits orbits starting at orbit 0.
+func TimeFrameOf(orbit uint32, nofOrbits uint32) (TimeFrame, error) {
+	if nofOrbits == 0 {
+		return TimeFrame{}, ErrInvalidTimeFrameLength
+	}
+	return TimeFrame{FirstOrbit: orbit - orbit%nofOrbits, NofOrbits: nofOrbits}, nil
+}
+
+// Contains returns true if the orbit is part of the time frame.
+func (tf TimeFrame) Contains(orbit uint32) bool {
+	return orbit >= tf.FirstOrbit && orbit-tf.FirstOrbit < tf.NofOrbits
+}
+
+// SplitTimeFrames splits (sorted) digits into chunks belonging to
+// consecutive time frames of nofOrbits orbits, and call the handler
+// for each of those chunks.
+func SplitTimeFrames(digits []digit.Digit, nofOrbits uint32, handler func(tf TimeFrame, digits []digit.Digit)) error {
+	if nofOrbits == 0 {
+		return ErrInvalidTimeFrameLength
+	}
+	first := 0
+	for first < len(digits) {
+		tf, _ := TimeFrameOf(digits[first].Orbit, nofOrbits)
+		last := first + 1
+		for last < len(digits) && tf.Contains(digits[last].Orbit) {
+			last++
+		}
+		handler(tf, digits[first:last])
+		first = last
+	}
+	return nil
+}
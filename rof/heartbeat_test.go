@@ -0,0 +1,93 @@
+package rof
+
+import (
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+)
+
+func TestHeartbeatFrameOrbitBC(t *testing.T) {
+	hb := HeartbeatFrame{Orbit: 10, SampaBC: 1000}
+	for _, test := range []struct {
+		sampaBC uint32
+		sample  int
+		orbit   uint32
+		bc      uint16
+	}{
+		{1000, 0, 10, 0},
+		{1010, 0, 10, 10},
+		{1010, 2, 10, 18},
+		{1000 + digit.BCPerOrbit, 0, 11, 0},
+		{1000 + digit.BCPerOrbit + 5, 1, 11, 9},
+	} {
+		orbit, bc := hb.OrbitBC(test.sampaBC, test.sample)
+		if orbit != test.orbit || bc != test.bc {
+			t.Errorf("sampaBC %d sample %d : expected (%d,%d) and got (%d,%d)",
+				test.sampaBC, test.sample, test.orbit, test.bc, orbit, bc)
+		}
+	}
+}
+
+func TestHeartbeatFrameCounterWrapAround(t *testing.T) {
+	hb := HeartbeatFrame{Orbit: 0, SampaBC: SampaBCCounterRange - 10}
+	if bc := hb.SampaToBC(5, 0); bc != 15 {
+		t.Errorf("expected 15 BCs since heartbeat and got %d", bc)
+	}
+}
+
+func TestHeartbeatFramesFind(t *testing.T) {
+	hbfs := NewHeartbeatFrames(
+		HeartbeatFrame{Orbit: 20, SampaBC: 2},
+		HeartbeatFrame{Orbit: 10, SampaBC: 1},
+	)
+	if _, err := hbfs.Find(9); err != ErrNoHeartbeatFrame {
+		t.Errorf("expected ErrNoHeartbeatFrame and got %v", err)
+	}
+	for _, test := range []struct {
+		orbit    uint32
+		expected uint32
+	}{{10, 10}, {15, 10}, {20, 20}, {1000, 20}} {
+		hb, err := hbfs.Find(test.orbit)
+		if err != nil || hb.Orbit != test.expected {
+			t.Errorf("orbit %d : expected hbf %d and got %v (%v)", test.orbit, test.expected, hb, err)
+		}
+	}
+}
+
+func TestSplitTimeFrames(t *testing.T) {
+	digits := []digit.Digit{{Orbit: 1}, {Orbit: 127}, {Orbit: 128}, {Orbit: 300}, {Orbit: 301}}
+	var tfs []TimeFrame
+	var sizes []int
+	err := SplitTimeFrames(digits, DefaultNofOrbitsPerTimeFrame, func(tf TimeFrame, dd []digit.Digit) {
+		tfs = append(tfs, tf)
+		sizes = append(sizes, len(dd))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		first uint32
+		n     int
+	}{{0, 2}, {128, 1}, {256, 2}}
+	if len(tfs) != len(expected) {
+		t.Fatalf("expected %d time frames and got %d", len(expected), len(tfs))
+	}
+	for i, e := range expected {
+		if tfs[i].FirstOrbit != e.first || sizes[i] != e.n {
+			t.Errorf("tf %d : expected first orbit %d with %d digits and got %v with %d digits",
+				i, e.first, e.n, tfs[i], sizes[i])
+		}
+	}
+}
+
+func TestInvalidTimeFrameLength(t *testing.T) {
+	if _, err := TimeFrameOf(10, 0); err != ErrInvalidTimeFrameLength {
+		t.Errorf("expected ErrInvalidTimeFrameLength and got %v", err)
+	}
+	err := SplitTimeFrames([]digit.Digit{{Orbit: 1}}, 0, func(tf TimeFrame, dd []digit.Digit) {
+		t.Errorf("handler should not be called")
+	})
+	if err != ErrInvalidTimeFrameLength {
+		t.Errorf("expected ErrInvalidTimeFrameLength and got %v", err)
+	}
+}
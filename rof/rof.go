@@ -0,0 +1,190 @@
+package rof
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// Mode selects the way digits are grouped into readout frames.
+type Mode int
+
+const (
+	// FixedWindows groups digits into consecutive windows of Width
+	// bunch crossings, aligned on the start of each orbit.
+	// The last window of an orbit may be shorter than Width.
+	FixedWindows Mode = iota
+	// TimeClusters groups digits as long as the time difference between
+	// two consecutive digits is at most Width bunch crossings.
+	TimeClusters
+)
+
+func (m Mode) String() string {
+	switch m {
+	case FixedWindows:
+		return "FixedWindows"
+	case TimeClusters:
+		return "TimeClusters"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Config describes how to group digits into readout frames.
+type Config struct {
+	Mode  Mode
+	Width int // in bunch crossings
+}
+
+// DefaultConfig corresponds to windows of 100 ns (one SAMPA sample).
+var DefaultConfig = Config{Mode: FixedWindows, Width: 4}
+
+var (
+	// ErrInvalidWidth signals a non-positive time window.
+	ErrInvalidWidth = errors.New("readout frame width should be > 0")
+	// ErrUnsortedDigits signals digits not in the order defined by digit.Sort.
+	ErrUnsortedDigits = errors.New("digits must be sorted to be grouped into readout frames")
+	// ErrMixedDEIDs signals digits from several detection elements.
+	ErrMixedDEIDs = errors.New("digits must belong to a single detection element")
+	// ErrUnknownMode signals an invalid grouping mode.
+	ErrUnknownMode = errors.New("unknown readout frame grouping mode")
+)
+
+// ROF is a readout frame : a time window of one detection element
+// and the range of the digits within that window. Digits of one ROF
+// are close enough in time to be clustered together.
+// The digits themselves are not part of the ROF, which only
+// refers to a (sorted) slice of digits by index.
+type ROF struct {
+	DEID      mapping.DEID
+	Orbit     uint32
+	BC        uint16
+	Width     int // in bunch crossings
+	FirstIdx  int
+	NofDigits int
+}
+
+// Time returns the start time of the ROF expressed in number of
+// bunch crossings since orbit 0.
+func (r ROF) Time() int64 {
+	return int64(r.Orbit)*digit.BCPerOrbit + int64(r.BC)
+}
+
+// Contains returns true if the time t (in bunch crossings since orbit 0)
+// falls within the ROF.
+func (r ROF) Contains(t int64) bool {
+	return t >= r.Time() && t < r.Time()+int64(r.Width)
+}
+
+// Digits returns the part of digits which belongs to this ROF.
+// digits must be the slice that was used to create the ROF.
+func (r ROF) Digits(digits []digit.Digit) []digit.Digit {
+	return digits[r.FirstIdx : r.FirstIdx+r.NofDigits]
+}
+
+func (r ROF) String() string {
+	return fmt.Sprintf("ROF DE %4d ORBIT %10d BC %4d WIDTH %4d DIGITS [%d,%d[",
+		r.DEID, r.Orbit, r.BC, r.Width, r.FirstIdx, r.FirstIdx+r.NofDigits)
+}
+
+func fromTime(t int64) (uint32, uint16) {
+	return uint32(t / digit.BCPerOrbit), uint16(t % digit.BCPerOrbit)
+}
+
+// Group splits the digits of one detection element into readout frames.
+// The digits must be sorted (see digit.Sort).
+func Group(digits []digit.Digit, cfg Config) ([]ROF, error) {
+	if cfg.Width <= 0 {
+		return nil, ErrInvalidWidth
+	}
+	if len(digits) == 0 {
+		return nil, nil
+	}
+	deid := digits[0].DEID
+	for i, d := range digits {
+		if d.DEID != deid {
+			return nil, ErrMixedDEIDs
+		}
+		if i > 0 && d.Time() < digits[i-1].Time() {
+			return nil, ErrUnsortedDigits
+		}
+	}
+	switch cfg.Mode {
+	case FixedWindows:
+		return groupFixed(deid, digits, cfg.Width), nil
+	case TimeClusters:
+		return groupClusters(deid, digits, cfg.Width), nil
+	}
+	return nil, ErrUnknownMode
+}
+
+func groupFixed(deid mapping.DEID, digits []digit.Digit, width int) []ROF {
+	var rofs []ROF
+	for i, d := range digits {
+		bc := int(d.BC) - int(d.BC)%width
+		if len(rofs) > 0 {
+			last := &rofs[len(rofs)-1]
+			if last.Orbit == d.Orbit && int(last.BC) == bc {
+				last.NofDigits++
+				continue
+			}
+		}
+		w := width
+		if bc+w > digit.BCPerOrbit {
+			w = digit.BCPerOrbit - bc
+		}
+		rofs = append(rofs, ROF{DEID: deid, Orbit: d.Orbit, BC: uint16(bc), Width: w, FirstIdx: i, NofDigits: 1})
+	}
+	return rofs
+}
+
+func groupClusters(deid mapping.DEID, digits []digit.Digit, width int) []ROF {
+	var rofs []ROF
+	var last int64
+	for i, d := range digits {
+		t := d.Time()
+		if len(rofs) > 0 && t-last <= int64(width) {
+			r := &rofs[len(rofs)-1]
+			r.NofDigits++
+			r.Width = int(t-r.Time()) + 1
+			last = t
+			continue
+		}
+		orbit, bc := fromTime(t)
+		rofs = append(rofs, ROF{DEID: deid, Orbit: orbit, BC: bc, Width: 1, FirstIdx: i, NofDigits: 1})
+		last = t
+	}
+	return rofs
+}
+
+// GroupContainer splits the digits of each detection element of the container
+// into readout frames. The ROFs refer to the slices returned by c.Digits(deid).
+func GroupContainer(c *digit.Container, cfg Config) (map[mapping.DEID][]ROF, error) {
+	rofs := make(map[mapping.DEID][]ROF)
+	for _, deid := range c.DEIDs() {
+		r, err := Group(c.Digits(deid), cfg)
+		if err != nil {
+			return nil, err
+		}
+		rofs[deid] = r
+	}
+	return rofs, nil
+}
+
+// ForEachROF calls the handler for each readout frame of each detection
+// element of the container, in increasing deid order, and in time order
+// within one detection element.
+func ForEachROF(c *digit.Container, cfg Config, handler func(r ROF, digits []digit.Digit)) error {
+	for _, deid := range c.DEIDs() {
+		digits := c.Digits(deid)
+		rofs, err := Group(digits, cfg)
+		if err != nil {
+			return err
+		}
+		for _, r := range rofs {
+			handler(r, r.Digits(digits))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package rof
+
+import (
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+var testDigits = []digit.Digit{
+	{DEID: 100, PadUID: 1, Orbit: 1, BC: 0},
+	{DEID: 100, PadUID: 2, Orbit: 1, BC: 3},
+	{DEID: 100, PadUID: 3, Orbit: 1, BC: 4},
+	{DEID: 100, PadUID: 4, Orbit: 1, BC: 6},
+	{DEID: 100, PadUID: 5, Orbit: 1, BC: 20},
+	{DEID: 100, PadUID: 6, Orbit: 1, BC: 3563},
+	{DEID: 100, PadUID: 7, Orbit: 2, BC: 0},
+}
+
+func TestGroupFixedWindows(t *testing.T) {
+	rofs, err := Group(testDigits, Config{Mode: FixedWindows, Width: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ROF{
+		{DEID: 100, Orbit: 1, BC: 0, Width: 4, FirstIdx: 0, NofDigits: 2},
+		{DEID: 100, Orbit: 1, BC: 4, Width: 4, FirstIdx: 2, NofDigits: 2},
+		{DEID: 100, Orbit: 1, BC: 20, Width: 4, FirstIdx: 4, NofDigits: 1},
+		{DEID: 100, Orbit: 1, BC: 3560, Width: 4, FirstIdx: 5, NofDigits: 1},
+		{DEID: 100, Orbit: 2, BC: 0, Width: 4, FirstIdx: 6, NofDigits: 1},
+	}
+	if len(rofs) != len(expected) {
+		t.Fatalf("expected %d rofs and got %d : %v", len(expected), len(rofs), rofs)
+	}
+	for i, r := range rofs {
+		if r != expected[i] {
+			t.Errorf("rof %d : expected %v and got %v", i, expected[i], r)
+		}
+	}
+}
+
+func TestGroupFixedWindowsLastWindowOfOrbitIsShorter(t *testing.T) {
+	rofs, err := Group(testDigits[5:6], Config{Mode: FixedWindows, Width: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rofs[0].BC != 3560 || rofs[0].Width != 4 {
+		t.Errorf("expected a 4 BC wide window starting at 3560, got %v", rofs[0])
+	}
+}
+
+func TestGroupTimeClusters(t *testing.T) {
+	rofs, err := Group(testDigits, Config{Mode: TimeClusters, Width: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ROF{
+		{DEID: 100, Orbit: 1, BC: 0, Width: 7, FirstIdx: 0, NofDigits: 4},
+		{DEID: 100, Orbit: 1, BC: 20, Width: 1, FirstIdx: 4, NofDigits: 1},
+		{DEID: 100, Orbit: 1, BC: 3563, Width: 2, FirstIdx: 5, NofDigits: 2},
+	}
+	if len(rofs) != len(expected) {
+		t.Fatalf("expected %d rofs and got %d : %v", len(expected), len(rofs), rofs)
+	}
+	for i, r := range rofs {
+		if r != expected[i] {
+			t.Errorf("rof %d : expected %v and got %v", i, expected[i], r)
+		}
+	}
+	if !rofs[2].Contains(testDigits[6].Time()) {
+		t.Errorf("last rof should contain last digit")
+	}
+	if n := len(rofs[0].Digits(testDigits)); n != 4 {
+		t.Errorf("expected 4 digits in first rof and got %d", n)
+	}
+}
+
+func TestGroupErrors(t *testing.T) {
+	if _, err := Group(testDigits, Config{Width: 0}); err != ErrInvalidWidth {
+		t.Errorf("expected ErrInvalidWidth and got %v", err)
+	}
+	unsorted := []digit.Digit{testDigits[1], testDigits[0]}
+	if _, err := Group(unsorted, DefaultConfig); err != ErrUnsortedDigits {
+		t.Errorf("expected ErrUnsortedDigits and got %v", err)
+	}
+	mixed := []digit.Digit{testDigits[0], {DEID: 101}}
+	if _, err := Group(mixed, DefaultConfig); err != ErrMixedDEIDs {
+		t.Errorf("expected ErrMixedDEIDs and got %v", err)
+	}
+	if _, err := Group(testDigits, Config{Mode: 42, Width: 1}); err != ErrUnknownMode {
+		t.Errorf("expected ErrUnknownMode and got %v", err)
+	}
+}
+
+func TestGroupContainer(t *testing.T) {
+	c := digit.NewContainer()
+	for _, d := range testDigits {
+		c.Add(d)
+		d.DEID = 501
+		c.Add(d)
+	}
+	rofs, err := GroupContainer(c, DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rofs) != 2 || len(rofs[100]) != 5 || len(rofs[501]) != 5 {
+		t.Errorf("unexpected rofs %v", rofs)
+	}
+	n := 0
+	err = ForEachROF(c, DefaultConfig, func(r ROF, digits []digit.Digit) {
+		for _, d := range digits {
+			if d.DEID != r.DEID || !r.Contains(d.Time()) {
+				t.Errorf("digit %v not within %v", d, r)
+			}
+		}
+		n += len(digits)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != c.Len() {
+		t.Errorf("expected %d digits and got %d", c.Len(), n)
+	}
+	var deids []mapping.DEID
+	for deid := range rofs {
+		deids = append(deids, deid)
+	}
+	if len(deids) != 2 {
+		t.Errorf("expected 2 deids and got %v", deids)
+	}
+}
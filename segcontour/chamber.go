@@ -0,0 +1,223 @@
+package segcontour
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/transform"
+)
+
+var ErrInvalidChamber = errors.New("chamber should be between 1 and 10")
+
+// DualSampaOutline is the contour of one dual sampa.
+type DualSampaOutline struct {
+	ID      int         `json:"id"`
+	Contour geo.Contour `json:"contour"`
+}
+
+// DEOutline is the contour of one cathode of a detection element
+// and of its dual sampas, in global coordinates.
+type DEOutline struct {
+	DEID       int                `json:"deid"`
+	Bending    bool               `json:"bending"`
+	Contour    geo.Contour        `json:"contour"`
+	DualSampas []DualSampaOutline `json:"dualsampas"`
+}
+
+// NewDEOutline returns the outline of one cathode of a detection element,
+// placed in global coordinates (see transform.ForDetectionElement).
+func NewDEOutline(deid mapping.DEID, bending bool) (DEOutline, error) {
+	t, err := transform.ForDetectionElement(deid)
+	if err != nil {
+		return DEOutline{}, err
+	}
+	cseg := mapping.NewCathodeSegmentation(deid, bending)
+	o := DEOutline{DEID: int(deid), Bending: bending}
+	var polygons []geo.Polygon
+	for i := 0; i < cseg.NofDualSampas(); i++ {
+		dsid, err := cseg.DualSampaID(i)
+		if err != nil {
+			return DEOutline{}, err
+		}
+		c := GetDualSampaContour(cseg, dsid)
+		polygons = append(polygons, c...)
+		o.DualSampas = append(o.DualSampas, DualSampaOutline{int(dsid), transformContour(t, c)})
+	}
+	contour, err := geo.NewContour(polygons)
+	if err != nil {
+		return DEOutline{}, fmt.Errorf("could not get contour of detection element %d: %v", deid, err)
+	}
+	o.Contour = transformContour(t, contour)
+	return o, nil
+}
+
+// transformContour returns the contour c (in local coordinates)
+// in global coordinates.
+func transformContour(t transform.Transformation, c geo.Contour) geo.Contour {
+	g := make(geo.Contour, len(c))
+	for i, p := range c {
+		g[i] = t.Polygon(p)
+	}
+	return g
+}
+
+// translate moves the outline by (dx,dy).
+func (o DEOutline) translate(dx, dy float64) DEOutline {
+	move := func(c geo.Contour) geo.Contour {
+		m := make(geo.Contour, len(c))
+		for i, p := range c {
+			m[i] = make(geo.Polygon, len(p))
+			for j, v := range p {
+				m[i][j] = geo.Vertex{X: v.X + dx, Y: v.Y + dy}
+			}
+		}
+		return m
+	}
+	t := DEOutline{DEID: o.DEID, Bending: o.Bending, Contour: move(o.Contour)}
+	for _, ds := range o.DualSampas {
+		t.DualSampas = append(t.DualSampas, DualSampaOutline{ds.ID, move(ds.Contour)})
+	}
+	return t
+}
+
+// ChamberOutlines returns the outlines of all the detection elements
+// of one chamber (1..10), ordered by detection element id.
+func ChamberOutlines(chamber int, bending bool) ([]DEOutline, error) {
+	if chamber < 1 || chamber > 10 {
+		return nil, ErrInvalidChamber
+	}
+	var deids []int
+	mapping.ForEachDetectionElement(func(deid mapping.DEID) {
+		if int(deid)/100 == chamber {
+			deids = append(deids, int(deid))
+		}
+	})
+	sort.Ints(deids)
+	outlines := make([]DEOutline, 0, len(deids))
+	for _, deid := range deids {
+		o, err := NewDEOutline(mapping.DEID(deid), bending)
+		if err != nil {
+			return nil, err
+		}
+		outlines = append(outlines, o)
+	}
+	return outlines, nil
+}
+
+// WriteJSON outputs the outlines as a JSON array.
+func WriteJSON(out io.Writer, outlines []DEOutline) error {
+	return json.NewEncoder(out).Encode(outlines)
+}
+
+// outlinesBBox returns the bounding box of a list of outlines.
+func outlinesBBox(outlines []DEOutline) geo.BBox {
+	xmin, ymin := math.MaxFloat64, math.MaxFloat64
+	xmax, ymax := -xmin, -ymin
+	for _, o := range outlines {
+		b := o.Contour.BBox()
+		xmin = math.Min(xmin, b.Xmin())
+		ymin = math.Min(ymin, b.Ymin())
+		xmax = math.Max(xmax, b.Xmax())
+		ymax = math.Max(ymax, b.Ymax())
+	}
+	return geo.NewBBoxUnchecked(xmin, ymin, xmax, ymax)
+}
+
+// SVGOutlines draws the contours and dual sampas of some detection elements,
+// each one being labelled with its id (delabel CSS class).
+func SVGOutlines(outlines []DEOutline, w geo.Canvas) {
+	w.GroupStart("dualsampas")
+	for _, o := range outlines {
+		for _, ds := range o.DualSampas {
+			for _, p := range ds.Contour {
+				w.PolygonWithClass(&p, fmt.Sprintf("polds DS%d", ds.ID))
+			}
+		}
+	}
+	w.GroupEnd()
+	w.GroupStart("detectionelements")
+	for _, o := range outlines {
+		for _, p := range o.Contour {
+			w.PolygonWithClass(&p, fmt.Sprintf("DE%d", o.DEID))
+		}
+	}
+	w.GroupEnd()
+	w.GroupStart("delabels")
+	for _, o := range outlines {
+		b := o.Contour.BBox()
+		size := 0.25 * math.Min(b.Width(), b.Height())
+		w.CenteredText(fmt.Sprintf("%d", o.DEID), b.Xcenter(), b.Ycenter(), size, "delabel")
+	}
+	w.GroupEnd()
+}
+
+// SVGChamber draws all the detection elements of one chamber
+// in global coordinates.
+func SVGChamber(chamber int, bending bool, w geo.Canvas) error {
+	outlines, err := ChamberOutlines(chamber, bending)
+	if err != nil {
+		return err
+	}
+	w.GroupStart(fmt.Sprintf("chamber CH%d", chamber))
+	SVGOutlines(outlines, w)
+	w.GroupEnd()
+	return nil
+}
+
+// SpectrometerOutlines returns the outlines of all the detection elements
+// of the 10 chambers, each chamber being moved to one cell of a grid of
+// 5 columns (stations) and 2 rows, the beam axis being at the center of
+// each cell. It also returns the bounding box of each cell.
+func SpectrometerOutlines(bending bool) ([][]DEOutline, []geo.BBox, error) {
+	chambers := make([][]DEOutline, 10)
+	var width, height float64
+	for i := range chambers {
+		outlines, err := ChamberOutlines(i+1, bending)
+		if err != nil {
+			return nil, nil, err
+		}
+		b := outlinesBBox(outlines)
+		// the cells are centered on the beam axis (0,0)
+		width = math.Max(width, 2*math.Max(math.Abs(b.Xmin()), math.Abs(b.Xmax())))
+		height = math.Max(height, 2*math.Max(math.Abs(b.Ymin()), math.Abs(b.Ymax())))
+		chambers[i] = outlines
+	}
+	// keep some space between cells
+	width *= 1.05
+	height *= 1.05
+	cells := make([]geo.BBox, 10)
+	for i, outlines := range chambers {
+		col := i / 2
+		row := i % 2
+		dx := (float64(col) + 0.5) * width
+		dy := (float64(row) + 0.5) * height
+		for j := range outlines {
+			outlines[j] = outlines[j].translate(dx, dy)
+		}
+		cells[i] = geo.NewBBoxUnchecked(dx-width/2, dy-height/2, dx+width/2, dy+height/2)
+	}
+	return chambers, cells, nil
+}
+
+// SVGSpectrometer draws the 10 chambers in a grid (see SpectrometerOutlines),
+// each chamber being labelled (chamberlabel CSS class).
+func SVGSpectrometer(bending bool, w geo.Canvas) error {
+	chambers, cells, err := SpectrometerOutlines(bending)
+	if err != nil {
+		return err
+	}
+	for i, outlines := range chambers {
+		w.GroupStart(fmt.Sprintf("chamber CH%d", i+1))
+		SVGOutlines(outlines, w)
+		c := cells[i]
+		w.CenteredText(fmt.Sprintf("CH%d", i+1), c.Xmin()+0.1*c.Width(), c.Ymin()+0.05*c.Height(), 0.04*c.Height(), "chamberlabel")
+		w.GroupEnd()
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package segcontour
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/geo"
+)
+
+func TestChamberOutlines(t *testing.T) {
+	for _, tc := range []struct {
+		chamber, n int
+	}{{1, 4}, {4, 4}, {5, 18}, {10, 26}} {
+		outlines, err := ChamberOutlines(tc.chamber, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(outlines) != tc.n {
+			t.Errorf("chamber %d: want %d detection elements, got %d", tc.chamber, tc.n, len(outlines))
+		}
+	}
+	if _, err := ChamberOutlines(11, true); err != ErrInvalidChamber {
+		t.Errorf("want ErrInvalidChamber, got %v", err)
+	}
+}
+
+func TestQuadrantsAreInTheirQuadrant(t *testing.T) {
+	outlines, err := ChamberOutlines(1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// DE x00 in (x>0,y>0), the others following counter-clockwise
+	signs := [][2]float64{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+	for i, o := range outlines {
+		c := o.Contour.BBox()
+		if c.Xcenter()*signs[i][0] <= 0 || c.Ycenter()*signs[i][1] <= 0 {
+			t.Errorf("DE%d is not in the expected quadrant: %v", o.DEID, c)
+		}
+	}
+}
+
+func TestChamberJSON(t *testing.T) {
+	outlines, err := ChamberOutlines(2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, outlines); err != nil {
+		t.Fatal(err)
+	}
+	var back []DEOutline
+	if err := json.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != 4 || back[1].DEID != 201 || len(back[1].DualSampas) != len(outlines[1].DualSampas) {
+		t.Errorf("JSON round trip failed")
+	}
+	if !strings.Contains(buf.String(), `"contour":[[{"X":`) {
+		t.Errorf("unexpected JSON format: %s", buf.String()[:100])
+	}
+}
+
+func TestSVGChamber(t *testing.T) {
+	w := geo.NewSVGWriter(1024)
+	if err := SVGChamber(5, true, w); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w.WriteSVG(&buf)
+	out := buf.String()
+	if n := strings.Count(out, `class="delabel"`); n != 18 {
+		t.Errorf("want 18 labels, got %d", n)
+	}
+	if !strings.Contains(out, `<g class="chamber CH5">`) || !strings.Contains(out, `class="DE517"`) {
+		t.Errorf("missing chamber group or detection element")
+	}
+}
+
+func TestSVGSpectrometer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("drawing all detection elements takes a while")
+	}
+	chambers, cells, err := SpectrometerOutlines(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, outlines := range chambers {
+		b := outlinesBBox(outlines)
+		c := cells[i]
+		if b.Xmin() < c.Xmin() || b.Xmax() > c.Xmax() || b.Ymin() < c.Ymin() || b.Ymax() > c.Ymax() {
+			t.Errorf("chamber %d (%v) is not within its cell (%v)", i+1, b, c)
+		}
+	}
+	w := geo.NewSVGWriter(1024)
+	if err := SVGSpectrometer(true, w); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w.WriteSVG(&buf)
+	if n := strings.Count(buf.String(), `class="delabel"`); n != 156 {
+		t.Errorf("want 156 labels, got %d", n)
+	}
+}
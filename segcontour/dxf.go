@@ -0,0 +1,40 @@
+package segcontour
+
+import (
+	"fmt"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// DXFLayerNames returns the names of the DXF layers used for the contour
+// of the detection element, for one of its dual sampas and for its pads.
+func DXFLayerNames(deid mapping.DEID, dsid mapping.DualSampaID) (de, ds, pads string) {
+	return fmt.Sprintf("DE%d", deid), fmt.Sprintf("DE%d_DS%d", deid, dsid), fmt.Sprintf("DE%d_PADS", deid)
+}
+
+// DXF adds the contour of the cathode, of each of its dual sampas and
+// (if withPads is true) of its pads to a DXF writer, each on its own layer
+// (see DXFLayerNames).
+func DXF(cseg mapping.CathodeSegmentation, w *geo.DXFWriter, withPads bool) {
+	deid := cseg.DetElemID()
+	deLayer, _, padLayer := DXFLayerNames(deid, 0)
+	w.Layer(deLayer, geo.DXFWhite)
+	w.Contour(deLayer, Contour(cseg))
+	for i := 0; i < cseg.NofDualSampas(); i++ {
+		dsid, err := cseg.DualSampaID(i)
+		if err != nil {
+			panic(err)
+		}
+		_, dsLayer, _ := DXFLayerNames(deid, dsid)
+		w.Layer(dsLayer, geo.DXFBlue)
+		w.Contour(dsLayer, GetDualSampaContour(cseg, dsid))
+	}
+	if !withPads {
+		return
+	}
+	w.Layer(padLayer, geo.DXFGray)
+	cseg.ForEachPad(func(padcid mapping.PadCID) {
+		w.Polygon(padLayer, PadPolygon(cseg, padcid))
+	})
+}
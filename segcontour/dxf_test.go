@@ -0,0 +1,68 @@
+package segcontour
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// scaled returns the contour with all coordinates multiplied by s.
+func scaled(c geo.Contour, s float64) geo.Contour {
+	r := make(geo.Contour, len(c))
+	for i, p := range c {
+		r[i] = make(geo.Polygon, len(p))
+		for j, v := range p {
+			r[i][j] = geo.Vertex{X: v.X * s, Y: v.Y * s}
+		}
+	}
+	return r
+}
+
+func TestWKTRoundTrip(t *testing.T) {
+	mapping.ForOneDetectionElementOfEachSegmentationType(func(deid mapping.DEID) {
+		for _, bending := range []bool{true, false} {
+			c := Contour(mapping.NewCathodeSegmentation(deid, bending))
+			back, err := geo.ParseWKT(c.WKT())
+			if err != nil {
+				t.Fatalf("DE%d: %v", deid, err)
+			}
+			if !geo.EqualContour(c, back) || len(c) != len(back) {
+				t.Errorf("DE%d bending=%v: WKT round trip failed", deid, bending)
+			}
+		}
+	})
+}
+
+func TestDXFRoundTrip(t *testing.T) {
+	mapping.ForOneDetectionElementOfEachSegmentationType(func(deid mapping.DEID) {
+		cseg := mapping.NewCathodeSegmentation(deid, false)
+		w := geo.NewDXFWriter()
+		DXF(cseg, w, true)
+		var buf bytes.Buffer
+		if err := w.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		layers, err := geo.ReadDXF(&buf)
+		if err != nil {
+			t.Fatalf("DE%d: %v", deid, err)
+		}
+		deLayer, _, padLayer := DXFLayerNames(deid, 0)
+		// contours are written in millimetres
+		if c := Contour(cseg); !geo.EqualContour(scaled(c, 10), layers[deLayer]) || len(c) != len(layers[deLayer]) {
+			t.Errorf("DE%d: DXF round trip of the contour failed", deid)
+		}
+		dsid, _ := cseg.DualSampaID(0)
+		_, dsLayer, _ := DXFLayerNames(deid, dsid)
+		if !geo.EqualContour(scaled(GetDualSampaContour(cseg, dsid), 10), layers[dsLayer]) {
+			t.Errorf("DE%d: DXF round trip of dual sampa %d failed", deid, dsid)
+		}
+		if n := len(layers[padLayer]); n != cseg.NofPads() {
+			t.Errorf("DE%d: want %d pads, got %d", deid, cseg.NofPads(), n)
+		}
+		if want := 2 + cseg.NofDualSampas(); len(layers) != want {
+			t.Errorf("DE%d: want %d layers, got %d", deid, want, len(layers))
+		}
+	})
+}
@@ -0,0 +1,253 @@
+package segcontour
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// Scale is the way values are mapped to colours.
+type Scale int
+
+const (
+	// LinearScale maps values linearly between the min and the max.
+	LinearScale Scale = iota
+	// LogScale maps the logarithm of the values between the min and the max.
+	// Non positive values are drawn with the colour of the min.
+	LogScale
+)
+
+// ColorMap is a gradient of colours.
+type ColorMap int
+
+const (
+	// Viridis is a perceptually uniform blue-green-yellow colour map.
+	Viridis ColorMap = iota
+	// Hot is a black-red-yellow-white colour map.
+	Hot
+	// Grayscale is a black to white colour map.
+	Grayscale
+	// BlueWhiteRed is a diverging colour map.
+	BlueWhiteRed
+)
+
+type rgb struct {
+	r, g, b float64
+}
+
+var colorMaps = map[ColorMap][]rgb{
+	Viridis:      {{68, 1, 84}, {59, 82, 139}, {33, 145, 140}, {94, 201, 98}, {253, 231, 37}},
+	Hot:          {{0, 0, 0}, {230, 0, 0}, {255, 210, 0}, {255, 255, 255}},
+	Grayscale:    {{0, 0, 0}, {255, 255, 255}},
+	BlueWhiteRed: {{59, 76, 192}, {255, 255, 255}, {180, 4, 38}},
+}
+
+// Color returns the colour (#rrggbb) at position f (in [0,1]) of the colour map.
+func (cm ColorMap) Color(f float64) string {
+	anchors, ok := colorMaps[cm]
+	if !ok {
+		anchors = colorMaps[Viridis]
+	}
+	f = math.Max(0, math.Min(1, f))
+	x := f * float64(len(anchors)-1)
+	i := int(x)
+	if i >= len(anchors)-1 {
+		i = len(anchors) - 2
+	}
+	t := x - float64(i)
+	a, b := anchors[i], anchors[i+1]
+	return fmt.Sprintf("#%02x%02x%02x",
+		int(math.Round(a.r+t*(b.r-a.r))),
+		int(math.Round(a.g+t*(b.g-a.g))),
+		int(math.Round(a.b+t*(b.b-a.b))))
+}
+
+// HeatMapOptions select how values are drawn by the heat map functions.
+type HeatMapOptions struct {
+	Scale    Scale
+	ColorMap ColorMap
+	// Min and Max are the values corresponding to both ends of the colour map.
+	// Values outside of [Min,Max] are clamped. If Min and Max are equal
+	// they are computed from the values.
+	Min, Max float64
+	// Levels is the number of distinct colours used.
+	Levels int
+	// Legend adds a colour bar to the right of the drawing.
+	Legend bool
+}
+
+// DefaultHeatMapOptions are reasonable defaults for heat maps.
+var DefaultHeatMapOptions = HeatMapOptions{
+	Scale:    LinearScale,
+	ColorMap: Viridis,
+	Levels:   64,
+	Legend:   true,
+}
+
+// heatScale maps values to colour levels.
+type heatScale struct {
+	opts     HeatMapOptions
+	min, max float64
+}
+
+func newHeatScale(values []float64, opts HeatMapOptions) heatScale {
+	if opts.Levels < 2 {
+		opts.Levels = 2
+	}
+	s := heatScale{opts: opts, min: opts.Min, max: opts.Max}
+	if s.min == s.max {
+		s.min = math.MaxFloat64
+		s.max = -math.MaxFloat64
+		for _, v := range values {
+			if opts.Scale == LogScale && v <= 0 {
+				continue
+			}
+			s.min = math.Min(s.min, v)
+			s.max = math.Max(s.max, v)
+		}
+		if s.min > s.max {
+			s.min, s.max = 0, 1
+			if opts.Scale == LogScale {
+				s.min = 1
+				s.max = 10
+			}
+		}
+	}
+	if opts.Scale == LogScale && s.min <= 0 {
+		s.min = math.Min(1e-9, s.max/10)
+	}
+	return s
+}
+
+// fraction returns the position of v in [0,1] along the scale.
+func (s heatScale) fraction(v float64) float64 {
+	if s.max <= s.min {
+		return 0
+	}
+	var f float64
+	if s.opts.Scale == LogScale {
+		if v <= 0 {
+			return 0
+		}
+		f = (math.Log(v) - math.Log(s.min)) / (math.Log(s.max) - math.Log(s.min))
+	} else {
+		f = (v - s.min) / (s.max - s.min)
+	}
+	return math.Max(0, math.Min(1, f))
+}
+
+func (s heatScale) level(v float64) int {
+	l := int(s.fraction(v) * float64(s.opts.Levels))
+	if l >= s.opts.Levels {
+		l = s.opts.Levels - 1
+	}
+	return l
+}
+
+// value returns the value at position f in [0,1] along the scale.
+func (s heatScale) value(f float64) float64 {
+	if s.opts.Scale == LogScale {
+		return math.Exp(math.Log(s.min) + f*(math.Log(s.max)-math.Log(s.min)))
+	}
+	return s.min + f*(s.max-s.min)
+}
+
+func (s heatScale) class(l int) string {
+	return fmt.Sprintf("hm%d", l)
+}
+
+// style adds to the writer the CSS classes of all the levels.
+func (s heatScale) style(w geo.Canvas) {
+	for l := 0; l < s.opts.Levels; l++ {
+		f := (float64(l) + 0.5) / float64(s.opts.Levels)
+		w.Style(fmt.Sprintf(".%s{fill:%s;}\n", s.class(l), s.opts.ColorMap.Color(f)))
+	}
+	w.Style(".hmnovalue{fill:none;}\n")
+}
+
+// legend draws a colour bar to the right of the bounding box.
+func (s heatScale) legend(w geo.Canvas, bbox geo.BBox) {
+	w.GroupStart("legend")
+	defer w.GroupEnd()
+	width := 0.05 * math.Max(bbox.Width(), bbox.Height())
+	x := bbox.Xmax() + width
+	h := bbox.Height() / float64(s.opts.Levels)
+	// reserve room for the labels, as texts have no extent in the writer
+	w.RectWithClass(x, bbox.Ymin(), 4*width, bbox.Height(), "hmnovalue")
+	for l := 0; l < s.opts.Levels; l++ {
+		// max is at the top of the screen, i.e. at the lowest y
+		y := bbox.Ymin() + float64(s.opts.Levels-1-l)*h
+		w.RectWithClass(x, y, width, h, s.class(l))
+	}
+	fractions := []float64{0, 0.5, 1}
+	labels := make([]string, len(fractions))
+	// the font size is scaled to the height of the bar, but the labels
+	// must also fit in the room reserved on its right
+	size := 0.06 * bbox.Height()
+	for i, f := range fractions {
+		labels[i] = strconv.FormatFloat(s.value(f), 'g', 3, 64)
+		size = math.Min(size, 2.6*width/geo.TextWidth(labels[i], 1))
+	}
+	for i, f := range fractions {
+		y := bbox.Ymax() - f*bbox.Height()
+		y = math.Max(bbox.Ymin()+size/2, math.Min(bbox.Ymax()-size/2, y))
+		tw := geo.TextWidth(labels[i], size)
+		w.CenteredText(labels[i], x+1.2*width+tw/2, y, size, "hmlabel")
+	}
+}
+
+// SVGHeatMap draws the pads of the cathode filled with a colour depending
+// on their value. Pads without value are only outlined (hmnovalue CSS class).
+func SVGHeatMap(cseg mapping.CathodeSegmentation, w geo.Canvas, values map[mapping.PadCID]float64, opts HeatMapOptions) {
+	v := make([]float64, 0, len(values))
+	for _, x := range values {
+		v = append(v, x)
+	}
+	s := newHeatScale(v, opts)
+	s.style(w)
+	w.GroupStart("heatmap")
+	cseg.ForEachPad(func(padcid mapping.PadCID) {
+		p := PadPolygon(cseg, padcid)
+		class := "hmnovalue"
+		if x, ok := values[padcid]; ok {
+			class = s.class(s.level(x))
+		}
+		w.PolygonWithClass(&p, class)
+	})
+	w.GroupEnd()
+	if s.opts.Legend {
+		s.legend(w, mapping.ComputeBBox(cseg))
+	}
+}
+
+// SVGDualSampaHeatMap draws the dual sampas of the cathode filled with
+// a colour depending on their value.
+func SVGDualSampaHeatMap(cseg mapping.CathodeSegmentation, w geo.Canvas, values map[mapping.DualSampaID]float64, opts HeatMapOptions) {
+	v := make([]float64, 0, len(values))
+	for _, x := range values {
+		v = append(v, x)
+	}
+	s := newHeatScale(v, opts)
+	s.style(w)
+	w.GroupStart("heatmap")
+	for i := 0; i < cseg.NofDualSampas(); i++ {
+		dsid, err := cseg.DualSampaID(i)
+		if err != nil {
+			panic(err)
+		}
+		class := "hmnovalue"
+		if x, ok := values[dsid]; ok {
+			class = s.class(s.level(x))
+		}
+		for _, c := range GetDualSampaContour(cseg, dsid) {
+			w.PolygonWithClass(&c, fmt.Sprintf("%s DS%d", class, dsid))
+		}
+	}
+	w.GroupEnd()
+	if s.opts.Legend {
+		s.legend(w, mapping.ComputeBBox(cseg))
+	}
+}
@@ -0,0 +1,132 @@
+package segcontour
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func TestColorMap(t *testing.T) {
+	for _, tc := range []struct {
+		cm   ColorMap
+		f    float64
+		want string
+	}{
+		{Grayscale, 0, "#000000"},
+		{Grayscale, 1, "#ffffff"},
+		{Grayscale, 2, "#ffffff"},
+		{Grayscale, 0.5, "#808080"},
+		{Viridis, 0, "#440154"},
+		{BlueWhiteRed, 0.5, "#ffffff"},
+	} {
+		if got := tc.cm.Color(tc.f); got != tc.want {
+			t.Errorf("color(%v,%v): want %s, got %s", tc.cm, tc.f, tc.want, got)
+		}
+	}
+}
+
+func TestHeatScale(t *testing.T) {
+	s := newHeatScale([]float64{2, 4, 12}, HeatMapOptions{Levels: 10})
+	if s.min != 2 || s.max != 12 {
+		t.Errorf("want autoscale [2,12], got [%v,%v]", s.min, s.max)
+	}
+	for _, tc := range []struct {
+		v    float64
+		want int
+	}{{-1, 0}, {2, 0}, {7.5, 5}, {12, 9}, {100, 9}} {
+		if l := s.level(tc.v); l != tc.want {
+			t.Errorf("level(%v): want %d, got %d", tc.v, tc.want, l)
+		}
+	}
+	clamped := newHeatScale([]float64{2, 4, 12}, HeatMapOptions{Min: 0, Max: 4, Levels: 4})
+	if l := clamped.level(12); l != 3 {
+		t.Errorf("want clamped level 3, got %d", l)
+	}
+	log := newHeatScale([]float64{0, 1, 100}, HeatMapOptions{Scale: LogScale, Levels: 2})
+	if log.min != 1 || log.max != 100 {
+		t.Errorf("want log autoscale to ignore non positive values, got [%v,%v]", log.min, log.max)
+	}
+	if f := log.fraction(10); math.Abs(f-0.5) > 1e-9 {
+		t.Errorf("want fraction 0.5 for 10 in [1,100] log, got %v", f)
+	}
+	if v := log.value(0.5); math.Abs(v-10) > 1e-9 {
+		t.Errorf("want value 10 at the middle of [1,100] log, got %v", v)
+	}
+}
+
+func TestSVGHeatMap(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, true)
+	values := make(map[mapping.PadCID]float64)
+	cseg.ForEachPadInDualSampa(3, func(padcid mapping.PadCID) {
+		values[padcid] = float64(padcid)
+	})
+	w := geo.NewSVGWriter(1024)
+	opts := DefaultHeatMapOptions
+	opts.Levels = 8
+	SVGHeatMap(cseg, w, values, opts)
+	var buf bytes.Buffer
+	w.WriteHTML(&buf)
+	out := buf.String()
+	if n := strings.Count(out, `<polygon`); n != cseg.NofPads() {
+		t.Errorf("want %d pads, got %d", cseg.NofPads(), n)
+	}
+	if n := strings.Count(out, `class="hmnovalue"`); n != cseg.NofPads()-len(values)+1 {
+		t.Errorf("want %d pads without value (plus legend), got %d", cseg.NofPads()-len(values), n-1)
+	}
+	if !strings.Contains(out, ".hm7{fill:") || !strings.Contains(out, `<g class="legend">`) {
+		t.Errorf("missing styles or legend")
+	}
+	sizes := regexp.MustCompile(`font-size="([^"]*)"[^>]*class="hmlabel"`).FindAllStringSubmatch(out, -1)
+	if len(sizes) != 3 {
+		t.Fatalf("want 3 legend labels, got %d", len(sizes))
+	}
+	h := mapping.ComputeBBox(cseg).Height()
+	for _, m := range sizes {
+		if size, err := strconv.ParseFloat(m[1], 64); err != nil || size <= 0 || size > 0.1*h {
+			t.Errorf("legend font size %s not scaled to the legend height %v", m[1], h)
+		}
+	}
+}
+
+func TestSVGDualSampaHeatMap(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, true)
+	w := geo.NewSVGWriter(1024)
+	opts := DefaultHeatMapOptions
+	opts.Legend = false
+	SVGDualSampaHeatMap(cseg, w, map[mapping.DualSampaID]float64{3: 1, 4: 2}, opts)
+	var buf bytes.Buffer
+	w.WriteSVG(&buf)
+	out := buf.String()
+	if !strings.Contains(out, `class="hm0 DS3"`) || !strings.Contains(out, `class="hm63 DS4"`) {
+		t.Errorf("unexpected dual sampa classes")
+	}
+	if strings.Contains(out, "legend") {
+		t.Errorf("legend not expected")
+	}
+}
+
+func TestRasterHeatMap(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, true)
+	values := make(map[mapping.PadCID]float64)
+	cseg.ForEachPad(func(padcid mapping.PadCID) {
+		values[padcid] = 1
+	})
+	opts := DefaultHeatMapOptions
+	opts.Legend = false
+	opts.ColorMap = Grayscale
+	opts.Min, opts.Max = 0, 2
+	bbox := mapping.ComputeBBox(cseg)
+	r := geo.NewRasterizer(80, 40)
+	r.ViewBox(bbox.Xmin(), bbox.Xmax(), bbox.Ymin(), bbox.Ymax())
+	SVGHeatMap(cseg, r, values, opts)
+	// all the pads have the value in the middle of the scale
+	if c := r.Image().RGBAAt(40, 20); c.R != c.G || c.R < 120 || c.R > 135 {
+		t.Errorf("want a mid gray, got %v", c)
+	}
+}
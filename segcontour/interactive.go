@@ -0,0 +1,71 @@
+package segcontour
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+const interactiveStyle = `
+.pad { fill: #f4f4f4; stroke: #999; stroke-width: 0.03; }
+.pad:hover { fill: #bcd; }
+.polds { fill: none; stroke: #000; stroke-width: 0.1; pointer-events: none; }
+`
+
+// dualSampaGroup is the identifier used to highlight all the pads of a dual sampa.
+func dualSampaGroup(deid mapping.DEID, dsid mapping.DualSampaID) string {
+	return fmt.Sprintf("DE%d-DS%d", deid, dsid)
+}
+
+func svgInteractiveCathode(cseg mapping.CathodeSegmentation, w *geo.SVGWriter, hidden bool) {
+	deid := cseg.DetElemID()
+	plane := mapping.PlaneAbbreviation(cseg.IsBending())
+	class := "cathode"
+	if hidden {
+		class += " hidden"
+	}
+	layer := "non-bending"
+	if cseg.IsBending() {
+		layer = "bending"
+	}
+	w.GroupStartWithAttrs(class, geo.Attr{Name: "data-layer", Value: layer})
+	defer w.GroupEnd()
+	w.GroupStart("pads")
+	cseg.ForEachPad(func(padcid mapping.PadCID) {
+		p := PadPolygon(cseg, padcid)
+		dsid := cseg.PadDualSampaID(padcid)
+		w.PolygonWithAttrs(&p, "pad",
+			geo.Attr{Name: "id", Value: fmt.Sprintf("DE%d-%s-%d", deid, plane, padcid)},
+			geo.Attr{Name: "data-deid", Value: strconv.Itoa(int(deid))},
+			geo.Attr{Name: "data-dsid", Value: strconv.Itoa(int(dsid))},
+			geo.Attr{Name: "data-channel", Value: strconv.Itoa(int(cseg.PadDualSampaChannel(padcid)))},
+			geo.Attr{Name: "data-padcid", Value: strconv.Itoa(int(padcid))},
+			geo.Attr{Name: "data-group", Value: dualSampaGroup(deid, dsid)},
+			geo.Attr{Name: "data-tooltip", Value: strings.TrimSpace(cseg.String(padcid))})
+	})
+	w.GroupEnd()
+	w.GroupStart("dualsampas")
+	for i := 0; i < cseg.NofDualSampas(); i++ {
+		dsid, err := cseg.DualSampaID(i)
+		if err != nil {
+			panic(err)
+		}
+		for _, c := range GetDualSampaContour(cseg, dsid) {
+			w.PolygonWithClass(&c, fmt.Sprintf("polds DS%d", dsid))
+		}
+	}
+	w.GroupEnd()
+}
+
+// SVGInteractiveSegmentation draws both cathodes of a detection element,
+// with the attributes used by geo.SVGWriter.WriteInteractiveHTML : hovering
+// a pad shows its description, clicking it highlights its dual sampa, and
+// each cathode can be shown or hidden (the non-bending one is initially hidden).
+func SVGInteractiveSegmentation(deid mapping.DEID, w *geo.SVGWriter) {
+	w.Style(interactiveStyle)
+	svgInteractiveCathode(mapping.NewCathodeSegmentation(deid, true), w, false)
+	svgInteractiveCathode(mapping.NewCathodeSegmentation(deid, false), w, true)
+}
@@ -0,0 +1,44 @@
+package segcontour
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func TestSVGInteractiveSegmentation(t *testing.T) {
+	w := geo.NewSVGWriter(1024)
+	SVGInteractiveSegmentation(706, w)
+	var buf bytes.Buffer
+	w.WriteInteractiveHTML(&buf)
+	out := buf.String()
+	npads := mapping.NewCathodeSegmentation(706, true).NofPads() + mapping.NewCathodeSegmentation(706, false).NofPads()
+	if n := strings.Count(out, `class="pad"`); n != npads {
+		t.Errorf("want %d pads, got %d", npads, n)
+	}
+	for _, want := range []string{
+		`<g class="cathode" data-layer="bending">`,
+		`<g class="cathode hidden" data-layer="non-bending">`,
+		`id="DE706-B-0"`,
+		`data-group="DE706-DS3"`,
+		`data-tooltip="`,
+		`<script>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	// the viewBox is (xmin,ymin,width,height) and covers the whole detection element
+	b := BBox(mapping.NewCathodeSegmentation(706, true))
+	want := fmt.Sprintf(`viewBox="%v %v %v %v"`, b.Xmin(), b.Ymin(), b.Width(), b.Height())
+	if !strings.Contains(out, want) {
+		t.Errorf("missing %s", want)
+	}
+	if strings.Contains(out, `src=`) || strings.Contains(out, `href=`) {
+		t.Errorf("output must be self-contained")
+	}
+}
@@ -0,0 +1,94 @@
+package segcontour
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// MinLabelPixels is the font size (in pixels of the SVG output)
+// below which labels are not drawn.
+const MinLabelPixels = 4.0
+
+// labeler draws labels whose font size adapts to the room available.
+type labeler struct {
+	w geo.Canvas
+	// pixels per user unit
+	scale float64
+}
+
+func newLabeler(cseg mapping.CathodeSegmentation, w geo.Canvas) labeler {
+	return labeler{w: w, scale: float64(w.Width()) / mapping.ComputeBBox(cseg).Width()}
+}
+
+// label draws text centered on (x,y), with the largest font size such
+// that it fits in a width x height box (and uses at most fill of the height).
+// It returns false if the label is too small to be drawn.
+func (l labeler) label(text string, x, y, width, height, fill float64, class string) bool {
+	size := math.Min(fill*height, 0.8*width/geo.TextWidth(text, 1))
+	if size*l.scale < MinLabelPixels {
+		return false
+	}
+	l.w.CenteredText(text, x, y, size, class)
+	return true
+}
+
+// svgPadChannels draws the dual sampa channel number of each pad.
+func svgPadChannels(cseg mapping.CathodeSegmentation, l labeler) {
+	l.w.GroupStart("padchannels")
+	defer l.w.GroupEnd()
+	cseg.ForEachPad(func(padcid mapping.PadCID) {
+		l.label(strconv.Itoa(int(cseg.PadDualSampaChannel(padcid))),
+			cseg.PadPositionX(padcid), cseg.PadPositionY(padcid),
+			cseg.PadSizeX(padcid), cseg.PadSizeY(padcid), 0.6, "padchannel")
+	})
+}
+
+// dualSampaLabelPosition returns the centroid of the dual sampa, or the
+// center of its pad closest to the centroid if the latter is outside of
+// the dual sampa (e.g. for L-shaped dual sampas).
+func dualSampaLabelPosition(cseg mapping.CathodeSegmentation, dsid mapping.DualSampaID, contour geo.Contour) geo.Vertex {
+	c := contour.Centroid()
+	if contour.Contains(c.X, c.Y) {
+		return c
+	}
+	best := math.MaxFloat64
+	var pos geo.Vertex
+	cseg.ForEachPadInDualSampa(dsid, func(padcid mapping.PadCID) {
+		x, y := cseg.PadPositionX(padcid), cseg.PadPositionY(padcid)
+		if d := (x-c.X)*(x-c.X) + (y-c.Y)*(y-c.Y); d < best {
+			best = d
+			pos = geo.Vertex{X: x, Y: y}
+		}
+	})
+	return pos
+}
+
+// svgDualSampaIDs draws the id of each dual sampa.
+func svgDualSampaIDs(cseg mapping.CathodeSegmentation, l labeler) {
+	l.w.GroupStart("dualsampaids")
+	defer l.w.GroupEnd()
+	for i := 0; i < cseg.NofDualSampas(); i++ {
+		dsid, err := cseg.DualSampaID(i)
+		if err != nil {
+			panic(err)
+		}
+		contour := GetDualSampaContour(cseg, dsid)
+		pos := dualSampaLabelPosition(cseg, dsid, contour)
+		bbox := contour.BBox()
+		l.label(strconv.Itoa(int(dsid)), pos.X, pos.Y, bbox.Width(), bbox.Height(), 0.25, "dualsampaid")
+	}
+}
+
+// svgDETitle draws the detection element id and plane above the drawing.
+func svgDETitle(cseg mapping.CathodeSegmentation, l labeler) {
+	l.w.GroupStart("title")
+	defer l.w.GroupEnd()
+	bbox := mapping.ComputeBBox(cseg)
+	size := 0.05 * math.Max(bbox.Width(), bbox.Height())
+	text := fmt.Sprintf("DE%d %s", cseg.DetElemID(), mapping.PlaneAbbreviation(cseg.IsBending()))
+	l.w.CenteredText(text, bbox.Xcenter(), bbox.Ymin()-size, size, "detitle")
+}
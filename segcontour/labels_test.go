@@ -0,0 +1,54 @@
+package segcontour
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func svgLabels(cseg mapping.CathodeSegmentation, width int, show ShowFlags) string {
+	w := geo.NewSVGWriter(width)
+	SVGSegmentation(cseg, w, show)
+	var buf bytes.Buffer
+	w.WriteSVG(&buf)
+	return buf.String()
+}
+
+func TestPadChannels(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, true)
+	out := svgLabels(cseg, 4096, ShowFlags{PadChannels: true})
+	if n := strings.Count(out, `class="padchannel"`); n != cseg.NofPads() {
+		t.Errorf("want %d pad channel labels, got %d", cseg.NofPads(), n)
+	}
+	// at low resolution the labels do not fit in the pads
+	out = svgLabels(cseg, 256, ShowFlags{PadChannels: true})
+	if n := strings.Count(out, `class="padchannel"`); n != 0 {
+		t.Errorf("want no pad channel label, got %d", n)
+	}
+}
+
+func TestDualSampaIDsAndTitle(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, true)
+	out := svgLabels(cseg, 1024, ShowFlags{DualSampaIDs: true, DETitle: true})
+	if n := strings.Count(out, `class="dualsampaid"`); n != cseg.NofDualSampas() {
+		t.Errorf("want %d dual sampa labels, got %d", cseg.NofDualSampas(), n)
+	}
+	if !strings.Contains(out, `class="detitle">DE706 B</text>`) {
+		t.Errorf("missing title")
+	}
+}
+
+func TestDualSampaLabelIsInsideDualSampa(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(706, true)
+	for i := 0; i < cseg.NofDualSampas(); i++ {
+		dsid, _ := cseg.DualSampaID(i)
+		contour := GetDualSampaContour(cseg, dsid)
+		pos := dualSampaLabelPosition(cseg, dsid, contour)
+		if !contour.Contains(pos.X, pos.Y) {
+			t.Errorf("label of dual sampa %d at (%v,%v) is outside of it", dsid, pos.X, pos.Y)
+		}
+	}
+}
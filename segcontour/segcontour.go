@@ -31,20 +31,30 @@ func Contour(cseg mapping.CathodeSegmentation) geo.Contour {
 func getDualSampaPadPolygons(cseg mapping.CathodeSegmentation, dsid mapping.DualSampaID) []geo.Polygon {
 	var pads []geo.Polygon
 	cseg.ForEachPadInDualSampa(dsid, func(padcid mapping.PadCID) {
-		x := cseg.PadPositionX(padcid)
-		y := cseg.PadPositionY(padcid)
-		dx := cseg.PadSizeX(padcid) / 2
-		dy := cseg.PadSizeY(padcid) / 2
-		pads = append(pads, geo.Polygon{
-			{X: x - dx, Y: y - dy},
-			{X: x + dx, Y: y - dy},
-			{X: x + dx, Y: y + dy},
-			{X: x - dx, Y: y + dy},
-			{X: x - dx, Y: y - dy}})
+		pads = append(pads, PadPolygon(cseg, padcid))
 	})
 	return pads
 }
 
+// PadPolygon returns the outline of one pad.
+func PadPolygon(cseg mapping.CathodeSegmentation, padcid mapping.PadCID) geo.Polygon {
+	return RectanglePolygon(cseg.PadPositionX(padcid), cseg.PadPositionY(padcid),
+		cseg.PadSizeX(padcid), cseg.PadSizeY(padcid))
+}
+
+// RectanglePolygon returns the (closed, counter-clockwise) outline of
+// a rectangle of size (sx,sy) centered on (x,y).
+func RectanglePolygon(x, y, sx, sy float64) geo.Polygon {
+	dx := sx / 2
+	dy := sy / 2
+	return geo.Polygon{
+		{X: x - dx, Y: y - dy},
+		{X: x + dx, Y: y - dy},
+		{X: x + dx, Y: y + dy},
+		{X: x - dx, Y: y + dy},
+		{X: x - dx, Y: y - dy}}
+}
+
 // GetDualSampaContour returns the contour of one FEC.
 func GetDualSampaContour(cseg mapping.CathodeSegmentation, dsid mapping.DualSampaID) geo.Contour {
 	pads := getDualSampaPadPolygons(cseg, dsid)
@@ -1,6 +1,8 @@
 package segcontour
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/mrrtf/pigiron/geo"
@@ -94,3 +96,106 @@ func TestPadSizes(t *testing.T) {
 		t.Errorf("wanted 18 padsizes - got %d", len(padsizes))
 	}
 }
+
+func TestSVGDeadRegions(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(501, true)
+	// one whole dual sampa plus one isolated pad are dead
+	dead := make(map[mapping.PadCID]bool)
+	cseg.ForEachPadInDualSampa(4, func(padcid mapping.PadCID) {
+		dead[padcid] = true
+	})
+	isolated, err := cseg.FindPadByPosition(60, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead[isolated] = true
+	w := geo.NewSVGWriter(1024)
+	err = SVGDeadRegions(cseg, w, func(padcid mapping.PadCID) bool {
+		return dead[padcid]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w.WriteSVG(&buf)
+	if n := strings.Count(buf.String(), `class="dead"`); n != 2 {
+		t.Errorf("expected 2 dead regions and got %d", n)
+	}
+}
+
+func TestSVGSegmentationStream(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(501, true)
+	var buf bytes.Buffer
+	w := geo.NewSVGStreamWriter(&buf, 1024, mapping.ComputeBBox(cseg))
+	w.BatchPaths = true
+	SVGSegmentation(cseg, w, ShowFlags{Pads: true, DEs: true})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if n := strings.Count(out, "M"); n != cseg.NofPads()+1 {
+		t.Errorf("want %d sub-paths (pads and detection element), got %d", cseg.NofPads()+1, n)
+	}
+}
+
+func TestSegmentationAllFormats(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(501, true)
+	w := geo.NewSVGWriter(1024)
+	SVGSegmentation(cseg, w, ShowFlags{Pads: true, DEs: true})
+	var svg, pdf, eps bytes.Buffer
+	w.WriteSVG(&svg)
+	if err := w.WritePDF(&pdf, 842); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteEPS(&eps, 842); err != nil {
+		t.Fatal(err)
+	}
+	n := cseg.NofPads() + 1
+	if got := strings.Count(svg.String(), "<polygon"); got != n {
+		t.Errorf("SVG: want %d polygons, got %d", n, got)
+	}
+	if got := strings.Count(pdf.String(), " m "); got != n {
+		t.Errorf("PDF: want %d paths, got %d", n, got)
+	}
+	if got := strings.Count(eps.String(), "newpath "); got != n {
+		t.Errorf("EPS: want %d paths, got %d", n, got)
+	}
+}
+
+func TestDeadAreaIsDEMinusActiveDualSampas(t *testing.T) {
+	mapping.ForOneDetectionElementOfEachSegmentationType(func(deid mapping.DEID) {
+		for _, isBending := range []bool{true, false} {
+			cseg := mapping.NewCathodeSegmentation(deid, isBending)
+			dead, err := cseg.DualSampaID(cseg.NofDualSampas() / 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var active geo.Contour
+			for i := 0; i < cseg.NofDualSampas(); i++ {
+				dsid, _ := cseg.DualSampaID(i)
+				if dsid != dead {
+					active = append(active, GetDualSampaContour(cseg, dsid)...)
+				}
+			}
+			c, err := geo.Difference(Contour(cseg), active)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// compared with XOR as the coordinates are only equal within EqualFloat
+			diff, err := geo.XOR(c, GetDualSampaContour(cseg, dead))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(c) == 0 || len(diff) != 0 {
+				t.Errorf("DE %d bending %v: DE minus active dual sampas is not dual sampa %d", deid, isBending, dead)
+			}
+			overlap, err := geo.Intersection(c, active)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(overlap) != 0 {
+				t.Errorf("DE %d bending %v: unexpected overlap %v", deid, isBending, overlap)
+			}
+		}
+	})
+}
@@ -13,26 +13,32 @@ type ShowFlags struct {
 	DualSampas  bool
 	Pads        bool
 	PadChannels bool
+	// DualSampaIDs labels each dual sampa with its id
+	DualSampaIDs bool
+	// DETitle adds the detection element id and plane above the drawing
+	DETitle bool
 }
 
-func svgDualSampaPads(w *geo.SVGWriter, dualSampaPads *[][]geo.Polygon) {
+func svgDualSampaPads(w geo.Canvas, dualSampaPads *[][]geo.Polygon) {
 	w.GroupStart("pads")
 	defer w.GroupEnd()
 	for _, dsp := range *dualSampaPads {
 		for _, p := range dsp {
-			w.Polygon(&p)
+			w.PolygonWithClass(&p, "")
 		}
 	}
 }
 
-func svgDetectionElements(w *geo.SVGWriter, de *geo.Contour) {
+func svgDetectionElements(w geo.Canvas, de *geo.Contour) {
 	w.GroupStart("detectionelements")
 	defer w.GroupEnd()
-	w.Contour(de)
+	for _, p := range *de {
+		w.PolygonWithClass(&p, "")
+	}
 }
 
 // SVGSegmentation creates a SVG representation of segmentation
-func SVGSegmentation(cseg mapping.CathodeSegmentation, w *geo.SVGWriter, show ShowFlags) {
+func SVGSegmentation(cseg mapping.CathodeSegmentation, w geo.Canvas, show ShowFlags) {
 	if show.Pads {
 		dualSampaPads := getAllDualSampaPadPolygons(cseg)
 		svgDualSampaPads(w, &dualSampaPads)
@@ -55,4 +61,42 @@ func SVGSegmentation(cseg mapping.CathodeSegmentation, w *geo.SVGWriter, show Sh
 		}
 		w.GroupEnd()
 	}
+	if show.PadChannels || show.DualSampaIDs || show.DETitle {
+		l := newLabeler(cseg, w)
+		if show.PadChannels {
+			svgPadChannels(cseg, l)
+		}
+		if show.DualSampaIDs {
+			svgDualSampaIDs(cseg, l)
+		}
+		if show.DETitle {
+			svgDETitle(cseg, l)
+		}
+	}
+}
+
+// SVGDeadRegions adds to the SVG the outline of the pads for which isDead
+// returns true, merged into contiguous regions, so that they can be shaded
+// on top of a segmentation drawing (using the "dead" CSS class).
+// Nothing is drawn if the regions cannot be computed.
+func SVGDeadRegions(cseg mapping.CathodeSegmentation, w geo.Canvas, isDead func(padcid mapping.PadCID) bool) error {
+	var pads []geo.Polygon
+	cseg.ForEachPad(func(padcid mapping.PadCID) {
+		if isDead(padcid) {
+			pads = append(pads, PadPolygon(cseg, padcid))
+		}
+	})
+	if len(pads) == 0 {
+		return nil
+	}
+	contour, err := geo.NewContour(pads)
+	if err != nil {
+		return err
+	}
+	w.GroupStart("deadregions")
+	defer w.GroupEnd()
+	for _, c := range contour {
+		w.PolygonWithClass(&c, "dead")
+	}
+	return nil
 }
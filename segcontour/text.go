@@ -0,0 +1,176 @@
+package segcontour
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+var ErrInvalidTextWidth = errors.New("text width should be at least 2 columns")
+
+// TextOptions select how WriteText draws a cathode segmentation.
+type TextOptions struct {
+	// Width is the number of columns of the drawing. The number of rows
+	// follows from the aspect ratio of the area drawn.
+	Width int
+	// Area, if not nil, is the part (in local coordinates) of the
+	// detection element to draw. Default is the whole detection element.
+	Area geo.BBox
+	// Highlight is the id of a dual sampa drawn with heavy lines
+	// (0 for none).
+	Highlight mapping.DualSampaID
+	// ASCII restricts the output to ASCII characters (no box-drawing
+	// characters nor shades).
+	ASCII bool
+	// Scale, Min and Max map the pad values to shades,
+	// as for the heat maps (see HeatMapOptions).
+	Scale    Scale
+	Min, Max float64
+}
+
+// charAspect is the height over width ratio of a terminal character.
+const charAspect = 2.0
+
+// textCharset are the characters used to draw.
+type textCharset struct {
+	// lines and heavy are indexed by the set of segments
+	// (1 up, 2 right, 4 down, 8 left) going from the center of a character.
+	lines, heavy [16]rune
+	// shades are the pad values from min to max
+	shades []rune
+	// highlighted fills the highlighted dual sampa when there are no values
+	highlighted rune
+}
+
+var unicodeCharset = textCharset{
+	lines:       [16]rune{' ', '╵', '╶', '└', '╷', '│', '┌', '├', '╴', '┘', '─', '┴', '┐', '┤', '┬', '┼'},
+	heavy:       [16]rune{' ', '╹', '╺', '┗', '╻', '┃', '┏', '┣', '╸', '┛', '━', '┻', '┓', '┫', '┳', '╋'},
+	shades:      []rune{'░', '▒', '▓', '█'},
+	highlighted: '░',
+}
+
+var asciiCharset = textCharset{
+	lines:       [16]rune{' ', '|', '-', '+', '|', '|', '+', '+', '-', '+', '-', '+', '+', '+', '+', '+'},
+	heavy:       [16]rune{' ', 'H', '=', '#', 'H', 'H', '#', '#', '=', '#', '=', '#', '#', '#', '#', '#'},
+	shades:      []rune{'.', ':', 'o', '@'},
+	highlighted: ',',
+}
+
+// textOutside is the region of the points outside of the detection element.
+const textOutside = mapping.DualSampaID(-1)
+
+// WriteText draws, with characters, the dual sampas of a cathode segmentation,
+// the boundaries of the dual sampas (and of the detection element) being
+// drawn with lines and the pads having a value with shades.
+// If values are given a legend line is added below the drawing.
+func WriteText(cseg mapping.CathodeSegmentation, out io.Writer, values map[mapping.PadCID]float64, opts TextOptions) error {
+	if opts.Width < 2 {
+		return ErrInvalidTextWidth
+	}
+	cs := unicodeCharset
+	if opts.ASCII {
+		cs = asciiCharset
+	}
+	area := opts.Area
+	if area == nil {
+		area = BBox(cseg)
+	}
+	// the grid of regions is sampled at the corners of the characters,
+	// with half a character around the area so its edges are visible
+	nx := opts.Width
+	dx := area.Width() / float64(nx-1)
+	dy := dx * charAspect
+	ny := int(math.Ceil(area.Height()/dy)) + 1
+	x0 := area.Xcenter() - float64(nx)*dx/2
+	y0 := area.Ycenter() + float64(ny)*dy/2
+
+	region := func(x, y float64) mapping.DualSampaID {
+		padcid, err := cseg.FindPadByPosition(x, y)
+		if err != nil {
+			return textOutside
+		}
+		return cseg.PadDualSampaID(padcid)
+	}
+	corners := make([][]mapping.DualSampaID, ny+1)
+	for j := range corners {
+		corners[j] = make([]mapping.DualSampaID, nx+1)
+		for i := range corners[j] {
+			corners[j][i] = region(x0+float64(i)*dx, y0-float64(j)*dy)
+		}
+	}
+
+	var vs []float64
+	for _, v := range values {
+		vs = append(vs, v)
+	}
+	scale := newHeatScale(vs, HeatMapOptions{
+		Scale:  opts.Scale,
+		Min:    opts.Min,
+		Max:    opts.Max,
+		Levels: len(cs.shades),
+	})
+
+	h := opts.Highlight
+	b := bufio.NewWriter(out)
+	line := make([]rune, nx)
+	for j := 0; j < ny; j++ {
+		for i := range line {
+			tl, tr := corners[j][i], corners[j][i+1]
+			bl, br := corners[j+1][i], corners[j+1][i+1]
+			segments := 0
+			if tl != tr {
+				segments |= 1
+			}
+			if tr != br {
+				segments |= 2
+			}
+			if bl != br {
+				segments |= 4
+			}
+			if tl != bl {
+				segments |= 8
+			}
+			heavy := h != 0 && (tl == h || tr == h || bl == h || br == h)
+			var c rune
+			switch {
+			case segments != 0 && heavy:
+				c = cs.heavy[segments]
+			case segments != 0:
+				c = cs.lines[segments]
+			default:
+				c = ' '
+				x := x0 + (float64(i)+0.5)*dx
+				y := y0 - (float64(j)+0.5)*dy
+				if padcid, err := cseg.FindPadByPosition(x, y); err == nil {
+					if v, ok := values[padcid]; ok {
+						c = cs.shades[scale.level(v)]
+					} else if len(values) == 0 && heavy {
+						c = cs.highlighted
+					}
+				}
+			}
+			line[i] = c
+		}
+		n := len(line)
+		for n > 0 && line[n-1] == ' ' {
+			n--
+		}
+		b.WriteString(string(line[:n]))
+		b.WriteByte('\n')
+	}
+	if len(values) > 0 {
+		for l, c := range cs.shades {
+			if l > 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(b, "%c %.3g", c, scale.value(float64(l)/float64(len(cs.shades))))
+		}
+		fmt.Fprintf(b, " - %.3g\n", scale.value(1))
+	}
+	return b.Flush()
+}
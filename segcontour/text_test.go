@@ -0,0 +1,93 @@
+package segcontour
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func textLines(t *testing.T, cseg mapping.CathodeSegmentation, values map[mapping.PadCID]float64, opts TextOptions) []string {
+	var buf bytes.Buffer
+	if err := WriteText(cseg, &buf, values, opts); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestWriteText(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(501, false)
+	lines := textLines(t, cseg, nil, TextOptions{Width: 100})
+	// characters are twice as high as wide
+	b := BBox(cseg)
+	if want := int(math.Ceil(b.Height()/(2*b.Width()/99))) + 1; len(lines) != want {
+		t.Errorf("want %d lines, got %d", want, len(lines))
+	}
+	for _, l := range lines {
+		if n := utf8.RuneCountInString(l); n > 100 {
+			t.Errorf("line longer than 100 characters: %d", n)
+		}
+	}
+	if !strings.HasPrefix(lines[0], "┌─") || !strings.HasPrefix(lines[len(lines)-1], "└─") {
+		t.Errorf("expected the detection element outline, got\n%s\n...\n%s", lines[0], lines[len(lines)-1])
+	}
+	text := strings.Join(lines, "\n")
+	if strings.ContainsAny(text, "━┃░") {
+		t.Errorf("unexpected highlight")
+	}
+	if strings.Count(text, "┬") < 10 {
+		t.Errorf("expected dual sampa boundaries")
+	}
+}
+
+func TestWriteTextHighlightASCII(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(501, false)
+	text := strings.Join(textLines(t, cseg, nil, TextOptions{Width: 60, ASCII: true, Highlight: 1025}), "\n")
+	for _, r := range text {
+		if r > 127 {
+			t.Fatalf("unexpected non ASCII character %c", r)
+		}
+	}
+	if !strings.ContainsAny(text, "=H") || !strings.Contains(text, ",") {
+		t.Errorf("expected a highlighted dual sampa, got\n%s", text)
+	}
+}
+
+func TestWriteTextValues(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(100, true)
+	values := make(map[mapping.PadCID]float64)
+	cseg.ForEachPadInDualSampa(2, func(padcid mapping.PadCID) {
+		values[padcid] = cseg.PadPositionY(padcid)
+	})
+	lines := textLines(t, cseg, values, TextOptions{Width: 80})
+	legend := lines[len(lines)-1]
+	if !strings.HasPrefix(legend, "░ ") || !strings.Contains(legend, "█") {
+		t.Errorf("unexpected legend %q", legend)
+	}
+	text := strings.Join(lines[:len(lines)-1], "\n")
+	if !strings.ContainsAny(text, "░▒▓█") {
+		t.Errorf("expected shaded pads")
+	}
+}
+
+func TestWriteTextArea(t *testing.T) {
+	cseg := mapping.NewCathodeSegmentation(100, true)
+	b := GetDualSampaContour(cseg, 2).BBox()
+	area := geo.NewBBoxUnchecked(b.Xmin(), b.Ymin(), b.Xmax(), b.Ymax())
+	all := textLines(t, cseg, nil, TextOptions{Width: 40})
+	zoomed := textLines(t, cseg, nil, TextOptions{Width: 40, Area: area})
+	if len(zoomed) == len(all) {
+		t.Errorf("expected a different aspect ratio when zooming")
+	}
+}
+
+func TestWriteTextInvalidWidth(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteText(mapping.NewCathodeSegmentation(100, true), &buf, nil, TextOptions{Width: 1}); err != ErrInvalidTextWidth {
+		t.Errorf("want ErrInvalidTextWidth, got %v", err)
+	}
+}
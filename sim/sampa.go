@@ -0,0 +1,346 @@
+package sim
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/rof"
+)
+
+// SampaMaxADC is the maximum value of the 10-bits SAMPA ADC.
+const SampaMaxADC = 1<<10 - 1
+
+// nsPerBC is the duration of one bunch crossing
+const nsPerBC = 25.0
+
+// ErrInvalidSampaConfig signals an unusable SAMPA simulation configuration.
+var ErrInvalidSampaConfig = errors.New("invalid SAMPA configuration")
+
+// SampaConfig holds the parameters of the SAMPA simulation.
+type SampaConfig struct {
+	// BCPerSample is the sampling period, in bunch crossings.
+	BCPerSample int
+	// PeakingTime is the peaking time (ns) of the semi-gaussian shaper.
+	PeakingTime float64
+	// ShaperOrder is the order of the semi-gaussian (CR-RC^n) shaper.
+	ShaperOrder int
+	// Baseline is the (constant) baseline of the ADC, in ADC counts.
+	Baseline float64
+	// Noise is the sigma of the noise added to each sample, in ADC counts.
+	Noise float64
+	// ZSThreshold is the zero-suppression threshold, in ADC counts above
+	// the baseline.
+	ZSThreshold int
+	// MinSamples is the minimum number of consecutive samples above
+	// threshold to form a cluster (glitch filter).
+	MinSamples int
+	// PreSamples and PostSamples are the number of samples kept
+	// before and after the samples above threshold.
+	PreSamples  int
+	PostSamples int
+	// ClusterSum is true to only keep the sum of the samples of each
+	// cluster, instead of the samples themselves.
+	ClusterSum bool
+}
+
+// DefaultSampaConfig are reasonable defaults for the SAMPA simulation.
+var DefaultSampaConfig = SampaConfig{
+	BCPerSample: rof.BCPerSample,
+	PeakingTime: 300,
+	ShaperOrder: 4,
+	Baseline:    50,
+	Noise:       1.0,
+	ZSThreshold: 4,
+	MinSamples:  2,
+	PreSamples:  1,
+	PostSamples: 2,
+	ClusterSum:  false,
+}
+
+// SampaCluster is a group of consecutive samples of one channel,
+// as sent by the SAMPA after zero-suppression. The samples are
+// baseline subtracted.
+type SampaCluster struct {
+	Time       int64    // time of the first sample, in bunch crossings since orbit 0
+	Samples    []uint16 // nil in cluster sum mode
+	ChargeSum  uint32
+	NofSamples uint16
+	// Saturated is true if one of the samples reached the maximum value
+	// of the ADC. It is not part of the SAMPA output but is kept to
+	// flag the corresponding digit.
+	Saturated bool
+}
+
+// SampaBC returns the value of the 20-bits SAMPA bunch crossing counter
+// for the first sample of the cluster, assuming the counter was zero at orbit 0.
+func (c SampaCluster) SampaBC() uint32 {
+	return uint32(c.Time % rof.SampaBCCounterRange)
+}
+
+// ChannelSamples is the simulated output of one SAMPA channel.
+type ChannelSamples struct {
+	DEID        mapping.DEID
+	PadUID      mapping.PadUID
+	DualSampaID mapping.DualSampaID
+	Channel     mapping.DualSampaChannelID
+	// Time is the time of the first sample of the train, in bunch
+	// crossings since orbit 0
+	Time int64
+	// Samples is the full sample train (with baseline),
+	// before zero-suppression
+	Samples  []uint16
+	Clusters []SampaCluster
+}
+
+// Digits converts the SAMPA clusters of the channels into digits.
+func Digits(channels []ChannelSamples) []digit.Digit {
+	var digits []digit.Digit
+	for _, ch := range channels {
+		for _, c := range ch.Clusters {
+			d := digit.Digit{
+				DEID:       ch.DEID,
+				PadUID:     ch.PadUID,
+				ADC:        c.ChargeSum,
+				Orbit:      uint32(c.Time / digit.BCPerOrbit),
+				BC:         uint16(c.Time % digit.BCPerOrbit),
+				NofSamples: c.NofSamples,
+				Flags:      digit.Simulated,
+			}
+			if c.Saturated {
+				d.Flags |= digit.Saturated
+			}
+			digits = append(digits, d)
+		}
+	}
+	return digits
+}
+
+// shaper is the response of the semi-gaussian shaper to a unit charge
+// deposited at t=0, normalized to 1 at the peak (t in ns).
+func (cfg SampaConfig) shaper(t float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	u := t / cfg.PeakingTime
+	n := float64(cfg.ShaperOrder)
+	return math.Pow(u, n) * math.Exp(n*(1-u))
+}
+
+// pulseLength is the number of samples after which the shaper
+// response is negligible
+func (cfg SampaConfig) pulseLength() int {
+	period := float64(cfg.BCPerSample) * nsPerBC
+	n := int(math.Ceil(cfg.PeakingTime / period))
+	for cfg.shaper(float64(n)*period) > 1e-4 {
+		n++
+	}
+	return n + 1
+}
+
+// amplitude returns the peak amplitude of a pulse such that the sum
+// of its samples equals q, for a charge deposited dt ns after a sample.
+func (cfg SampaConfig) amplitude(q, dt float64, length int) float64 {
+	period := float64(cfg.BCPerSample) * nsPerBC
+	var sum float64
+	for k := 0; k <= length; k++ {
+		sum += cfg.shaper(float64(k)*period - dt)
+	}
+	if sum <= 0 {
+		return 0
+	}
+	return q / sum
+}
+
+type deposit struct {
+	time int64 // bunch crossings
+	q    float64
+}
+
+type channelKey struct {
+	deid   mapping.DEID
+	paduid mapping.PadUID
+}
+
+// SimulateSamples simulates the sample trains of the SAMPA channels
+// fired by the hits. The charge of each hit is shared among the pads
+// (see Charges), then converted into a pulse of the semi-gaussian shaper
+// whose samples sum up to that charge. The pulses are sampled by the
+// 10-bits ADC, with baseline and noise, and the zero-suppression
+// and, if configured, cluster sum logic of the SAMPA are applied.
+//
+// The returned channels, sorted by detection element, pad and time, are
+// those with at least one cluster, i.e. what the raw data encoder would
+// have to serialize. A channel fired several times, far apart in time,
+// gives one ChannelSamples per (short) sample train.
+func (s *Simulator) SimulateSamples(hits []Hit, cfg SampaConfig) ([]ChannelSamples, error) {
+	if cfg.BCPerSample <= 0 || cfg.PeakingTime <= 0 || cfg.ShaperOrder <= 0 {
+		return nil, ErrInvalidSampaConfig
+	}
+	deposits := make(map[channelKey][]deposit)
+	for _, hit := range hits {
+		t := int64(hit.Orbit)*digit.BCPerOrbit + int64(hit.BC)
+		err := s.Charges(hit, func(paduid mapping.PadUID, q float64) {
+			k := channelKey{hit.DEID, paduid}
+			deposits[k] = append(deposits[k], deposit{t, q})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	keys := make([]channelKey, 0, len(deposits))
+	for k := range deposits {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].deid != keys[j].deid {
+			return keys[i].deid < keys[j].deid
+		}
+		return keys[i].paduid < keys[j].paduid
+	})
+	length := cfg.pulseLength()
+	var channels []ChannelSamples
+	for _, k := range keys {
+		for _, train := range cfg.trains(deposits[k], length) {
+			ch := s.sampleChannel(train, cfg, length)
+			ch.Clusters = cfg.zeroSuppress(ch.Samples, ch.Time)
+			if len(ch.Clusters) == 0 {
+				continue
+			}
+			seg := s.segs.Segmentation(k.deid)
+			ch.DEID = k.deid
+			ch.PadUID = k.paduid
+			ch.DualSampaID = seg.PadDualSampaID(k.paduid)
+			ch.Channel = seg.PadDualSampaChannel(k.paduid)
+			channels = append(channels, ch)
+		}
+	}
+	return channels, nil
+}
+
+// trains sorts the deposits of one channel by time and groups them into
+// sample trains, a new train being started whenever a deposit is too far
+// from the previous one for their (sampled) pulses to overlap.
+func (cfg SampaConfig) trains(deposits []deposit, length int) [][]deposit {
+	sort.Slice(deposits, func(i, j int) bool {
+		return deposits[i].time < deposits[j].time
+	})
+	bps := int64(cfg.BCPerSample)
+	maxGap := int64(length + cfg.PreSamples + cfg.PostSamples + 1)
+	var trains [][]deposit
+	begin := 0
+	for i := 1; i <= len(deposits); i++ {
+		if i == len(deposits) || deposits[i].time/bps-deposits[i-1].time/bps > maxGap {
+			trains = append(trains, deposits[begin:i])
+			begin = i
+		}
+	}
+	return trains
+}
+
+// sampleChannel computes the sample train of one channel, from its
+// time ordered deposits
+func (s *Simulator) sampleChannel(deposits []deposit, cfg SampaConfig, length int) ChannelSamples {
+	bps := int64(cfg.BCPerSample)
+	tmin, tmax := deposits[0].time, deposits[len(deposits)-1].time
+	// the train starts on a sample boundary, a few samples before
+	// the first deposit
+	first := tmin/bps - int64(cfg.PreSamples) - 1
+	if first < 0 {
+		first = 0
+	}
+	n := int(tmax/bps-first) + length + cfg.PostSamples + 1
+	values := make([]float64, n)
+	for _, d := range deposits {
+		// dt is the delay of the deposit relative to the sample k0
+		k0 := d.time/bps - first
+		dt := float64(d.time%bps) * nsPerBC
+		a := cfg.amplitude(d.q, dt, length)
+		for k := int(k0); k < n; k++ {
+			t := float64(int64(k)-k0)*float64(bps)*nsPerBC - dt
+			values[k] += a * cfg.shaper(t)
+		}
+	}
+	ch := ChannelSamples{Time: first * bps, Samples: make([]uint16, n)}
+	for k, v := range values {
+		v += cfg.Baseline
+		if cfg.Noise > 0 {
+			v += s.rng.NormFloat64() * cfg.Noise
+		}
+		adc := math.Round(v)
+		if adc < 0 {
+			adc = 0
+		}
+		if adc > SampaMaxADC {
+			adc = SampaMaxADC
+		}
+		ch.Samples[k] = uint16(adc)
+	}
+	return ch
+}
+
+// zeroSuppress applies the baseline subtraction and zero-suppression of
+// the SAMPA to a sample train starting at the given time.
+func (cfg SampaConfig) zeroSuppress(samples []uint16, time int64) []SampaCluster {
+	baseline := int(math.Round(cfg.Baseline))
+	sub := make([]int, len(samples))
+	for i, s := range samples {
+		sub[i] = int(s) - baseline
+		if sub[i] < 0 {
+			sub[i] = 0
+		}
+	}
+	// find the ranges of samples above threshold, long enough
+	// to pass the glitch filter, extended by pre and post samples
+	type span struct{ begin, end int }
+	var spans []span
+	for i := 0; i < len(sub); {
+		if sub[i] <= cfg.ZSThreshold {
+			i++
+			continue
+		}
+		j := i
+		for j < len(sub) && sub[j] > cfg.ZSThreshold {
+			j++
+		}
+		if j-i >= cfg.MinSamples {
+			b := i - cfg.PreSamples
+			if b < 0 {
+				b = 0
+			}
+			e := j + cfg.PostSamples
+			if e > len(sub) {
+				e = len(sub)
+			}
+			if len(spans) > 0 && b <= spans[len(spans)-1].end {
+				spans[len(spans)-1].end = e
+			} else {
+				spans = append(spans, span{b, e})
+			}
+		}
+		i = j
+	}
+	clusters := make([]SampaCluster, 0, len(spans))
+	for _, sp := range spans {
+		c := SampaCluster{
+			Time:       time + int64(sp.begin*cfg.BCPerSample),
+			NofSamples: uint16(sp.end - sp.begin),
+		}
+		for i, v := range sub[sp.begin:sp.end] {
+			c.ChargeSum += uint32(v)
+			if samples[sp.begin+i] >= SampaMaxADC {
+				c.Saturated = true
+			}
+		}
+		if !cfg.ClusterSum {
+			c.Samples = make([]uint16, 0, sp.end-sp.begin)
+			for _, v := range sub[sp.begin:sp.end] {
+				c.Samples = append(c.Samples, uint16(v))
+			}
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters
+}
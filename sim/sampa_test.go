@@ -0,0 +1,174 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// noNoise is a SAMPA configuration without noise
+func noNoise() SampaConfig {
+	cfg := DefaultSampaConfig
+	cfg.Noise = 0
+	return cfg
+}
+
+func TestShaperPeaksAtPeakingTime(t *testing.T) {
+	cfg := DefaultSampaConfig
+	if v := cfg.shaper(cfg.PeakingTime); v != 1 {
+		t.Errorf("expected 1 at peaking time and got %v", v)
+	}
+	if cfg.shaper(cfg.PeakingTime*0.9) >= 1 || cfg.shaper(cfg.PeakingTime*1.1) >= 1 {
+		t.Errorf("shaper should peak at peaking time")
+	}
+	if cfg.shaper(-10) != 0 {
+		t.Errorf("shaper should be zero before the deposit")
+	}
+}
+
+func TestClusterSumMatchesCharge(t *testing.T) {
+	hit := Hit{DEID: 501, X: 10.2, Y: 3.3, Energy: 1e-5, Orbit: 10, BC: 123}
+	s := NewSimulator(noFluctuations, rand.NewSource(1))
+	charges := make(map[mapping.PadUID]float64)
+	s.Charges(hit, func(paduid mapping.PadUID, q float64) {
+		charges[paduid] = q
+	})
+	channels, err := s.SimulateSamples([]Hit{hit}, noNoise())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) == 0 {
+		t.Fatal("expected some channels")
+	}
+	seg := mapping.NewSegmentation(501)
+	for _, ch := range channels {
+		if len(ch.Clusters) != 1 {
+			t.Fatalf("expected one cluster per channel, got %d", len(ch.Clusters))
+		}
+		c := ch.Clusters[0]
+		if q := charges[ch.PadUID]; math.Abs(float64(c.ChargeSum)-q) > float64(c.NofSamples) {
+			t.Errorf("pad %d : cluster sum %d too far from charge %v", ch.PadUID, c.ChargeSum, q)
+		}
+		if int(c.NofSamples) != len(c.Samples) {
+			t.Errorf("expected %d samples and got %d", c.NofSamples, len(c.Samples))
+		}
+		hitTime := int64(hit.Orbit)*digit.BCPerOrbit + int64(hit.BC)
+		// the cluster must start on a sample boundary, before the peak of the pulse
+		peak := hitTime + int64(DefaultSampaConfig.PeakingTime/nsPerBC)
+		if c.Time > peak || c.Time < hitTime-8 || c.Time%int64(DefaultSampaConfig.BCPerSample) != 0 {
+			t.Errorf("cluster time %d not aligned or too far from the hit time %d", c.Time, hitTime)
+		}
+		if ch.DualSampaID != seg.PadDualSampaID(ch.PadUID) || ch.Channel != seg.PadDualSampaChannel(ch.PadUID) {
+			t.Errorf("wrong electronics for pad %d", ch.PadUID)
+		}
+	}
+}
+
+func TestClusterSumMode(t *testing.T) {
+	hit := Hit{DEID: 501, X: 10.2, Y: 3.3, Energy: 1e-5}
+	cfg := noNoise()
+	samples, _ := NewSimulator(noFluctuations, rand.NewSource(1)).SimulateSamples([]Hit{hit}, cfg)
+	cfg.ClusterSum = true
+	sums, _ := NewSimulator(noFluctuations, rand.NewSource(1)).SimulateSamples([]Hit{hit}, cfg)
+	if len(samples) != len(sums) {
+		t.Fatalf("expected the same channels in both modes")
+	}
+	for i := range sums {
+		a, b := samples[i].Clusters[0], sums[i].Clusters[0]
+		if b.Samples != nil {
+			t.Errorf("expected no samples in cluster sum mode")
+		}
+		if a.ChargeSum != b.ChargeSum || a.NofSamples != b.NofSamples {
+			t.Errorf("expected same cluster sums in both modes")
+		}
+	}
+}
+
+func TestHitsFarApartGiveSeparateTrains(t *testing.T) {
+	// two hits on the same pads, about one hour of beam apart
+	early := Hit{DEID: 501, X: 10.2, Y: 3.3, Energy: 1e-5, Orbit: 10, BC: 123}
+	late := early
+	late.Orbit = 40000000
+	s := NewSimulator(noFluctuations, rand.NewSource(1))
+	single, err := s.SimulateSamples([]Hit{early}, noNoise())
+	if err != nil {
+		t.Fatal(err)
+	}
+	channels, err := NewSimulator(noFluctuations, rand.NewSource(1)).SimulateSamples([]Hit{late, early}, noNoise())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 2*len(single) {
+		t.Fatalf("expected %d channels and got %d", 2*len(single), len(channels))
+	}
+	lateTime := int64(late.Orbit) * digit.BCPerOrbit
+	for i, ch := range channels {
+		if len(ch.Samples) > len(single[0].Samples)+10 {
+			t.Errorf("sample train too long : %d samples", len(ch.Samples))
+		}
+		// sorted by pad then time
+		isLate := ch.Time > lateTime
+		if isLate != (i%2 == 1) || ch.PadUID != single[i/2].PadUID {
+			t.Errorf("channel %d (pad %d, time %d) not in pad and time order", i, ch.PadUID, ch.Time)
+		}
+	}
+}
+
+func TestZeroSuppress(t *testing.T) {
+	cfg := DefaultSampaConfig
+	cfg.Baseline = 10
+	cfg.ZSThreshold = 3
+	cfg.MinSamples = 2
+	cfg.PreSamples = 1
+	cfg.PostSamples = 1
+	samples := []uint16{10, 10, 20, 30, 11, 10, 10, 50, 10, 10, 10, 15, 15, 10, 10, 16, 18, 9}
+	clusters := cfg.zeroSuppress(samples, 100)
+	// the glitch at index 7 is removed, and the ranges [10,14) and [14,18),
+	// once extended by the pre and post samples, are merged
+	expected := []SampaCluster{
+		{Time: 104, Samples: []uint16{0, 10, 20, 1}, ChargeSum: 31, NofSamples: 4},
+		{Time: 140, Samples: []uint16{0, 5, 5, 0, 0, 6, 8, 0}, ChargeSum: 24, NofSamples: 8},
+	}
+	if len(clusters) != len(expected) {
+		t.Fatalf("expected %d clusters and got %d : %v", len(expected), len(clusters), clusters)
+	}
+	for i, c := range clusters {
+		e := expected[i]
+		if c.Time != e.Time || c.ChargeSum != e.ChargeSum || c.NofSamples != e.NofSamples {
+			t.Errorf("cluster %d : expected %v and got %v", i, e, c)
+		}
+		for j := range e.Samples {
+			if c.Samples[j] != e.Samples[j] {
+				t.Errorf("cluster %d : expected %v and got %v", i, e, c)
+				break
+			}
+		}
+	}
+}
+
+func TestSampaSaturation(t *testing.T) {
+	hit := Hit{DEID: 501, X: 10.2, Y: 3.3, Energy: 1e-3}
+	channels, err := NewSimulator(noFluctuations, rand.NewSource(1)).SimulateSamples([]Hit{hit}, noNoise())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, d := range Digits(channels) {
+		if d.IsSaturated() {
+			n++
+		}
+	}
+	if n == 0 {
+		t.Errorf("expected some saturated digits")
+	}
+}
+
+func TestInvalidSampaConfig(t *testing.T) {
+	s := NewSimulator(DefaultConfig, rand.NewSource(1))
+	if _, err := s.SimulateSamples(nil, SampaConfig{}); err != ErrInvalidSampaConfig {
+		t.Errorf("expected ErrInvalidSampaConfig and got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package sim
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	"github.com/mrrtf/pigiron/mathieson"
+)
+
+// Hit is the energy deposited by a particle crossing a detection element.
+type Hit struct {
+	DEID   mapping.DEID
+	X, Y   float64 // local position (cm)
+	Energy float64 // deposited energy (GeV)
+	Orbit  uint32
+	BC     uint16
+}
+
+// ErrInvalidDEID signals a hit on an unknown detection element.
+var ErrInvalidDEID = errors.New("invalid detection element id")
+
+// Config holds the parameters of the simulation.
+type Config struct {
+	// ADCPerGeV converts the deposited energy into the mean total
+	// charge (in ADC counts) seen by each cathode.
+	ADCPerGeV float64
+	// GainSpread is the relative (gaussian) fluctuation of the total
+	// charge of one hit, i.e. of the avalanche gain.
+	GainSpread float64
+	// Noise is the sigma (in ADC counts) of the electronic noise
+	// added to each pad.
+	Noise float64
+	// Threshold is the zero-suppression threshold (in ADC counts) : pads
+	// with a smaller charge do not give a digit.
+	Threshold float64
+	// Saturation is the maximum ADC value of a digit. Digits above
+	// that value are clipped and flagged as saturated. Zero means no
+	// saturation.
+	Saturation uint32
+	// Extent is the half size (in cm) of the area around the hit
+	// where pads are considered.
+	Extent float64
+}
+
+// DefaultConfig are reasonable defaults for the simulation.
+var DefaultConfig = Config{
+	ADCPerGeV:  2e8,
+	GainSpread: 0.2,
+	Noise:      1.0,
+	Threshold:  4.0,
+	Saturation: 1<<20 - 1,
+	Extent:     2.5,
+}
+
+// Simulator converts hits into digits.
+type Simulator struct {
+	cfg  Config
+	rng  *rand.Rand
+	segs mapping.SegCache
+}
+
+// NewSimulator returns a simulator using the given random number source.
+func NewSimulator(cfg Config, src rand.Source) *Simulator {
+	return &Simulator{cfg: cfg, rng: rand.New(src)}
+}
+
+// padKey identifies one pad at a given time
+type padKey struct {
+	deid   mapping.DEID
+	paduid mapping.PadUID
+	orbit  uint32
+	bc     uint16
+}
+
+// Charges returns the charge (in ADC counts, before noise and
+// zero-suppression) induced by one hit on each pad of both cathodes
+// of its detection element. The total charge of the hit is fluctuated
+// to account for the gain fluctuations, then shared among the pads by
+// integrating the Mathieson distribution over each pad surface.
+func (s *Simulator) Charges(hit Hit, handler func(paduid mapping.PadUID, q float64)) error {
+	seg := s.segs.Segmentation(hit.DEID)
+	if seg == nil {
+		return ErrInvalidDEID
+	}
+	m, err := mathieson.ForDetectionElement(hit.DEID)
+	if err != nil {
+		return err
+	}
+	q := hit.Energy * s.cfg.ADCPerGeV
+	if s.cfg.GainSpread > 0 {
+		q *= math.Max(0, 1+s.rng.NormFloat64()*s.cfg.GainSpread)
+	}
+	if q <= 0 {
+		return nil
+	}
+	e := s.cfg.Extent
+	return seg.ForEachPadInArea(hit.X-e, hit.Y-e, hit.X+e, hit.Y+e, func(paduid mapping.PadUID) {
+		if f := m.IntegratePad(seg, paduid, hit.X, hit.Y); f > 0 {
+			handler(paduid, q*f)
+		}
+	})
+}
+
+// Simulate converts hits into digits. The charges of the hits occurring
+// on the same pad at the same time are summed. Noise is then added to each
+// pad and the zero-suppression applied.
+// The returned digits, flagged as Simulated, are sorted by detection
+// element, then by time and pad.
+func (s *Simulator) Simulate(hits []Hit) ([]digit.Digit, error) {
+	charges := make(map[padKey]float64)
+	for _, hit := range hits {
+		err := s.Charges(hit, func(paduid mapping.PadUID, q float64) {
+			charges[padKey{hit.DEID, paduid, hit.Orbit, hit.BC}] += q
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	// loop over the pads in a fixed order so the result only
+	// depends on the random source
+	keys := make([]padKey, 0, len(charges))
+	for k := range charges {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.deid != b.deid {
+			return a.deid < b.deid
+		}
+		if a.orbit != b.orbit {
+			return a.orbit < b.orbit
+		}
+		if a.bc != b.bc {
+			return a.bc < b.bc
+		}
+		return a.paduid < b.paduid
+	})
+	var digits []digit.Digit
+	for _, k := range keys {
+		q := charges[k]
+		if s.cfg.Noise > 0 {
+			q += s.rng.NormFloat64() * s.cfg.Noise
+		}
+		if q < s.cfg.Threshold || q < 0.5 {
+			continue
+		}
+		d := digit.Digit{
+			DEID:   k.deid,
+			PadUID: k.paduid,
+			Orbit:  k.orbit,
+			BC:     k.bc,
+			Flags:  digit.Simulated,
+		}
+		adc := math.Round(q)
+		if s.cfg.Saturation > 0 && adc >= float64(s.cfg.Saturation) {
+			d.ADC = s.cfg.Saturation
+			d.Flags |= digit.Saturated
+		} else {
+			d.ADC = uint32(adc)
+		}
+		digits = append(digits, d)
+	}
+	return digits, nil
+}
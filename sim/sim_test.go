@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/mrrtf/pigiron/cluster"
+	"github.com/mrrtf/pigiron/digit"
+	"github.com/mrrtf/pigiron/mapping"
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+// noFluctuations is a configuration without any random effect
+var noFluctuations = Config{ADCPerGeV: 1e8, Extent: 2.5, Threshold: 0}
+
+func TestChargesSumToTotalChargeOnEachCathode(t *testing.T) {
+	s := NewSimulator(noFluctuations, rand.NewSource(1))
+	for _, test := range []struct {
+		deid mapping.DEID
+		x, y float64
+	}{
+		{100, 30.2, 40.1},
+		{501, 10.2, 3.3},
+		{1025, 10.2, 3.3},
+	} {
+		deid := test.deid
+		seg := mapping.NewSegmentation(deid)
+		var qb, qnb float64
+		err := s.Charges(Hit{DEID: deid, X: test.x, Y: test.y, Energy: 1e-5}, func(paduid mapping.PadUID, q float64) {
+			if seg.IsBendingPad(paduid) {
+				qb += q
+			} else {
+				qnb += q
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(qb-1000) > 1 || math.Abs(qnb-1000) > 1 {
+			t.Errorf("DE %d : expected 1000 on each cathode, got %v and %v", deid, qb, qnb)
+		}
+	}
+}
+
+func TestInvalidDEID(t *testing.T) {
+	s := NewSimulator(DefaultConfig, rand.NewSource(1))
+	if _, err := s.Simulate([]Hit{{DEID: 42, Energy: 1e-5}}); err != ErrInvalidDEID {
+		t.Errorf("expected ErrInvalidDEID and got %v", err)
+	}
+}
+
+func TestZeroSuppression(t *testing.T) {
+	cfg := noFluctuations
+	hit := Hit{DEID: 501, X: 10.2, Y: 3.3, Energy: 1e-5}
+	all, _ := NewSimulator(cfg, rand.NewSource(1)).Simulate([]Hit{hit})
+	cfg.Threshold = 20
+	zs, _ := NewSimulator(cfg, rand.NewSource(1)).Simulate([]Hit{hit})
+	if len(zs) == 0 || len(zs) >= len(all) {
+		t.Fatalf("expected the threshold to remove some digits : %d vs %d", len(zs), len(all))
+	}
+	for _, d := range zs {
+		if d.ADC < 20 {
+			t.Errorf("digit below threshold : %v", d)
+		}
+		if d.Flags&digit.Simulated == 0 {
+			t.Errorf("digit not flagged as simulated : %v", d)
+		}
+	}
+}
+
+func TestSaturation(t *testing.T) {
+	cfg := noFluctuations
+	cfg.Saturation = 100
+	digits, _ := NewSimulator(cfg, rand.NewSource(1)).Simulate([]Hit{{DEID: 501, X: 10.2, Y: 3.3, Energy: 1e-5}})
+	n := 0
+	for _, d := range digits {
+		if d.ADC > 100 {
+			t.Errorf("digit above saturation : %v", d)
+		}
+		if d.IsSaturated() {
+			n++
+		}
+	}
+	if n == 0 {
+		t.Errorf("expected some saturated digits")
+	}
+}
+
+func TestSameSeedSameDigits(t *testing.T) {
+	hits := []Hit{
+		{DEID: 100, X: 30, Y: 40, Energy: 5e-6},
+		{DEID: 100, X: 30.5, Y: 40.2, Energy: 5e-6},
+		{DEID: 1025, X: 7, Y: 2, Energy: 5e-6, Orbit: 2, BC: 10},
+	}
+	a, err := NewSimulator(DefaultConfig, rand.NewSource(42)).Simulate(hits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewSimulator(DefaultConfig, rand.NewSource(42)).Simulate(hits)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed should give same digits")
+	}
+	for i := 1; i < len(a); i++ {
+		if a[i].DEID < a[i-1].DEID {
+			t.Fatalf("digits not sorted by detection element")
+		}
+	}
+}
+
+func TestReconstructedPosition(t *testing.T) {
+	hit := Hit{DEID: 501, X: -12.34, Y: 5.67, Energy: 5e-6}
+	digits, err := NewSimulator(DefaultConfig, rand.NewSource(7)).Simulate([]Hit{hit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := cluster.NewFinder(mapping.NewSegmentation(501), cluster.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := f.FindCluster(digits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(c.X-hit.X) > 0.1 || math.Abs(c.Y-hit.Y) > 0.05 {
+		t.Errorf("reconstructed cluster %v too far from hit %v", c, hit)
+	}
+}
@@ -0,0 +1,77 @@
+package status
+
+import (
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// Segmentation wraps a mapping.Segmentation so that the pad loops
+// (ForEachPad, ForEachPadInDualSampa, ForEachPadInArea) and the neighbour
+// queries skip the pads whose status, at a given time, matches a mask.
+// All the other methods are those of the wrapped segmentation.
+type Segmentation struct {
+	mapping.Segmentation
+	skip []bool
+}
+
+// NewSegmentation returns a segmentation that skips the pads having
+// (at least) one of the flags of mask set at time t.
+// With a zero mask no pad is skipped.
+func NewSegmentation(seg mapping.Segmentation, m *Map, t int64, mask Flag) *Segmentation {
+	s := &Segmentation{Segmentation: seg, skip: make([]bool, seg.NofPads())}
+	if mask == Good || m == nil {
+		return s
+	}
+	seg.ForEachPad(func(paduid mapping.PadUID) {
+		s.skip[paduid] = m.PadStatus(seg, paduid, t)&mask != 0
+	})
+	return s
+}
+
+// IsSkipped returns true if the pad is skipped by the pad loops.
+func (s *Segmentation) IsSkipped(paduid mapping.PadUID) bool {
+	return paduid >= 0 && int(paduid) < len(s.skip) && s.skip[paduid]
+}
+
+// NofSkippedPads returns the number of pads that are skipped.
+func (s *Segmentation) NofSkippedPads() int {
+	n := 0
+	for _, skip := range s.skip {
+		if skip {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Segmentation) filter(padHandler func(paduid mapping.PadUID)) func(paduid mapping.PadUID) {
+	return func(paduid mapping.PadUID) {
+		if !s.IsSkipped(paduid) {
+			padHandler(paduid)
+		}
+	}
+}
+
+func (s *Segmentation) ForEachPad(padHandler func(paduid mapping.PadUID)) {
+	s.Segmentation.ForEachPad(s.filter(padHandler))
+}
+
+func (s *Segmentation) ForEachPadInDualSampa(dualSampaID mapping.DualSampaID, padHandler func(paduid mapping.PadUID)) {
+	s.Segmentation.ForEachPadInDualSampa(dualSampaID, s.filter(padHandler))
+}
+
+func (s *Segmentation) ForEachPadInArea(xmin, ymin, xmax, ymax float64, padHandler func(paduid mapping.PadUID)) error {
+	return s.Segmentation.ForEachPadInArea(xmin, ymin, xmax, ymax, s.filter(padHandler))
+}
+
+// GetNeighbourIDs returns the neighbours of the pad that are not skipped.
+func (s *Segmentation) GetNeighbourIDs(paduid mapping.PadUID, neighbours []int) int {
+	n := s.Segmentation.GetNeighbourIDs(paduid, neighbours)
+	j := 0
+	for i := 0; i < n; i++ {
+		if !s.IsSkipped(mapping.PadUID(neighbours[i])) {
+			neighbours[j] = neighbours[i]
+			j++
+		}
+	}
+	return j
+}
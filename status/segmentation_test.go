@@ -0,0 +1,64 @@
+package status
+
+import (
+	"testing"
+
+	"github.com/mrrtf/pigiron/mapping"
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+func TestSegmentationSkipsMaskedPads(t *testing.T) {
+	seg := mapping.NewSegmentation(501)
+	b, _, err := seg.FindPadPairByPosition(10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dsid := seg.PadDualSampaID(b)
+	m := NewMap()
+	m.Add(Entry{DEID: 501, DualSampaID: dsid, Channel: AllChannels, Flags: HVOff})
+	m.Add(Entry{DEID: 501, DualSampaID: 1027, Channel: 3, Flags: Noisy})
+
+	ndsPads := 0
+	seg.ForEachPadInDualSampa(dsid, func(paduid mapping.PadUID) { ndsPads++ })
+
+	s := NewSegmentation(seg, m, 0, HVOff|Masked)
+	if s.NofSkippedPads() != ndsPads {
+		t.Errorf("expected %d skipped pads and got %d", ndsPads, s.NofSkippedPads())
+	}
+	n := 0
+	s.ForEachPad(func(paduid mapping.PadUID) {
+		n++
+		if s.PadDualSampaID(paduid) == dsid {
+			t.Errorf("pad %d should have been skipped", paduid)
+		}
+	})
+	if n != seg.NofPads()-ndsPads {
+		t.Errorf("expected %d pads and got %d", seg.NofPads()-ndsPads, n)
+	}
+	s.ForEachPadInDualSampa(dsid, func(paduid mapping.PadUID) {
+		t.Errorf("pad %d should have been skipped", paduid)
+	})
+	s.ForEachPadInArea(9, 9, 11, 11, func(paduid mapping.PadUID) {
+		if s.IsSkipped(paduid) {
+			t.Errorf("pad %d should have been skipped", paduid)
+		}
+	})
+	nei := make([]int, 13)
+	nn := s.GetNeighbourIDs(b, nei)
+	for _, p := range nei[:nn] {
+		if s.IsSkipped(mapping.PadUID(p)) {
+			t.Errorf("neighbour %d should have been skipped", p)
+		}
+	}
+}
+
+func TestSegmentationWithoutMask(t *testing.T) {
+	seg := mapping.NewSegmentation(100)
+	m := NewMap()
+	m.Add(Entry{DEID: 100, DualSampaID: 1, Channel: AllChannels, Flags: Dead})
+	s := NewSegmentation(seg, m, 0, Good)
+	if s.NofSkippedPads() != 0 {
+		t.Errorf("expected no skipped pads")
+	}
+	var _ mapping.Segmentation = s
+}
@@ -0,0 +1,257 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mrrtf/pigiron/calib"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// FileFormatVersion is the version of the status map files written by this package.
+const FileFormatVersion = 1
+
+// AllChannels is the channel number used to designate all the channels
+// of a dual sampa.
+const AllChannels mapping.DualSampaChannelID = -1
+
+var (
+	// ErrUnsupportedVersion signals a status map file with an unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported status map file version")
+	// ErrInvalidFlag signals an unknown status flag name.
+	ErrInvalidFlag = errors.New("invalid status flag")
+	// ErrInvalidValidity signals a validity range that ends before it starts.
+	ErrInvalidValidity = errors.New("invalid validity range")
+)
+
+// Flag is a bit set describing the status of a channel.
+type Flag uint8
+
+const (
+	// Dead is a channel that does not give any signal.
+	Dead Flag = 1 << iota
+	// Noisy is a channel with a too large noise.
+	Noisy
+	// Masked is a channel that is explicitly excluded from the readout
+	// or the reconstruction.
+	Masked
+	// HVOff is a channel whose high voltage is off.
+	HVOff
+)
+
+// Good is the status of a channel without any problem.
+const Good Flag = 0
+
+// Unusable is the union of all the flags.
+const Unusable = Dead | Noisy | Masked | HVOff
+
+var flagNames = []string{"dead", "noisy", "masked", "hvoff"}
+
+func (f Flag) String() string {
+	if f == Good {
+		return "good"
+	}
+	var names []string
+	for i, n := range flagNames {
+		if f&(1<<uint(i)) != 0 {
+			names = append(names, n)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// MarshalJSON encodes the flags as a list of names.
+func (f Flag) MarshalJSON() ([]byte, error) {
+	names := []string{}
+	for i, n := range flagNames {
+		if f&(1<<uint(i)) != 0 {
+			names = append(names, n)
+		}
+	}
+	return json.Marshal(names)
+}
+
+// UnmarshalJSON decodes a list of flag names.
+func (f *Flag) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*f = Good
+	for _, name := range names {
+		found := false
+		for i, n := range flagNames {
+			if n == name {
+				*f |= 1 << uint(i)
+				found = true
+			}
+		}
+		if !found {
+			return ErrInvalidFlag
+		}
+	}
+	return nil
+}
+
+// Validity is a time range [Start,End). Times are expressed in any
+// monotonic unit, typically milliseconds since epoch, but must be
+// consistent within one map. An End of zero means the range is not bounded.
+type Validity struct {
+	Start int64
+	End   int64
+}
+
+// Always is a validity range covering all times.
+var Always = Validity{}
+
+// Contains returns true if t is within the validity range.
+func (v Validity) Contains(t int64) bool {
+	return t >= v.Start && (v.End == 0 || t < v.End)
+}
+
+// Entry is the status of one channel, or of a whole dual sampa if
+// Channel is AllChannels, during a given time range.
+type Entry struct {
+	DEID        mapping.DEID
+	DualSampaID mapping.DualSampaID
+	Channel     mapping.DualSampaChannelID
+	Flags       Flag
+	Validity    Validity
+}
+
+// Map is a collection of channel statuses.
+//
+// Entries should only be modified through Add, FromCalibration, Sort and
+// ReadJSON, which keep up to date the index used by Status.
+type Map struct {
+	Version int
+	Entries []Entry
+	// index gives the positions in Entries of the entries of each
+	// channel (or dual sampa, with Channel set to AllChannels)
+	index   map[calib.ChannelKey][]int
+	indexed int // number of entries in the index
+}
+
+// NewMap returns an empty status map.
+func NewMap() *Map {
+	return &Map{Version: FileFormatVersion}
+}
+
+// Add adds the status of one channel (or dual sampa).
+func (m *Map) Add(e Entry) error {
+	if e.Validity.End != 0 && e.Validity.End <= e.Validity.Start {
+		return ErrInvalidValidity
+	}
+	m.Entries = append(m.Entries, e)
+	m.updateIndex()
+	return nil
+}
+
+// updateIndex adds to the index the entries not indexed yet.
+func (m *Map) updateIndex() {
+	if m.index == nil || m.indexed > len(m.Entries) {
+		m.index = make(map[calib.ChannelKey][]int)
+		m.indexed = 0
+	}
+	for i := m.indexed; i < len(m.Entries); i++ {
+		e := m.Entries[i]
+		k := calib.ChannelKey{DEID: e.DEID, DualSampaID: e.DualSampaID, Channel: e.Channel}
+		m.index[k] = append(m.index[k], i)
+	}
+	m.indexed = len(m.Entries)
+}
+
+// Status returns the status of one channel at time t, which is the union
+// of the flags of all the entries for that channel or its dual sampa
+// valid at that time.
+func (m *Map) Status(deid mapping.DEID, dsid mapping.DualSampaID, ch mapping.DualSampaChannelID, t int64) Flag {
+	if m.indexed != len(m.Entries) {
+		m.updateIndex()
+	}
+	f := Good
+	for _, c := range []mapping.DualSampaChannelID{ch, AllChannels} {
+		for _, i := range m.index[calib.ChannelKey{DEID: deid, DualSampaID: dsid, Channel: c}] {
+			if e := m.Entries[i]; e.Validity.Contains(t) {
+				f |= e.Flags
+			}
+		}
+		if ch == AllChannels {
+			break
+		}
+	}
+	return f
+}
+
+// PadStatus returns the status of one pad of a segmentation at time t.
+func (m *Map) PadStatus(seg mapping.Segmentation, paduid mapping.PadUID, t int64) Flag {
+	return m.Status(seg.DetElemID(), seg.PadDualSampaID(paduid), seg.PadDualSampaChannel(paduid), t)
+}
+
+// FromCalibration adds to the map the channels flagged as dead or noisy
+// by a pedestal calibration, with the given validity.
+func (m *Map) FromCalibration(c *calib.Calibration, v Validity) error {
+	for _, p := range c.Pedestals {
+		var f Flag
+		switch p.Status {
+		case calib.Dead:
+			f = Dead
+		case calib.Noisy:
+			f = Noisy
+		default:
+			continue
+		}
+		err := m.Add(Entry{DEID: p.DEID, DualSampaID: p.DualSampaID, Channel: p.Channel, Flags: f, Validity: v})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sort orders the entries by detection element, dual sampa,
+// channel and validity start.
+func (m *Map) Sort() {
+	sort.SliceStable(m.Entries, func(i, j int) bool {
+		a, b := m.Entries[i], m.Entries[j]
+		if a.DEID != b.DEID {
+			return a.DEID < b.DEID
+		}
+		if a.DualSampaID != b.DualSampaID {
+			return a.DualSampaID < b.DualSampaID
+		}
+		if a.Channel != b.Channel {
+			return a.Channel < b.Channel
+		}
+		return a.Validity.Start < b.Validity.Start
+	})
+	m.index = nil
+	m.updateIndex()
+}
+
+// WriteJSON writes the status map in JSON format.
+func (m *Map) WriteJSON(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", " ")
+	return enc.Encode(m)
+}
+
+// ReadJSON reads a status map in JSON format.
+func ReadJSON(in io.Reader) (*Map, error) {
+	var m Map
+	if err := json.NewDecoder(in).Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.Version != FileFormatVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	for _, e := range m.Entries {
+		if e.Validity.End != 0 && e.Validity.End <= e.Validity.Start {
+			return nil, ErrInvalidValidity
+		}
+	}
+	m.updateIndex()
+	return &m, nil
+}
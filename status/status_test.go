@@ -0,0 +1,136 @@
+package status
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mrrtf/pigiron/calib"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+func TestFlagString(t *testing.T) {
+	if s := (Dead | HVOff).String(); s != "dead|hvoff" {
+		t.Errorf("expected dead|hvoff and got %s", s)
+	}
+	if s := Good.String(); s != "good" {
+		t.Errorf("expected good and got %s", s)
+	}
+}
+
+func TestValidity(t *testing.T) {
+	v := Validity{Start: 10, End: 20}
+	for _, test := range []struct {
+		t        int64
+		expected bool
+	}{{9, false}, {10, true}, {19, true}, {20, false}} {
+		if v.Contains(test.t) != test.expected {
+			t.Errorf("%d : expected %v", test.t, test.expected)
+		}
+	}
+	if !Always.Contains(0) || !Always.Contains(1<<62) {
+		t.Errorf("Always should contain all times")
+	}
+	if err := NewMap().Add(Entry{Validity: Validity{Start: 10, End: 10}}); err != ErrInvalidValidity {
+		t.Errorf("expected ErrInvalidValidity and got %v", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	m := NewMap()
+	m.Add(Entry{DEID: 501, DualSampaID: 4, Channel: 10, Flags: Noisy})
+	m.Add(Entry{DEID: 501, DualSampaID: 4, Channel: AllChannels, Flags: HVOff, Validity: Validity{Start: 100, End: 200}})
+	m.Add(Entry{DEID: 501, DualSampaID: 5, Channel: 1, Flags: Masked, Validity: Validity{Start: 150}})
+	for _, test := range []struct {
+		dsid     mapping.DualSampaID
+		ch       mapping.DualSampaChannelID
+		t        int64
+		expected Flag
+	}{
+		{4, 10, 0, Noisy},
+		{4, 10, 150, Noisy | HVOff},
+		{4, 11, 150, HVOff},
+		{4, 11, 200, Good},
+		{5, 1, 100, Good},
+		{5, 1, 1000, Masked},
+	} {
+		if f := m.Status(501, test.dsid, test.ch, test.t); f != test.expected {
+			t.Errorf("%v : expected %v and got %v", test, test.expected, f)
+		}
+	}
+}
+
+func TestStatusAfterSort(t *testing.T) {
+	m := NewMap()
+	m.Add(Entry{DEID: 501, DualSampaID: 5, Channel: 1, Flags: Masked})
+	m.Add(Entry{DEID: 100, DualSampaID: 1, Channel: AllChannels, Flags: Dead})
+	m.Add(Entry{DEID: 100, DualSampaID: 1, Channel: 3, Flags: Noisy})
+	m.Sort()
+	m.Add(Entry{DEID: 100, DualSampaID: 2, Channel: 3, Flags: HVOff})
+	for _, test := range []struct {
+		deid     mapping.DEID
+		dsid     mapping.DualSampaID
+		ch       mapping.DualSampaChannelID
+		expected Flag
+	}{
+		{501, 5, 1, Masked},
+		{100, 1, 3, Dead | Noisy},
+		{100, 1, 4, Dead},
+		{100, 1, AllChannels, Dead},
+		{100, 2, 3, HVOff},
+		{501, 5, 2, Good},
+	} {
+		if f := m.Status(test.deid, test.dsid, test.ch, 0); f != test.expected {
+			t.Errorf("%v : expected %v and got %v", test, test.expected, f)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	m := NewMap()
+	m.Add(Entry{DEID: 501, DualSampaID: 4, Channel: AllChannels, Flags: HVOff | Masked, Validity: Validity{Start: 100, End: 200}})
+	m.Add(Entry{DEID: 100, DualSampaID: 1, Channel: 3, Flags: Dead})
+	m.Sort()
+	if m.Entries[0].DEID != 100 {
+		t.Errorf("entries not sorted")
+	}
+	var buf bytes.Buffer
+	if err := m.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"masked"`) {
+		t.Errorf("expected flags as names in %s", buf.String())
+	}
+	m2, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, m2) {
+		t.Errorf("expected %v and got %v", m, m2)
+	}
+	if _, err := ReadJSON(strings.NewReader(`{"Version":1,"Entries":[{"Flags":["broken"]}]}`)); err != ErrInvalidFlag {
+		t.Errorf("expected ErrInvalidFlag and got %v", err)
+	}
+	if _, err := ReadJSON(strings.NewReader(`{"Version":2}`)); err != ErrUnsupportedVersion {
+		t.Errorf("expected ErrUnsupportedVersion and got %v", err)
+	}
+}
+
+func TestFromCalibration(t *testing.T) {
+	c := &calib.Calibration{Pedestals: []calib.Pedestal{
+		{ChannelKey: calib.ChannelKey{DEID: 100, DualSampaID: 1, Channel: 1}, Status: calib.OK},
+		{ChannelKey: calib.ChannelKey{DEID: 100, DualSampaID: 1, Channel: 2}, Status: calib.Dead},
+		{ChannelKey: calib.ChannelKey{DEID: 100, DualSampaID: 1, Channel: 3}, Status: calib.Noisy},
+	}}
+	m := NewMap()
+	if err := m.FromCalibration(c, Always); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 2 {
+		t.Fatalf("expected 2 entries and got %d", len(m.Entries))
+	}
+	if m.Status(100, 1, 2, 0) != Dead || m.Status(100, 1, 3, 0) != Noisy || m.Status(100, 1, 1, 0) != Good {
+		t.Errorf("wrong statuses from calibration")
+	}
+}
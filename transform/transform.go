@@ -0,0 +1,172 @@
+package transform
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+)
+
+// Transformation is a rigid transformation (rotation then translation)
+// from the local coordinates of a detection element to the global
+// coordinates of the spectrometer.
+type Transformation struct {
+	R [9]float64 // rotation matrix, row-major
+	T [3]float64 // translation
+}
+
+// ErrInvalidDEID signals an unknown detection element.
+var ErrInvalidDEID = errors.New("invalid detection element id")
+
+var (
+	identity = [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1}
+	// 180 degrees around the x axis
+	rotX180 = [9]float64{1, 0, 0, 0, -1, 0, 0, 0, -1}
+	// 180 degrees around the y axis
+	rotY180 = [9]float64{-1, 0, 0, 0, 1, 0, 0, 0, -1}
+	// 180 degrees around the z axis
+	rotZ180 = [9]float64{-1, 0, 0, 0, -1, 0, 0, 0, 1}
+)
+
+// LocalToGlobal converts local coordinates into global ones.
+func (t Transformation) LocalToGlobal(x, y, z float64) (float64, float64, float64) {
+	r := &t.R
+	return r[0]*x + r[1]*y + r[2]*z + t.T[0],
+		r[3]*x + r[4]*y + r[5]*z + t.T[1],
+		r[6]*x + r[7]*y + r[8]*z + t.T[2]
+}
+
+// GlobalToLocal converts global coordinates into local ones.
+func (t Transformation) GlobalToLocal(x, y, z float64) (float64, float64, float64) {
+	r := &t.R
+	x -= t.T[0]
+	y -= t.T[1]
+	z -= t.T[2]
+	// the inverse of a rotation is its transpose
+	return r[0]*x + r[3]*y + r[6]*z,
+		r[1]*x + r[4]*y + r[7]*z,
+		r[2]*x + r[5]*y + r[8]*z
+}
+
+// Polygon returns the (x,y) projection of the polygon p expressed
+// in global coordinates. The orientation of the polygon is kept,
+// i.e. the vertices are reversed if the transformation is a mirror
+// in the (x,y) plane.
+func (t Transformation) Polygon(p geo.Polygon) geo.Polygon {
+	g := make(geo.Polygon, len(p))
+	mirror := t.R[0]*t.R[4]-t.R[1]*t.R[3] < 0
+	for i, v := range p {
+		j := i
+		if mirror {
+			j = len(p) - 1 - i
+		}
+		g[j].X, g[j].Y, _ = t.LocalToGlobal(v.X, v.Y, 0)
+	}
+	return g
+}
+
+// chamberZ is the nominal z position (in cm) of each chamber
+var chamberZ = [10]float64{
+	-526.16, -545.24, -676.4, -695.4, -959.75,
+	-975.25, -1276.25, -1292.75, -1539.25, -1555.75,
+}
+
+// slatYPitch is the (approximate) vertical distance between
+// the centers of two adjacent slats
+const slatYPitch = 38.0
+
+// ChamberZ returns the nominal z position of one chamber (1..10).
+func ChamberZ(chamber int) (float64, error) {
+	if chamber < 1 || chamber > 10 {
+		return 0, ErrInvalidDEID
+	}
+	return chamberZ[chamber-1], nil
+}
+
+// ForDetectionElement returns the transformation of one detection element,
+// for an ideal geometry where :
+//
+// - all detection elements of a chamber are at the nominal chamber z
+//
+// - the quadrants of stations 1 and 2 have their origin on the beam axis,
+// DE x00 being in the (x>0,y>0) quadrant, the others following counter-clockwise
+//
+// - the slats of stations 3 to 5 are stacked vertically with a fixed pitch,
+// their beam side end (where the rounded slats are cut) at x=0,
+// DE x00 being the central one on the x>0 side and the others
+// following counter-clockwise.
+//
+// This is meant for visualization and rough global positions,
+// not for precise alignment.
+func ForDetectionElement(deid mapping.DEID) (Transformation, error) {
+	chamber := int(deid) / 100
+	z, err := ChamberZ(chamber)
+	if err != nil {
+		return Transformation{}, err
+	}
+	i := int(deid) % 100
+	if chamber <= 4 {
+		if i > 3 {
+			return Transformation{}, ErrInvalidDEID
+		}
+		rot := [4][9]float64{identity, rotY180, rotZ180, rotX180}
+		return Transformation{R: rot[i], T: [3]float64{0, 0, z}}, nil
+	}
+	nslats := 26
+	if chamber <= 6 {
+		nslats = 18
+	}
+	if i >= nslats {
+		return Transformation{}, ErrInvalidDEID
+	}
+	xmax, err := slatXmax(deid)
+	if err != nil {
+		return Transformation{}, err
+	}
+	// index of the slat along y, from -nslats/4 to nslats/4
+	n := nslats / 4
+	var iy int
+	rightSide := true
+	switch {
+	case i <= n:
+		iy = i
+	case i <= 3*n+1:
+		iy = 2*n + 1 - i
+		rightSide = false
+	default:
+		iy = i - nslats
+	}
+	y := float64(iy) * slatYPitch
+	if rightSide {
+		if iy >= 0 {
+			return Transformation{R: rotY180, T: [3]float64{xmax, y, z}}, nil
+		}
+		return Transformation{R: rotZ180, T: [3]float64{xmax, y, z}}, nil
+	}
+	if iy >= 0 {
+		return Transformation{R: identity, T: [3]float64{-xmax, y, z}}, nil
+	}
+	return Transformation{R: rotX180, T: [3]float64{-xmax, y, z}}, nil
+}
+
+var (
+	slatXmaxCache = make(map[mapping.DEID]float64)
+	slatXmaxMutex sync.Mutex
+)
+
+// slatXmax returns the right-most local x of a slat.
+func slatXmax(deid mapping.DEID) (float64, error) {
+	slatXmaxMutex.Lock()
+	defer slatXmaxMutex.Unlock()
+	if xmax, ok := slatXmaxCache[deid]; ok {
+		return xmax, nil
+	}
+	cseg := mapping.NewCathodeSegmentation(deid, true)
+	if cseg == nil {
+		return 0, ErrInvalidDEID
+	}
+	xmax := mapping.ComputeBBox(cseg).Xmax()
+	slatXmaxCache[deid] = xmax
+	return xmax, nil
+}
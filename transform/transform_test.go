@@ -0,0 +1,120 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/mrrtf/pigiron/geo"
+	"github.com/mrrtf/pigiron/mapping"
+	_ "github.com/mrrtf/pigiron/mapping/impl4"
+)
+
+func TestLocalToGlobalRoundTrip(t *testing.T) {
+	mapping.ForEachDetectionElement(func(deid mapping.DEID) {
+		tr, err := ForDetectionElement(deid)
+		if err != nil {
+			t.Fatalf("DE %d : %v", deid, err)
+		}
+		gx, gy, gz := tr.LocalToGlobal(12.3, -4.5, 0.6)
+		x, y, z := tr.GlobalToLocal(gx, gy, gz)
+		if !geo.EqualFloat(x, 12.3) || !geo.EqualFloat(y, -4.5) || !geo.EqualFloat(z, 0.6) {
+			t.Errorf("DE %d : round trip failed, got (%v,%v,%v)", deid, x, y, z)
+		}
+	})
+}
+
+func TestQuadrants(t *testing.T) {
+	for _, test := range []struct {
+		deid   mapping.DEID
+		sx, sy float64
+	}{
+		{100, 1, 1},
+		{101, -1, 1},
+		{102, -1, -1},
+		{103, 1, -1},
+		{403, 1, -1},
+	} {
+		tr, err := ForDetectionElement(test.deid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, y, z := tr.LocalToGlobal(10, 20, 0)
+		if x*test.sx <= 0 || y*test.sy <= 0 {
+			t.Errorf("DE %d : (10,20) should go to quadrant (%v,%v), got (%v,%v)", test.deid, test.sx, test.sy, x, y)
+		}
+		zc, _ := ChamberZ(int(test.deid) / 100)
+		if z != zc {
+			t.Errorf("DE %d : expected z %v and got %v", test.deid, zc, z)
+		}
+	}
+}
+
+func TestSlats(t *testing.T) {
+	for _, test := range []struct {
+		deid   mapping.DEID
+		right  bool
+		yindex int
+	}{
+		{500, true, 0},
+		{504, true, 4},
+		{505, false, 4},
+		{509, false, 0},
+		{513, false, -4},
+		{514, true, -4},
+		{517, true, -1},
+		{700, true, 0},
+		{706, true, 6},
+		{713, false, 0},
+		{719, false, -6},
+		{725, true, -1},
+	} {
+		tr, err := ForDetectionElement(test.deid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cseg := mapping.NewCathodeSegmentation(test.deid, true)
+		bbox := mapping.ComputeBBox(cseg)
+		// the beam side end of the slat must be at x=0
+		x, y, _ := tr.LocalToGlobal(bbox.Xmax(), 0, 0)
+		if !geo.EqualFloat(x, 0) {
+			t.Errorf("DE %d : beam side end expected at x=0 and got %v", test.deid, x)
+		}
+		if !geo.EqualFloat(y, float64(test.yindex)*slatYPitch) {
+			t.Errorf("DE %d : expected y %v and got %v", test.deid, float64(test.yindex)*slatYPitch, y)
+		}
+		x, _, _ = tr.LocalToGlobal(bbox.Xcenter(), 0, 0)
+		if (x > 0) != test.right {
+			t.Errorf("DE %d : wrong side", test.deid)
+		}
+	}
+}
+
+func TestInvalidDEID(t *testing.T) {
+	for _, deid := range []mapping.DEID{0, 104, 518, 726, 1100} {
+		if _, err := ForDetectionElement(deid); err != ErrInvalidDEID {
+			t.Errorf("DE %d : expected ErrInvalidDEID and got %v", deid, err)
+		}
+	}
+}
+
+func TestPolygon(t *testing.T) {
+	tr, _ := ForDetectionElement(102)
+	p := tr.Polygon(geo.Polygon{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 2}, {X: 0, Y: 0}})
+	expected := geo.Polygon{{X: 0, Y: 0}, {X: -1, Y: 0}, {X: -1, Y: -2}, {X: 0, Y: 0}}
+	for i := range p {
+		if !geo.EqualVertex(p[i], expected[i]) {
+			t.Errorf("vertex %d : expected %v and got %v", i, expected[i], p[i])
+		}
+	}
+}
+
+func TestPolygonKeepsOrientation(t *testing.T) {
+	// DE 101 is mirrored (180 degrees around y)
+	tr, _ := ForDetectionElement(101)
+	p := tr.Polygon(geo.Polygon{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 2}, {X: 0, Y: 0}})
+	expected := geo.Polygon{{X: 0, Y: 0}, {X: -1, Y: 2}, {X: -1, Y: 0}, {X: 0, Y: 0}}
+	for i := range p {
+		if !geo.EqualVertex(p[i], expected[i]) {
+			t.Errorf("vertex %d : expected %v and got %v", i, expected[i], p[i])
+		}
+	}
+}